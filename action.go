@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+func copyToClipboard(text string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("pbcopy")
+	case "windows":
+		cmd = exec.Command("clip")
+	default:
+		if _, err := exec.LookPath("xclip"); err == nil {
+			cmd = exec.Command("xclip", "-selection", "clipboard")
+		} else {
+			cmd = exec.Command("xsel", "--clipboard", "--input")
+		}
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// parseAction splits prompt input such as "3o", "3 o" or "o 3" into a
+// 1-based result index and an action letter.
+func parseAction(input string) (int, string, error) {
+	input = strings.Replace(strings.TrimSpace(input), " ", "", -1)
+	digits := strings.TrimRight(strings.TrimLeft(input, "ocb"), "ocb")
+	action := strings.Replace(input, digits, "", 1)
+	n, err := strconv.Atoi(digits)
+	if err != nil || len(action) != 1 {
+		return 0, "", fmt.Errorf("invalid input: %q", input)
+	}
+	return n, action, nil
+}
+
+// resultPager moves between the result pages of a keyword in the
+// interactive prompt, fetching the next page in the background while the
+// current one is shown.
+type resultPager struct {
+	keyword string
+	page    int
+	result  QueryResult
+	ttl     time.Duration
+	next    *prefetch
+	// filters narrow the commits shown, each term within the previous
+	// ones. They are kept when moving to another page.
+	filters []string
+}
+
+// visible is the result narrowed by the filters.
+func (p *resultPager) visible() QueryResult {
+	result := p.result
+	for _, word := range p.filters {
+		result.Commits = narrowCommits(result.Commits, word)
+	}
+	return result
+}
+
+func (p *resultPager) display() {
+	showResult(p.visible(), buildUrl(p.keyword, p.page), p.keyword, p.page)
+	if len(p.filters) > 0 {
+		fmt.Printf(tr("  narrowed: %s\n"), strings.Join(append([]string{p.keyword}, p.filters...), " > "))
+	}
+}
+
+func (p *resultPager) show() {
+	p.display()
+	p.next = nil
+	if hasNextPage(p.result, p.page) {
+		p.next = startPrefetch(p.keyword, p.page+1, p.ttl)
+	}
+}
+
+func (p *resultPager) move(page int) {
+	if page < 1 {
+		fmt.Println(tr("already on the first page"))
+		return
+	}
+	var result QueryResult
+	var err error
+	if p.next != nil && p.next.page == page {
+		result, err = p.next.wait()
+	} else {
+		result, err = cachedCrawl(p.keyword, page, p.ttl)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	p.page = page
+	p.result = result
+	p.saveSession()
+	p.show()
+}
+
+// narrow keeps the commits shown whose message also contains word.
+func (p *resultPager) narrow(word string) {
+	p.filters = append(p.filters, word)
+	p.saveSession()
+	p.display()
+}
+
+func (p *resultPager) undo() {
+	if len(p.filters) == 0 {
+		fmt.Println(tr("nothing to undo"))
+		return
+	}
+	p.filters = p.filters[:len(p.filters)-1]
+	p.saveSession()
+	p.display()
+}
+
+// saveSession saves the commits shown, so the numbers of follow-up
+// commands match the prompt.
+func (p *resultPager) saveSession() {
+	if err := saveSession(p.keyword, p.page, p.visible()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func narrowCommits(commits []*commit, word string) []*commit {
+	word = strings.ToLower(word)
+	narrowed := []*commit{}
+	for _, c := range commits {
+		if strings.Contains(strings.ToLower(c.Message), word) {
+			narrowed = append(narrowed, c)
+		}
+	}
+	return narrowed
+}
+
+func promptActions(keyword string, page int, result QueryResult, ttl time.Duration) {
+	pager := &resultPager{keyword: keyword, page: page, result: result, ttl: ttl}
+	if hasNextPage(result, page) {
+		pager.next = startPrefetch(keyword, page+1, ttl)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print(tr("\n[number][o=open, c=copy, b=bookmark], n=next page, p=previous page, /word=narrow, u=undo (q to quit): "))
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" || line == "q" {
+			return
+		}
+
+		commits := pager.visible().Commits
+		switch {
+		case line == "n":
+			pager.move(pager.page + 1)
+		case line == "p":
+			pager.move(pager.page - 1)
+		case line == "u":
+			pager.undo()
+		case strings.HasPrefix(line, "/") && strings.TrimSpace(line[1:]) != "":
+			pager.narrow(strings.TrimSpace(line[1:]))
+		default:
+			n, action, perr := parseAction(line)
+			if perr != nil {
+				fmt.Println(perr)
+			} else if n < 1 || n > len(commits) {
+				fmt.Printf(tr("no such result: %d\n"), n)
+			} else {
+				runAction(commits[n-1], action)
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// pickCommit asks for the number of one of the commits and returns it, or
+// nil when the user quits.
+func pickCommit(commits []*commit) *commit {
+	return pickCommitFrom(os.Stdin, os.Stdout, commits)
+}
+
+// pickCommitFrom is pickCommit reading the answer from in and prompting on
+// out.
+func pickCommitFrom(in io.Reader, out io.Writer, commits []*commit) *commit {
+	if len(commits) == 0 {
+		return nil
+	}
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprint(out, tr("\n[number] to select (q to quit): "))
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" || line == "q" {
+			return nil
+		}
+		if n, aerr := strconv.Atoi(line); aerr == nil && n >= 1 && n <= len(commits) {
+			return commits[n-1]
+		}
+		fmt.Fprintf(out, tr("no such result: %s\n"), line)
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+func runAction(c *commit, action string) {
+	var err error
+	switch action {
+	case "o":
+		err = openBrowser(c.displayURL())
+	case "c":
+		if err = copyToClipboard(c.Message); err == nil {
+			fmt.Println(tr("copied:"), c.Message)
+		}
+	case "b":
+		if err = addBookmark(c); err == nil {
+			fmt.Println(tr("bookmarked:"), c.Message)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
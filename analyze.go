@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/codegangsta/cli"
+	"github.com/mattn/go-runewidth"
+)
+
+// messageStopWords are left out of the word and bigram counts.
+var messageStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "in": true, "into": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "or": true, "so": true, "that": true, "the": true, "this": true, "to": true,
+	"was": true, "were": true, "when": true, "with": true,
+}
+
+// analysisModes are the flags of analyze replacing the word report.
+var analysisModes = []string{"lengths", "conventional", "emoji"}
+
+type termCount struct {
+	Term     string `json:"term"`
+	Messages int    `json:"messages"`
+}
+
+var analyzeCommand = cli.Command{
+	Name:      "analyze",
+	Usage:     "report the words and bigrams most often found with a keyword, or the lengths, conventional commit compliance or emoji of the messages",
+	ArgsUsage: "keyword",
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "top, n",
+			Value: 20,
+			Usage: "number of words and bigrams, or emoji, reported",
+		},
+		cli.BoolFlag{
+			Name:  "chart",
+			Usage: "draw the counts as an ASCII bar chart",
+		},
+		cli.BoolFlag{
+			Name:  "lengths",
+			Usage: "report the distribution of the subject line lengths instead",
+		},
+		cli.BoolFlag{
+			Name:  "conventional",
+			Usage: "report how many messages follow the conventional commit syntax, by type, instead",
+		},
+		cli.BoolFlag{
+			Name:  "emoji",
+			Usage: "report how many messages contain emoji or gitmoji codes, and the most common ones, instead",
+		},
+	}, analysisFlags...),
+	Action: func(c *cli.Context) {
+		keyword := analysisKeyword(c, "analyze")
+		modes := 0
+		for _, mode := range analysisModes {
+			if c.Bool(mode) {
+				modes++
+			}
+		}
+		if modes > 1 {
+			fmt.Fprintf(os.Stderr, "only one of --%s can be used\n", strings.Join(analysisModes, ", --"))
+			os.Exit(1)
+		}
+		commits, err := analysedCommits(c, keyword)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			if len(commits) == 0 {
+				os.Exit(failureExitCode(err))
+			}
+		}
+
+		if c.Bool("lengths") {
+			h := subjectLengths(commits)
+			if c.Bool("json") {
+				printJSON(map[string]interface{}{"keyword": keyword, "lengths": h})
+				return
+			}
+			if len(commits) == 0 {
+				fmt.Println(tr("No Results Found."))
+				return
+			}
+			fmt.Printf("%d messages\n\n", len(commits))
+			printLengthHistogram(h)
+			return
+		}
+		if c.Bool("conventional") {
+			stats := conventionalCompliance(commits)
+			if c.Bool("json") {
+				printJSON(map[string]interface{}{"keyword": keyword, "conventional": stats})
+				return
+			}
+			if len(commits) == 0 {
+				fmt.Println(tr("No Results Found."))
+				return
+			}
+			printConventionalStats(stats)
+			return
+		}
+		if c.Bool("emoji") {
+			stats := emojiUsage(commits)
+			if n := c.Int("top"); n > 0 && len(stats.Emoji) > n {
+				stats.Emoji = stats.Emoji[:n]
+			}
+			if c.Bool("json") {
+				printJSON(map[string]interface{}{"keyword": keyword, "emoji": stats})
+				return
+			}
+			if len(commits) == 0 {
+				fmt.Println(tr("No Results Found."))
+				return
+			}
+			printEmojiUsage(stats)
+			return
+		}
+
+		words, bigrams := cooccurrences(commits, keyword)
+		if n := c.Int("top"); n > 0 {
+			if len(words) > n {
+				words = words[:n]
+			}
+			if len(bigrams) > n {
+				bigrams = bigrams[:n]
+			}
+		}
+		if c.Bool("json") {
+			printJSON(map[string]interface{}{"keyword": keyword, "messages": len(commits), "words": words, "bigrams": bigrams})
+			return
+		}
+		if len(commits) == 0 {
+			fmt.Println(tr("No Results Found."))
+			return
+		}
+		fmt.Printf("%d messages\n\nwords:\n", len(commits))
+		printTermCounts(words, len(commits), c.Bool("chart"))
+		fmt.Println("\nbigrams:")
+		printTermCounts(bigrams, len(commits), c.Bool("chart"))
+	},
+}
+
+func printTermCounts(terms []termCount, messages int, chart bool) {
+	width := 0
+	for _, t := range terms {
+		if w := runewidth.StringWidth(t.Term); w > width {
+			width = w
+		}
+	}
+	for _, t := range terms {
+		line := fmt.Sprintf("  %s %6d %5.1f%%", runewidth.FillRight(t.Term, width), t.Messages, 100*float64(t.Messages)/float64(messages))
+		if chart {
+			line += "  " + bar(t.Messages, terms[0].Messages, 40)
+		}
+		fmt.Println(line)
+	}
+}
+
+// tokenize splits the message into lower cased words.
+func tokenize(message string) []string {
+	words := strings.FieldsFunc(strings.ToLower(message), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+	tokens := []string{}
+	for _, w := range words {
+		if w = strings.Trim(w, "'-"); w != "" {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+// cooccurrences counts the messages each word and bigram appears in,
+// leaving out stop words and the words of the keyword.
+func cooccurrences(commits []*commit, keyword string) (words, bigrams []termCount) {
+	skip := map[string]bool{}
+	for _, w := range tokenize(keyword) {
+		skip[w] = true
+	}
+	wordCounts, bigramCounts := map[string]int{}, map[string]int{}
+	for _, c := range commits {
+		tokens := tokenize(c.Message)
+		seen := map[string]bool{}
+		for i, t := range tokens {
+			if !messageStopWords[t] && !skip[t] && len([]rune(t)) > 1 && !seen[t] {
+				seen[t] = true
+				wordCounts[t]++
+			}
+			if i > 0 && !messageStopWords[tokens[i-1]] && !messageStopWords[t] {
+				b := tokens[i-1] + " " + t
+				if !seen[b] {
+					seen[b] = true
+					bigramCounts[b]++
+				}
+			}
+		}
+	}
+	return sortedTerms(wordCounts), sortedTerms(bigramCounts)
+}
+
+func sortedTerms(counts map[string]int) []termCount {
+	terms := []termCount{}
+	for term, n := range counts {
+		terms = append(terms, termCount{Term: term, Messages: n})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Messages != terms[j].Messages {
+			return terms[i].Messages > terms[j].Messages
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	return terms
+}
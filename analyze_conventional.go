@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// conventionalSubject matches a conventional commit subject line:
+// type(scope)!: description
+var conventionalSubject = regexp.MustCompile(`^([A-Za-z]+)(\([^()]*\))?(!)?: \S`)
+
+type conventionalStats struct {
+	Messages  int         `json:"messages"`
+	Compliant int         `json:"compliant"`
+	Percent   float64     `json:"percent"`
+	Scoped    int         `json:"scoped"`
+	Breaking  int         `json:"breaking"`
+	Types     []termCount `json:"types"`
+}
+
+// conventionalCompliance counts the messages following the conventional
+// commit syntax, by type.
+func conventionalCompliance(commits []*commit) conventionalStats {
+	stats := conventionalStats{Messages: len(commits)}
+	types := map[string]int{}
+	for _, c := range commits {
+		m := conventionalSubject.FindStringSubmatch(firstLine(c.Message))
+		if m == nil {
+			continue
+		}
+		stats.Compliant++
+		types[strings.ToLower(m[1])]++
+		if m[2] != "" {
+			stats.Scoped++
+		}
+		if m[3] != "" || strings.Contains(c.Message, "BREAKING CHANGE:") {
+			stats.Breaking++
+		}
+	}
+	if stats.Messages > 0 {
+		stats.Percent = 100 * float64(stats.Compliant) / float64(stats.Messages)
+	}
+	stats.Types = sortedTerms(types)
+	return stats
+}
+
+func printConventionalStats(stats conventionalStats) {
+	fmt.Printf("%d of %d messages (%.1f%%) follow the conventional commit syntax\n", stats.Compliant, stats.Messages, stats.Percent)
+	if stats.Compliant == 0 {
+		return
+	}
+	fmt.Printf("%d with a scope, %d breaking changes\n\ntypes:\n", stats.Scoped, stats.Breaking)
+	printTermCounts(stats.Types, stats.Compliant, true)
+}
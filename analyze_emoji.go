@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"unicode"
+)
+
+// gitmojiCode matches a :shortcode: as written by gitmoji.
+var gitmojiCode = regexp.MustCompile(`:[a-z0-9_+-]+:`)
+
+// gitmojiEmoji maps the common gitmoji codes to their emoji, so both
+// spellings are counted together.
+var gitmojiEmoji = map[string]string{
+	":art:": "🎨", ":zap:": "⚡", ":fire:": "🔥", ":bug:": "🐛", ":ambulance:": "🚑",
+	":sparkles:": "✨", ":memo:": "📝", ":rocket:": "🚀", ":lipstick:": "💄", ":tada:": "🎉",
+	":white_check_mark:": "✅", ":lock:": "🔒", ":bookmark:": "🔖", ":rotating_light:": "🚨",
+	":construction:": "🚧", ":green_heart:": "💚", ":arrow_up:": "⬆", ":arrow_down:": "⬇",
+	":recycle:": "♻", ":heavy_plus_sign:": "➕", ":heavy_minus_sign:": "➖", ":wrench:": "🔧",
+	":globe_with_meridians:": "🌐", ":pencil2:": "✏", ":poop:": "💩", ":rewind:": "⏪",
+	":twisted_rightwards_arrows:": "🔀", ":package:": "📦", ":truck:": "🚚", ":boom:": "💥",
+	":wastebasket:": "🗑", ":see_no_evil:": "🙈", ":bulb:": "💡", ":beers:": "🍻",
+}
+
+type emojiStats struct {
+	Messages  int         `json:"messages"`
+	WithEmoji int         `json:"with_emoji"`
+	Percent   float64     `json:"percent"`
+	Emoji     []termCount `json:"emoji"`
+}
+
+// isEmoji reports whether r is in one of the emoji blocks.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F300 && r <= 0x1FAFF: // pictographs, emoticons, transport and supplemental symbols
+		return !(r >= 0x1F3FB && r <= 0x1F3FF) // skin tone modifiers
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols and dingbats
+		return unicode.Is(unicode.So, r)
+	case r >= 0x1F000 && r <= 0x1F2FF, r == 0x2B50, r == 0x2B55, r == 0x2B06, r == 0x2B07, r == 0x2B1B, r == 0x2B1C:
+		return unicode.Is(unicode.So, r)
+	}
+	return false
+}
+
+// messageEmoji returns the distinct emoji and gitmoji codes of the message.
+func messageEmoji(message string) []string {
+	seen := map[string]bool{}
+	emoji := []string{}
+	add := func(e string) {
+		if !seen[e] {
+			seen[e] = true
+			emoji = append(emoji, e)
+		}
+	}
+	for _, r := range message {
+		if isEmoji(r) {
+			add(string(r))
+		}
+	}
+	for _, code := range gitmojiCode.FindAllString(message, -1) {
+		if e, ok := gitmojiEmoji[code]; ok {
+			code = e
+		}
+		add(code)
+	}
+	return emoji
+}
+
+// emojiUsage counts the messages containing emoji, and the messages each
+// emoji appears in.
+func emojiUsage(commits []*commit) emojiStats {
+	stats := emojiStats{Messages: len(commits)}
+	counts := map[string]int{}
+	for _, c := range commits {
+		emoji := messageEmoji(c.Message)
+		if len(emoji) > 0 {
+			stats.WithEmoji++
+		}
+		for _, e := range emoji {
+			counts[e]++
+		}
+	}
+	if stats.Messages > 0 {
+		stats.Percent = 100 * float64(stats.WithEmoji) / float64(stats.Messages)
+	}
+	stats.Emoji = sortedTerms(counts)
+	return stats
+}
+
+func printEmojiUsage(stats emojiStats) {
+	fmt.Printf("%d of %d messages (%.1f%%) contain emoji\n", stats.WithEmoji, stats.Messages, stats.Percent)
+	if len(stats.Emoji) == 0 {
+		return
+	}
+	fmt.Println("\nemoji:")
+	printTermCounts(stats.Emoji, stats.Messages, true)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// lengthBucketWidth is the number of lengths of a histogram bucket.
+const lengthBucketWidth = 10
+
+type lengthHistogram struct {
+	Messages int           `json:"messages"`
+	Mean     float64       `json:"mean"`
+	P50      int           `json:"p50"`
+	P90      int           `json:"p90"`
+	Max      int           `json:"max"`
+	Over50   float64       `json:"over_50_percent"`
+	Over72   float64       `json:"over_72_percent"`
+	Buckets  []lengthRange `json:"buckets"`
+}
+
+type lengthRange struct {
+	From     int `json:"from"`
+	To       int `json:"to"`
+	Messages int `json:"messages"`
+}
+
+// percentile returns the p-th percentile of the sorted lengths, by the
+// nearest rank.
+func percentile(sorted []int, p int) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (p*len(sorted)+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// subjectLengths computes the distribution of the lengths, in characters,
+// of the subject lines.
+func subjectLengths(commits []*commit) lengthHistogram {
+	h := lengthHistogram{Messages: len(commits), Buckets: []lengthRange{}}
+	if len(commits) == 0 {
+		return h
+	}
+	lengths := make([]int, len(commits))
+	total, over50, over72 := 0, 0, 0
+	for i, c := range commits {
+		n := utf8.RuneCountInString(firstLine(c.Message))
+		lengths[i] = n
+		total += n
+		if n > 50 {
+			over50++
+		}
+		if n > 72 {
+			over72++
+		}
+	}
+	sort.Ints(lengths)
+	h.Mean = float64(total) / float64(len(lengths))
+	h.P50 = percentile(lengths, 50)
+	h.P90 = percentile(lengths, 90)
+	h.Max = lengths[len(lengths)-1]
+	h.Over50 = 100 * float64(over50) / float64(len(lengths))
+	h.Over72 = 100 * float64(over72) / float64(len(lengths))
+
+	for from := 0; from <= h.Max; from += lengthBucketWidth {
+		h.Buckets = append(h.Buckets, lengthRange{From: from, To: from + lengthBucketWidth - 1})
+	}
+	for _, n := range lengths {
+		h.Buckets[n/lengthBucketWidth].Messages++
+	}
+	return h
+}
+
+func printLengthHistogram(h lengthHistogram) {
+	most := 0
+	for _, b := range h.Buckets {
+		if b.Messages > most {
+			most = b.Messages
+		}
+	}
+	for _, b := range h.Buckets {
+		fmt.Printf("  %3d-%-3d %6d %5.1f%%  %s\n", b.From, b.To, b.Messages, 100*float64(b.Messages)/float64(h.Messages), bar(b.Messages, most, 40))
+	}
+	fmt.Printf("\nmean %.1f, p50 %d, p90 %d, max %d\n", h.Mean, h.P50, h.P90, h.Max)
+	fmt.Printf("%.1f%% over 50 characters, %.1f%% over 72\n", h.Over50, h.Over72)
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestPercentile(t *testing.T) {
+	sorted := []int{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	for _, tt := range []struct {
+		values []int
+		p      int
+		want   int
+	}{
+		{nil, 50, 0},
+		{[]int{}, 90, 0},
+		{sorted, 0, 10},
+		{sorted, 10, 10},
+		{sorted, 50, 50},
+		{sorted, 51, 60},
+		{sorted, 90, 90},
+		{sorted, 100, 100},
+		{[]int{42}, 0, 42},
+		{[]int{42}, 100, 42},
+	} {
+		if got := percentile(tt.values, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %d) = %d, want %d", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
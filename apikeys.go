@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const apiUsageFile = "api_usage.json"
+
+// apiKeyConfig is an [api_keys.<name>] section of the config. A quota of
+// 0 is unlimited.
+type apiKeyConfig struct {
+	Key        string `toml:"key"`
+	DailyQuota int    `toml:"daily_quota"`
+}
+
+var apiKeySettings map[string]apiKeyConfig
+
+// keyUsage is the usage of an API key, kept in api_usage.json.
+type keyUsage struct {
+	Day      string    `json:"day"`
+	Today    int       `json:"today"`
+	Total    int64     `json:"total"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// apiKeys authenticates the requests to serve when API keys are
+// configured, and counts them against the daily quota of the key. A nil
+// *apiKeys lets every request through.
+type apiKeys struct {
+	keys map[string]apiKeyConfig
+
+	mu    sync.Mutex
+	usage map[string]*keyUsage
+	dirty bool
+}
+
+func newAPIKeys(keys map[string]apiKeyConfig) (*apiKeys, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	for name, k := range keys {
+		if k.Key == "" {
+			key, err := keyringAPIKey(name)
+			if err != nil {
+				return nil, fmt.Errorf("api_keys.%s: key is empty and not in the keyring (auth login %s%s): %v", name, apiKeyPrefix, name, err)
+			}
+			k.Key = key
+			keys[name] = k
+		}
+	}
+	a := &apiKeys{keys: keys, usage: map[string]*keyUsage{}}
+	if err := loadJSON(dataPath(apiUsageFile), &a.usage); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// lookup returns the name of the key, comparing in constant time.
+func (a *apiKeys) lookup(key string) (string, bool) {
+	found := ""
+	for name, k := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			found = name
+		}
+	}
+	return found, found != ""
+}
+
+// use counts a request of the key, failing when its quota for the day
+// (in UTC) is used up. It returns the requests left today, or -1 without
+// a quota.
+func (a *apiKeys) use(name string) (int, error) {
+	today := time.Now().UTC().Format("2006-01-02")
+	quota := a.keys[name].DailyQuota
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	u, ok := a.usage[name]
+	if !ok {
+		u = &keyUsage{}
+		a.usage[name] = u
+	}
+	if u.Day != today {
+		u.Day, u.Today = today, 0
+	}
+	if quota > 0 && u.Today >= quota {
+		return 0, fmt.Errorf("daily quota of %d requests exceeded", quota)
+	}
+	u.Today++
+	u.Total++
+	u.LastUsed = time.Now()
+	a.dirty = true
+	apiKeyRequests.WithLabelValues(name).Inc()
+	if quota == 0 {
+		return -1, nil
+	}
+	return quota - u.Today, nil
+}
+
+func (a *apiKeys) usageOf(name string) keyUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if u, ok := a.usage[name]; ok {
+		return *u
+	}
+	return keyUsage{}
+}
+
+// save writes the usage when it changed since the last save.
+func (a *apiKeys) save() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.dirty {
+		return
+	}
+	if err := saveJSON(dataPath(apiUsageFile), a.usage); err != nil {
+		logger.Error("failed to save api key usage", "error", err)
+		return
+	}
+	a.dirty = false
+}
+
+// saveEvery saves the usage every interval until stop is closed.
+func (a *apiKeys) saveEvery(interval time.Duration, stop <-chan struct{}) {
+	if a == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.save()
+		case <-stop:
+			a.save()
+			return
+		}
+	}
+}
+
+func untilTomorrow() time.Duration {
+	now := time.Now().UTC()
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
+
+// requestKey is the key given as "Authorization: Bearer <key>", in an
+// X-API-Key header, or as the api_key parameter for clients such as feed
+// readers that cannot set headers.
+func requestKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return firstNonEmpty(r.Header.Get("X-API-Key"), r.URL.Query().Get("api_key"))
+}
+
+// require rejects requests without a valid key with 401 Unauthorized, and
+// those over the quota of their key with 429 Too Many Requests.
+func (a *apiKeys) require(h http.Handler) http.Handler {
+	if a == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := a.lookup(requestKey(r))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gommit-m"`)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("a valid api key is required"))
+			return
+		}
+		left, err := a.use(name)
+		if err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(untilTomorrow()/time.Second)))
+			writeError(w, http.StatusTooManyRequests, err)
+			return
+		}
+		if left >= 0 {
+			w.Header().Set("X-Quota-Limit", strconv.Itoa(a.keys[name].DailyQuota))
+			w.Header().Set("X-Quota-Remaining", strconv.Itoa(left))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// usageHandler serves GET /usage: the usage of the key of the request.
+func (a *apiKeys) usageHandler(w http.ResponseWriter, r *http.Request) {
+	if a == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no api keys configured"))
+		return
+	}
+	name, ok := a.lookup(requestKey(r))
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gommit-m"`)
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("a valid api key is required"))
+		return
+	}
+	u := a.usageOf(name)
+	if u.Day != time.Now().UTC().Format("2006-01-02") {
+		u.Today = 0
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":        name,
+		"daily_quota": a.keys[name].DailyQuota,
+		"today":       u.Today,
+		"total":       u.Total,
+		"last_used":   u.LastUsed,
+	})
+}
+
+// grpcAllow checks the key in the x-api-key or authorization metadata of
+// a call.
+func (a *apiKeys) grpcAllow(ctx context.Context) error {
+	if a == nil {
+		return nil
+	}
+	key := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-api-key"); len(v) > 0 {
+			key = v[0]
+		} else if v := md.Get("authorization"); len(v) > 0 {
+			key = strings.TrimPrefix(v[0], "Bearer ")
+		}
+	}
+	name, ok := a.lookup(key)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "a valid api key is required")
+	}
+	if _, err := a.use(name); err != nil {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+var authFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "auth-user",
+		EnvVar: "GOMMITM_AUTH_USER",
+		Usage:  "basic auth user for a private --endpoint",
+	},
+	cli.StringFlag{
+		Name:   "auth-password",
+		EnvVar: "GOMMITM_AUTH_PASSWORD",
+		Usage:  "basic auth password for a private --endpoint",
+	},
+	cli.StringFlag{
+		Name:   "auth-token",
+		EnvVar: "GOMMITM_AUTH_TOKEN",
+		Usage:  "bearer token for a private --endpoint",
+	},
+	cli.StringSliceFlag{
+		Name:  "header, H",
+		Value: &cli.StringSlice{},
+		Usage: "extra 'Name: value' header sent to --endpoint (repeatable)",
+	},
+}
+
+// endpointHeader holds the headers added to requests for the endpoint.
+var endpointHeader http.Header
+
+// authTransport adds credentials and headers to the requests for one
+// host, so they are never sent to GitHub or the other services.
+type authTransport struct {
+	base   http.RoundTripper
+	host   string
+	header http.Header
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
+
+// setAuth installs the credentials and headers given for the endpoint.
+func setAuth(c *cli.Context) error {
+	header := http.Header{}
+	for _, h := range c.StringSlice("header") {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return fmt.Errorf("invalid header %q, want 'Name: value'", h)
+		}
+		header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+	if token := c.String("auth-token"); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	} else if user := c.String("auth-user"); user != "" {
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(user, c.String("auth-password"))
+		header.Set("Authorization", req.Header.Get("Authorization"))
+	}
+	if len(header) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %s", err)
+	}
+	endpointHeader = header
+	http.DefaultTransport = &authTransport{base: http.DefaultTransport, host: u.Host, header: header}
+	return nil
+}
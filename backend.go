@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+// Backend is a source of commit messages. The CLI only searches through
+// backend, so another source can be added by implementing it.
+type Backend interface {
+	// Name identifies the backend in messages.
+	Name() string
+	// URL is the address of a page of results. It is shown with the
+	// results and used as the cache key.
+	URL(query string, page int) string
+	Search(ctx context.Context, query string, page int) (QueryResult, error)
+}
+
+// backend is the backend searched.
+var backend Backend = commitM{}
+
+var backendFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "backend",
+		EnvVar: "GOMMITM_BACKEND",
+		Usage:  "where to search: commit-m, github, gitlab, bitbucket, sourcegraph, grep.app, a plugin name, a comma separated list or all",
+	},
+	cli.StringFlag{
+		Name:   "gitlab-token",
+		EnvVar: "GITLAB_TOKEN",
+		Usage:  "GitLab personal access token for --backend gitlab",
+	},
+	cli.StringFlag{
+		Name:  "gitlab-url",
+		Value: gitlabDefaultEndpoint,
+		Usage: "GitLab instance searched by --backend gitlab",
+	},
+	cli.StringFlag{
+		Name:  "gitlab-project",
+		Usage: "search only this GitLab project (id or group/name)",
+	},
+	cli.StringFlag{
+		Name:  "bitbucket-workspace",
+		Usage: "Bitbucket Cloud workspace searched by --backend bitbucket",
+	},
+	cli.StringFlag{
+		Name:   "bitbucket-token",
+		EnvVar: "BITBUCKET_TOKEN",
+		Usage:  "Bitbucket access token, or user:app-password",
+	},
+	cli.IntFlag{
+		Name:  "bitbucket-depth",
+		Value: 500,
+		Usage: "number of latest commits searched in each Bitbucket repository",
+	},
+	cli.StringFlag{
+		Name:   "sourcegraph-token",
+		EnvVar: "SRC_ACCESS_TOKEN",
+		Usage:  "Sourcegraph access token for --backend sourcegraph",
+	},
+	cli.StringFlag{
+		Name:   "sourcegraph-url",
+		Value:  sourcegraphDefaultEndpoint,
+		EnvVar: "SRC_ENDPOINT",
+		Usage:  "Sourcegraph instance searched by --backend sourcegraph",
+	},
+	cli.StringFlag{
+		Name:  "sourcegraph-filters",
+		Usage: "filters added to the Sourcegraph query, e.g. 'author:alice repo:^github.com/org/'",
+	},
+	cli.BoolFlag{
+		Name:  "sourcegraph-diff",
+		Usage: "search the diffs instead of the messages on Sourcegraph",
+	},
+	cli.StringFlag{
+		Name:   "fallback-backend",
+		EnvVar: "GOMMITM_FALLBACK_BACKEND",
+		Usage:  "backend searched when --backend fails, e.g. github",
+	},
+	cli.DurationFlag{
+		Name:  "fallback-after",
+		Usage: "give up on --backend after this long and search --fallback-backend (default --timeout)",
+	},
+	cli.StringFlag{
+		Name:   "remote",
+		EnvVar: "GOMMITM_REMOTE",
+		Usage:  "search through a gommit-m serve instance, e.g. http://host:8080, instead of --backend",
+	},
+	cli.StringFlag{
+		Name:   "remote-key",
+		EnvVar: "GOMMITM_REMOTE_KEY",
+		Usage:  "API key for --remote",
+	},
+}
+
+// selectBackend sets backend from --backend and the options of the
+// selected backends. --backend takes a comma separated list, or "all", to
+// search several backends at once. --remote replaces --backend.
+func selectBackend(c *cli.Context) error {
+	names := strings.Split(c.String("backend"), ",")
+	if c.String("backend") == "all" {
+		names = []string{"commit-m", "github", "gitlab", "sourcegraph", "grep.app"}
+		if c.String("bitbucket-workspace") != "" {
+			names = append(names, "bitbucket")
+		}
+	}
+	backends := []Backend{}
+	if remote := c.String("remote"); remote != "" {
+		names = nil
+		backends = append(backends, newRemoteServer(remote, c.String("remote-key")))
+	}
+	for _, name := range names {
+		b, err := newBackend(c, strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		backends = append(backends, b)
+	}
+	if len(backends) == 1 {
+		backend = backends[0]
+	} else {
+		backend = federated(backends)
+	}
+
+	if name := c.String("fallback-backend"); name != "" {
+		fallback, err := newBackend(c, name)
+		if err != nil {
+			return err
+		}
+		backend = withFallback{primary: backend, fallback: fallback, timeout: c.Duration("fallback-after")}
+	}
+	return nil
+}
+
+// newBackend returns the backend called name. --gitlab-url and
+// --sourcegraph-url give the instance of self-hosted backends.
+func newBackend(c *cli.Context, name string) (Backend, error) {
+	switch name {
+	case "", "commit-m":
+		return commitM{}, nil
+	case "github":
+		return githubSearch{client: newGithubClient(githubToken(c))}, nil
+	case "gitlab":
+		return newGitlabSearch(c.String("gitlab-url"), c.String("gitlab-token"), c.String("gitlab-project")), nil
+	case "bitbucket":
+		return newBitbucketSearch(c.String("bitbucket-workspace"), c.String("bitbucket-token"), c.Int("bitbucket-depth")), nil
+	case "sourcegraph":
+		return newSourcegraphSearch(c.String("sourcegraph-url"),
+			c.String("sourcegraph-token"), c.String("sourcegraph-filters"), c.Bool("sourcegraph-diff")), nil
+	case "grep.app", "grepapp":
+		return grepApp{}, nil
+	}
+	return findPlugin(name)
+}
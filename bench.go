@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var benchCommand = cli.Command{
+	Name:      "bench",
+	Usage:     "fetch result pages of a keyword and report the fetch latency, parse time and throughput",
+	ArgsUsage: "keyword",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "pages",
+			Value: 3,
+			Usage: "number of pages fetched, from page 1, without politeness delay",
+		},
+		cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "pages fetched at a time (default --concurrency of gommit-m)",
+		},
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Usage: "fetch the pages through the cache with this ttl, instead of from the backend",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the report as json",
+		},
+	},
+	Action: func(c *cli.Context) {
+		keyword := analysisKeyword(c, "bench")
+		workers := concurrency
+		if c.IsSet("concurrency") {
+			workers = c.Int("concurrency")
+		}
+		if c.Int("pages") < 1 || workers < 1 {
+			fmt.Fprintln(os.Stderr, "--pages and --concurrency must be at least 1")
+			os.Exit(1)
+		}
+		report := bench(keyword, c.Int("pages"), workers, c.Duration("cache-ttl"))
+		if c.Bool("json") {
+			printJSON(report)
+		} else {
+			printBenchReport(report)
+		}
+		if report.Errors == report.Pages {
+			os.Exit(exitNetwork)
+		}
+	},
+}
+
+type benchReport struct {
+	Keyword        string        `json:"keyword"`
+	Backend        string        `json:"backend"`
+	Pages          int           `json:"pages"`
+	Concurrency    int           `json:"concurrency"`
+	Errors         int           `json:"errors"`
+	Commits        int           `json:"commits"`
+	Bytes          int           `json:"bytes"`
+	Elapsed        time.Duration `json:"elapsed_ns"`
+	PagesPerSecond float64       `json:"pages_per_second"`
+	Fetch          latencyStats  `json:"fetch"`
+	Parse          latencyStats  `json:"parse"`
+	// FirstError is the error of the first page that failed, if any.
+	FirstError string `json:"first_error,omitempty"`
+}
+
+type latencyStats struct {
+	Min  time.Duration `json:"min_ns"`
+	Mean time.Duration `json:"mean_ns"`
+	P50  time.Duration `json:"p50_ns"`
+	P90  time.Duration `json:"p90_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+type benchPage struct {
+	fetch, parse time.Duration
+	bytes        int
+	commits      int
+	err          error
+}
+
+// bench fetches pages 1 to pages with workers pages at a time. The pages
+// of commit-m are fetched and parsed separately to time both; the other
+// backends, and pages from the cache, are timed as a whole as the fetch.
+func bench(keyword string, pages, workers int, ttl time.Duration) benchReport {
+	results := make([]benchPage, pages)
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range queue {
+				results[page-1] = benchFetch(keyword, page, ttl)
+			}
+		}()
+	}
+	for page := 1; page <= pages; page++ {
+		queue <- page
+	}
+	close(queue)
+	wg.Wait()
+
+	report := benchReport{Keyword: keyword, Backend: backend.Name(), Pages: pages, Concurrency: workers, Elapsed: time.Since(start)}
+	fetches, parses := []time.Duration{}, []time.Duration{}
+	for page, r := range results {
+		if r.err != nil {
+			if report.Errors == 0 {
+				report.FirstError = fmt.Sprintf("page %d: %s", page+1, r.err)
+			}
+			report.Errors++
+			continue
+		}
+		report.Commits += r.commits
+		report.Bytes += r.bytes
+		fetches = append(fetches, r.fetch)
+		parses = append(parses, r.parse)
+	}
+	report.PagesPerSecond = float64(pages-report.Errors) / report.Elapsed.Seconds()
+	report.Fetch = latencies(fetches)
+	report.Parse = latencies(parses)
+	return report
+}
+
+func benchFetch(keyword string, page int, ttl time.Duration) benchPage {
+	start := time.Now()
+	if _, ok := backend.(commitM); !ok || ttl > 0 || commitMAPI(runContext) != "" {
+		result, err := cachedCrawl(keyword, page, ttl)
+		return benchPage{fetch: time.Since(start), commits: len(result.Commits), err: err}
+	}
+
+	url := buildUrl(keyword, page)
+	res, err := fetchPage(runContext, url)
+	if err != nil {
+		return benchPage{err: err}
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	closeBody(res.Body)
+	if err != nil {
+		return benchPage{err: err}
+	}
+	b := benchPage{fetch: time.Since(start), bytes: len(body)}
+
+	start = time.Now()
+	result, err := parseSearchPage(url, bytes.NewReader(body))
+	b.parse = time.Since(start)
+	b.commits = len(result.Commits)
+	if _, ok := err.(*parseError); ok && b.commits == 0 {
+		b.err = err
+	}
+	return b
+}
+
+func latencies(durations []time.Duration) latencyStats {
+	if len(durations) == 0 {
+		return latencyStats{}
+	}
+	sorted := make([]int, len(durations))
+	total := time.Duration(0)
+	for i, d := range durations {
+		sorted[i] = int(d)
+		total += d
+	}
+	sort.Ints(sorted)
+	return latencyStats{
+		Min:  time.Duration(sorted[0]),
+		Mean: total / time.Duration(len(durations)),
+		P50:  time.Duration(percentile(sorted, 50)),
+		P90:  time.Duration(percentile(sorted, 90)),
+		Max:  time.Duration(sorted[len(sorted)-1]),
+	}
+}
+
+func printBenchReport(r benchReport) {
+	fmt.Printf("%s, %q: %d pages, concurrency %d, %d errors\n\n", r.Backend, r.Keyword, r.Pages, r.Concurrency, r.Errors)
+	fmt.Printf("  %-6s %9s %9s %9s %9s %9s\n", "", "min", "mean", "p50", "p90", "max")
+	for _, row := range []struct {
+		name  string
+		stats latencyStats
+	}{{"fetch", r.Fetch}, {"parse", r.Parse}} {
+		values := []string{}
+		for _, d := range []time.Duration{row.stats.Min, row.stats.Mean, row.stats.P50, row.stats.P90, row.stats.Max} {
+			values = append(values, fmt.Sprintf("%9s", ms(d)))
+		}
+		fmt.Printf("  %-6s %s\n", row.name, strings.Join(values, " "))
+	}
+	fmt.Printf("\n%d commits, %d bytes in %s: %.2f pages/s\n", r.Commits, r.Bytes, ms(r.Elapsed), r.PagesPerSecond)
+	if r.FirstError != "" {
+		fmt.Fprintln(os.Stderr, r.FirstError)
+	}
+}
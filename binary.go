@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type encoder interface {
+	Encode(v interface{}) error
+}
+
+// newBinaryEncoder returns an encoder of the compact binary formats,
+// msgpack and cbor, writing the fields under their json names. Values
+// written one after the other make a stream readers decode in a loop.
+func newBinaryEncoder(format string, w io.Writer) (encoder, bool) {
+	switch format {
+	case "msgpack":
+		enc := msgpack.NewEncoder(w)
+		enc.SetCustomStructTag("json")
+		return enc, true
+	case "cbor":
+		return cbor.NewEncoder(w), true
+	}
+	return nil, false
+}
+
+// structuredFormat reports whether the output in the format carries the
+// error of a failed search, instead of it being printed.
+func structuredFormat(format string) bool {
+	if _, ok := newBinaryEncoder(format, io.Discard); ok {
+		return true
+	}
+	return format == "json" || format == "sexp"
+}
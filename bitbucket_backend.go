@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	bitbucketAPI           = "https://api.bitbucket.org/2.0"
+	bitbucketSearchPerPage = 20
+)
+
+// bitbucketSearch searches the commit messages of the repositories in a
+// Bitbucket Cloud workspace. Bitbucket has no commit search, so the latest
+// commits of every repository are fetched and matched here, once per query.
+type bitbucketSearch struct {
+	workspace string
+	token     string
+	depth     int
+
+	mu      sync.Mutex
+	matches map[string][]*commit
+}
+
+type bitbucketLinks struct {
+	HTML struct {
+		Href string `json:"href"`
+	} `json:"html"`
+}
+
+type bitbucketRepos struct {
+	Values []struct {
+		FullName string         `json:"full_name"`
+		Slug     string         `json:"slug"`
+		Links    bitbucketLinks `json:"links"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+type bitbucketCommits struct {
+	Values []struct {
+		Hash    string `json:"hash"`
+		Message string `json:"message"`
+		Date    string `json:"date"`
+		Author  struct {
+			Raw  string `json:"raw"`
+			User *struct {
+				DisplayName string `json:"display_name"`
+			} `json:"user"`
+		} `json:"author"`
+		Links bitbucketLinks `json:"links"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+func newBitbucketSearch(workspace, token string, depth int) *bitbucketSearch {
+	return &bitbucketSearch{
+		workspace: workspace,
+		token:     token,
+		depth:     depth,
+		matches:   map[string][]*commit{},
+	}
+}
+
+func (b *bitbucketSearch) Name() string {
+	return "bitbucket"
+}
+
+func (b *bitbucketSearch) URL(query string, page int) string {
+	return fmt.Sprintf("https://bitbucket.org/%s/workspace/search?q=%s&page=%d", b.workspace, url.QueryEscape(query), page)
+}
+
+// header authenticates with an access token, or with "user:app-password".
+func (b *bitbucketSearch) header() http.Header {
+	header := http.Header{}
+	switch {
+	case strings.Contains(b.token, ":"):
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(b.token)))
+	case b.token != "":
+		header.Set("Authorization", "Bearer "+b.token)
+	}
+	return header
+}
+
+func (b *bitbucketSearch) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	if b.workspace == "" {
+		return QueryResult{Commits: []*commit{}}, fmt.Errorf("bitbucket: --bitbucket-workspace is required")
+	}
+	b.mu.Lock()
+	matches, ok := b.matches[query]
+	b.mu.Unlock()
+	if !ok {
+		var err error
+		if matches, err = b.match(ctx, query); err != nil {
+			return QueryResult{Commits: []*commit{}}, fmt.Errorf("bitbucket: %s", err)
+		}
+		b.mu.Lock()
+		b.matches[query] = matches
+		b.mu.Unlock()
+	}
+
+	pages := (len(matches) + bitbucketSearchPerPage - 1) / bitbucketSearchPerPage
+	if pages == 0 {
+		pages = 1
+	}
+	commits := []*commit{}
+	if start := (page - 1) * bitbucketSearchPerPage; page >= 1 && start < len(matches) {
+		end := start + bitbucketSearchPerPage
+		if end > len(matches) {
+			end = len(matches)
+		}
+		commits = matches[start:end]
+	}
+	return QueryResult{
+		Commits:     commits,
+		ResultCount: fmt.Sprintf("%d results", len(matches)),
+		TotalPages:  strconv.Itoa(pages),
+	}, nil
+}
+
+// match returns the commits whose message contains query, newest first.
+func (b *bitbucketSearch) match(ctx context.Context, query string) ([]*commit, error) {
+	needle := strings.ToLower(query)
+	matches := []*commit{}
+	next := fmt.Sprintf("%s/repositories/%s?pagelen=100", bitbucketAPI, url.PathEscape(b.workspace))
+	for next != "" {
+		repos := &bitbucketRepos{}
+		if _, err := getJSON(ctx, next, b.header(), repos); err != nil {
+			return nil, err
+		}
+		for _, repo := range repos.Values {
+			fetched := 0
+			commitsURL := fmt.Sprintf("%s/repositories/%s/%s/commits?pagelen=100", bitbucketAPI, url.PathEscape(b.workspace), url.PathEscape(repo.Slug))
+			for commitsURL != "" && fetched < b.depth {
+				found := &bitbucketCommits{}
+				if _, err := getJSON(ctx, commitsURL, b.header(), found); err != nil {
+					debugf("bitbucket: %s: %s", repo.FullName, err)
+					break
+				}
+				for _, bc := range found.Values {
+					fetched++
+					if !strings.Contains(strings.ToLower(bc.Message), needle) {
+						continue
+					}
+					sha := bc.Hash
+					if len(sha) > 7 {
+						sha = sha[:7]
+					}
+					c := &commit{
+						Repo:      repo.FullName,
+						RepoURL:   repo.Links.HTML.Href,
+						Sha1:      sha,
+						CommitURL: bc.Links.HTML.Href,
+						Message:   firstLine(bc.Message),
+						Body:      messageBody(bc.Message),
+						Author:    bc.Author.Raw,
+						Date:      bc.Date,
+						Source:    "bitbucket",
+					}
+					if bc.Author.User != nil {
+						c.Author = bc.Author.User.DisplayName
+					}
+					matches = append(matches, c)
+				}
+				commitsURL = found.Next
+			}
+		}
+		next = repos.Next
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Date > matches[j].Date })
+	return matches, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+)
+
+const bookmarksFile = "bookmarks.json"
+
+type bookmark struct {
+	commit
+	Tags  []string  `json:"tags,omitempty"`
+	Added time.Time `json:"added"`
+}
+
+func (b *bookmark) hasTag(tag string) bool {
+	for _, t := range b.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *bookmark) hasAllTags(tags []string) bool {
+	for _, tag := range tags {
+		if !b.hasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bookmark) addTags(tags []string) {
+	for _, tag := range tags {
+		if !b.hasTag(tag) {
+			b.Tags = append(b.Tags, tag)
+		}
+	}
+}
+
+var bookmarkCommand = cli.Command{
+	Name:  "bookmark",
+	Usage: "manage bookmarked commits",
+	Subcommands: []cli.Command{
+		{
+			Name:      "add",
+			Usage:     "bookmark the numbered commit of the last search",
+			ArgsUsage: "number",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "tag, t",
+					Value: &cli.StringSlice{},
+					Usage: "attach a tag to the bookmark",
+				},
+			},
+			Action: func(c *cli.Context) {
+				n, err := strconv.Atoi(c.Args().First())
+				if err != nil {
+					cli.ShowCommandHelp(c, "add")
+					os.Exit(1)
+				}
+				commit, err := lastCommit(n)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if err := addBookmark(commit, c.StringSlice("tag")...); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				fmt.Println("bookmarked:", commit.Message)
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "list bookmarks",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "tag, t",
+					Value: &cli.StringSlice{},
+					Usage: "only list bookmarks with the tag",
+				},
+			},
+			Action: func(c *cli.Context) {
+				bookmarks, err := loadBookmarks()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				tags := c.StringSlice("tag")
+				for i, b := range bookmarks {
+					if !b.hasAllTags(tags) {
+						continue
+					}
+					tagsTxt := ""
+					if len(b.Tags) > 0 {
+						tagsTxt = color.MagentaString(" [%s]", strings.Join(b.Tags, ", "))
+					}
+					fmt.Fprintf(color.Output, "%4d  %s %s %s%s\n      %s\n",
+						i+1,
+						theme.repo(b.Repo),
+						theme.sha1(b.Sha1),
+						b.Message,
+						tagsTxt,
+						b.CommitURL,
+					)
+				}
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "remove the numbered bookmark",
+			ArgsUsage: "number",
+			Action: func(c *cli.Context) {
+				bookmarks, err := loadBookmarks()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				n, err := strconv.Atoi(c.Args().First())
+				if err != nil || n < 1 || n > len(bookmarks) {
+					fmt.Fprintf(os.Stderr, "no such bookmark: %s\n", c.Args().First())
+					os.Exit(1)
+				}
+				removed := bookmarks[n-1]
+				bookmarks = append(bookmarks[:n-1], bookmarks[n:]...)
+				if err := saveJSON(dataPath(bookmarksFile), bookmarks); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				fmt.Println("removed:", removed.Message)
+			},
+		},
+		bookmarkExportCommand,
+		bookmarkImportCommand,
+	},
+}
+
+func loadBookmarks() ([]*bookmark, error) {
+	bookmarks := []*bookmark{}
+	err := loadJSON(dataPath(bookmarksFile), &bookmarks)
+	return bookmarks, err
+}
+
+// addBookmark bookmarks the commit with the given tags. Bookmarking an
+// already bookmarked commit only adds the new tags.
+func addBookmark(c *commit, tags ...string) error {
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		return err
+	}
+	for _, b := range bookmarks {
+		if b.Sha1 == c.Sha1 && b.Repo == c.Repo {
+			b.addTags(tags)
+			return saveJSON(dataPath(bookmarksFile), bookmarks)
+		}
+	}
+	b := &bookmark{commit: *c, Added: time.Now()}
+	b.addTags(tags)
+	return saveJSON(dataPath(bookmarksFile), append(bookmarks, b))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var bookmarkCSVHeader = []string{"repo", "repo_url", "sha1", "commit_url", "message", "tags", "added"}
+
+var bookmarkExportCommand = cli.Command{
+	Name:  "export",
+	Usage: "write bookmarks to stdout as json or csv",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "export as json (default)",
+		},
+		cli.BoolFlag{
+			Name:  "csv",
+			Usage: "export as csv",
+		},
+	},
+	Action: func(c *cli.Context) {
+		bookmarks, err := loadBookmarks()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if c.Bool("csv") {
+			err = writeBookmarksCSV(os.Stdout, bookmarks)
+		} else {
+			err = writeBookmarksJSON(os.Stdout, bookmarks)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var bookmarkImportCommand = cli.Command{
+	Name:      "import",
+	Usage:     "merge bookmarks from a json or csv export (- for stdin)",
+	ArgsUsage: "file",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "csv",
+			Usage: "read csv even if the file name does not end in .csv",
+		},
+	},
+	Action: func(c *cli.Context) {
+		path := c.Args().First()
+		if path == "" {
+			cli.ShowCommandHelp(c, "import")
+			os.Exit(1)
+		}
+
+		var in io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		var imported []*bookmark
+		var err error
+		if c.Bool("csv") || strings.EqualFold(filepath.Ext(path), ".csv") {
+			imported, err = readBookmarksCSV(in)
+		} else {
+			err = json.NewDecoder(in).Decode(&imported)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		added, err := mergeBookmarks(imported)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("imported %d bookmarks (%d new)\n", len(imported), added)
+	},
+}
+
+func writeBookmarksJSON(w io.Writer, bookmarks []*bookmark) error {
+	data, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func writeBookmarksCSV(w io.Writer, bookmarks []*bookmark) error {
+	cw := csv.NewWriter(w)
+	cw.Write(bookmarkCSVHeader)
+	for _, b := range bookmarks {
+		cw.Write([]string{
+			b.Repo,
+			b.RepoURL,
+			b.Sha1,
+			b.CommitURL,
+			b.Message,
+			strings.Join(b.Tags, ";"),
+			b.Added.Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func readBookmarksCSV(r io.Reader) ([]*bookmark, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := []*bookmark{}
+	for i, record := range records {
+		if i == 0 && record[0] == bookmarkCSVHeader[0] {
+			continue
+		}
+		if len(record) != len(bookmarkCSVHeader) {
+			return nil, fmt.Errorf("line %d: expected %d fields, got %d", i+1, len(bookmarkCSVHeader), len(record))
+		}
+		b := &bookmark{
+			commit: commit{
+				Repo:      record[0],
+				RepoURL:   record[1],
+				Sha1:      record[2],
+				CommitURL: record[3],
+				Message:   record[4],
+			},
+		}
+		if record[5] != "" {
+			b.Tags = strings.Split(record[5], ";")
+		}
+		if added, err := time.Parse(time.RFC3339, record[6]); err == nil {
+			b.Added = added
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, nil
+}
+
+// mergeBookmarks adds the imported bookmarks to the local ones, merging
+// tags of commits that are already bookmarked. It returns how many new
+// bookmarks were added.
+func mergeBookmarks(imported []*bookmark) (int, error) {
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, in := range imported {
+		merged := false
+		for _, b := range bookmarks {
+			if b.Sha1 == in.Sha1 && b.Repo == in.Repo {
+				b.addTags(in.Tags)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			if in.Added.IsZero() {
+				in.Added = time.Now()
+			}
+			bookmarks = append(bookmarks, in)
+			added++
+		}
+	}
+	return added, saveJSON(dataPath(bookmarksFile), bookmarks)
+}
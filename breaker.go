@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+// breakerThreshold is the number of consecutive failures after which the
+// remaining requests of a multi-keyword operation are given up.
+const breakerThreshold = 3
+
+// circuitBreaker trips after breakerThreshold consecutive failures, so an
+// operation stops hammering a server that is clearly down.
+type circuitBreaker struct {
+	failures int
+	last     error
+}
+
+// record counts the outcome of a request and reports whether the breaker
+// is now open.
+func (b *circuitBreaker) record(err error) bool {
+	if err == nil {
+		b.failures = 0
+		return false
+	}
+	b.failures++
+	b.last = err
+	return b.open()
+}
+
+func (b *circuitBreaker) open() bool {
+	return b.failures >= breakerThreshold
+}
+
+// err describes the open breaker, with done of total items completed.
+func (b *circuitBreaker) err(done, total int) error {
+	return fmt.Errorf("giving up after %d consecutive failures, %d of %d done: %s", b.failures, done, total, b.last)
+}
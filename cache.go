@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const cacheResultsDir = "results"
+
+type cacheEntry struct {
+	URL     string      `json:"url"`
+	Fetched time.Time   `json:"fetched"`
+	Result  QueryResult `json:"result"`
+}
+
+func cacheFile(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return cachePath(cacheResultsDir, hex.EncodeToString(sum[:])+".json.gz")
+}
+
+func loadCached(url string) (*cacheEntry, bool) {
+	entry := &cacheEntry{}
+	if err := loadJSON(cacheFile(url), entry); err != nil || entry.URL != url {
+		return nil, false
+	}
+	return entry, true
+}
+
+// offlineResult returns the cached result for the url regardless of its
+// age, failing when the url was never fetched.
+func offlineResult(url string) (QueryResult, error) {
+	entry, ok := loadCached(url)
+	if !ok {
+		return QueryResult{Commits: []*commit{}}, fmt.Errorf("not cached, cannot search offline: %s", url)
+	}
+	return entry.Result, nil
+}
+
+// cachedResult returns the cached result for the url if it was fetched
+// within ttl.
+func cachedResult(url string, ttl time.Duration) (QueryResult, bool) {
+	if entry, ok := loadCached(url); ok && time.Since(entry.Fetched) <= ttl {
+		debugf("cache hit %s (fetched %s)", url, entry.Fetched.Format(time.RFC3339))
+		cacheLookups.WithLabelValues("hit").Inc()
+		return entry.Result, true
+	}
+	cacheLookups.WithLabelValues("miss").Inc()
+	return QueryResult{}, false
+}
+
+// cachedCrawl returns the cached result for the page if it was fetched
+// within ttl, and otherwise searches the backend and caches it. A ttl of 0
+// always searches, but still refreshes the cache.
+func cachedCrawl(keyword string, page int, ttl time.Duration) (QueryResult, error) {
+	url := buildUrl(keyword, page)
+	if ttl > 0 {
+		if result, ok := cachedResult(url, ttl); ok {
+			return result, nil
+		}
+	}
+
+	start := time.Now()
+	result, err := backend.Search(runContext, keyword, page)
+	observeSearch(start, err)
+	if err != nil {
+		return result, err
+	}
+	saveJSON(cacheFile(url), &cacheEntry{URL: url, Fetched: time.Now(), Result: result})
+	if err := recordFetched(keyword, result.Commits); err != nil {
+		logger.Warn("failed to store commits", "url", url, "error", err)
+	}
+	return result, nil
+}
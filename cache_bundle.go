@@ -0,0 +1,254 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const bundleDBEntry = "data/" + dbFile
+
+var cacheExportCommand = cli.Command{
+	Name:      "export",
+	Usage:     "write the cache into a bundle (.tar.zst, .tar.gz or .tar)",
+	ArgsUsage: "bundle",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "include-db",
+			Usage: "also bundle the database of every fetched commit",
+		},
+	},
+	Action: func(c *cli.Context) {
+		path := c.Args().First()
+		if path == "" {
+			cli.ShowCommandHelp(c, "export")
+			os.Exit(1)
+		}
+		n, err := exportCacheBundle(path, c.Bool("include-db"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("exported %d files to %s\n", n, path)
+	},
+}
+
+var cacheImportCommand = cli.Command{
+	Name:      "import",
+	Usage:     "merge a bundle written by cache export into the cache",
+	ArgsUsage: "bundle",
+	Action: func(c *cli.Context) {
+		path := c.Args().First()
+		if path == "" {
+			cli.ShowCommandHelp(c, "import")
+			os.Exit(1)
+		}
+		n, err := importCacheBundle(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("imported %d files from %s\n", n, path)
+	},
+}
+
+// compressWriter wraps w with the compression implied by the file name.
+func compressWriter(path string, w io.Writer) (io.WriteCloser, error) {
+	switch {
+	case strings.HasSuffix(path, ".zst"):
+		return zstd.NewWriter(w)
+	case strings.HasSuffix(path, ".gz"), strings.HasSuffix(path, ".tgz"):
+		return gzip.NewWriter(w), nil
+	}
+	return nopWriteCloser{w}, nil
+}
+
+func decompressReader(path string, r io.Reader) (io.Reader, func(), error) {
+	switch {
+	case strings.HasSuffix(path, ".zst"):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	case strings.HasSuffix(path, ".gz"), strings.HasSuffix(path, ".tgz"):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, func() { zr.Close() }, nil
+	}
+	return r, func() {}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func exportCacheBundle(path string, includeDB bool) (int, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	zw, err := compressWriter(path, f)
+	if err != nil {
+		return 0, err
+	}
+	tw := tar.NewWriter(zw)
+
+	n := 0
+	err = filepath.Walk(cacheDir(), func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(cacheDir(), file)
+		if err != nil {
+			return err
+		}
+		n++
+		return addTarFile(tw, "cache/"+filepath.ToSlash(rel), file, info)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return n, err
+	}
+	if includeDB {
+		if info, err := os.Stat(dbPath()); err == nil {
+			if err := addTarFile(tw, bundleDBEntry, dbPath(), info); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return n, err
+	}
+	return n, zw.Close()
+}
+
+func addTarFile(tw *tar.Writer, name, file string, info os.FileInfo) error {
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// importCacheBundle extracts the cache entries of the bundle, keeping local
+// files that are newer, and merges a bundled database into the local one.
+func importCacheBundle(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r, closer, err := decompressReader(path, f)
+	if err != nil {
+		return 0, err
+	}
+	defer closer()
+
+	n := 0
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if hdr.Typeflag != tar.TypeReg || strings.Contains(hdr.Name, "..") {
+			continue
+		}
+
+		switch {
+		case hdr.Name == bundleDBEntry:
+			if err := importDB(tr); err != nil {
+				return n, err
+			}
+			n++
+		case strings.HasPrefix(hdr.Name, "cache/"):
+			target := cachePath(filepath.FromSlash(strings.TrimPrefix(hdr.Name, "cache/")))
+			if info, err := os.Stat(target); err == nil && info.ModTime().After(hdr.ModTime) {
+				continue
+			}
+			if err := extractTarFile(tr, target, hdr); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+}
+
+func extractTarFile(r io.Reader, target string, hdr *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+}
+
+// importDB merges the commits of a bundled database into the local one.
+func importDB(r io.Reader) error {
+	tmp, err := ioutil.TempFile("", "gommit-m-import-*.db")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	db, err := openDB(dbPath())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// ATTACH only applies to the connection it is run on
+	conn, err := db.Conn(runContext)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(runContext, "ATTACH DATABASE ? AS bundle", tmp.Name()); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE bundle")
+	_, err = conn.ExecContext(runContext, "INSERT OR IGNORE INTO commits SELECT * FROM bundle.commits")
+	return err
+}
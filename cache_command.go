@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// enrichmentCacheFiles are the data files holding cached GitHub lookups.
+var enrichmentCacheFiles = []string{starsCacheFile, commitsCacheFile}
+
+var cacheCommand = cli.Command{
+	Name:  "cache",
+	Usage: "inspect and manage the on-disk cache",
+	Subcommands: []cli.Command{
+		{
+			Name:  "path",
+			Usage: "print the cache directory",
+			Action: func(c *cli.Context) {
+				fmt.Println(cacheDir())
+			},
+		},
+		{
+			Name:  "stats",
+			Usage: "print the number, size and age of cached entries",
+			Action: func(c *cli.Context) {
+				files, err := cacheFiles()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				var size int64
+				var oldest, newest time.Time
+				for _, f := range files {
+					size += f.Size()
+					if oldest.IsZero() || f.ModTime().Before(oldest) {
+						oldest = f.ModTime()
+					}
+					if f.ModTime().After(newest) {
+						newest = f.ModTime()
+					}
+				}
+				fmt.Printf("search results: %d entries, %s\n", len(files), formatBytes(size))
+				if len(files) > 0 {
+					fmt.Printf("  oldest: %s\n", oldest.Format("2006-01-02 15:04"))
+					fmt.Printf("  newest: %s\n", newest.Format("2006-01-02 15:04"))
+				}
+				for _, name := range enrichmentCacheFiles {
+					if info, err := os.Stat(cachePath(name)); err == nil {
+						fmt.Printf("%s: %s\n", name, formatBytes(info.Size()))
+					}
+				}
+			},
+		},
+		{
+			Name:  "clear",
+			Usage: "remove all cached search results, enrichment data and proxied pages",
+			Action: func(c *cli.Context) {
+				if err := os.RemoveAll(cachePath(cacheResultsDir)); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				for _, name := range enrichmentCacheFiles {
+					os.Remove(cachePath(name))
+				}
+				os.RemoveAll(cachePath(cacheProxyDir))
+				fmt.Println("cache cleared")
+			},
+		},
+		{
+			Name:  "prune",
+			Usage: "remove cached search results older than --older-than",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "older-than",
+					Value: "7d",
+					Usage: "age of the entries to remove (e.g. 12h, 7d, 2w)",
+				},
+			},
+			Action: func(c *cli.Context) {
+				age, err := parseAge(c.String("older-than"))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				files, err := cacheFiles()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				removed := 0
+				for _, f := range files {
+					if time.Since(f.ModTime()) > age {
+						if err := os.Remove(cachePath(cacheResultsDir, f.Name())); err == nil {
+							removed++
+						}
+					}
+				}
+				fmt.Printf("removed %d of %d entries\n", removed, len(files))
+			},
+		},
+		cacheExportCommand,
+		cacheImportCommand,
+	},
+}
+
+func cacheFiles() ([]os.FileInfo, error) {
+	files, err := ioutil.ReadDir(cachePath(cacheResultsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return files, err
+}
+
+// parseAge parses a duration, additionally accepting days ("7d") and
+// weeks ("2w").
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
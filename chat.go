@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+var chatFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "notify",
+		Value: &cli.StringSlice{},
+		Usage: "post the top results to a chat webhook: slack://hooks.slack.com/services/... or discord://discord.com/api/webhooks/... (repeatable)",
+	},
+	cli.IntFlag{
+		Name:  "notify-count",
+		Value: 5,
+		Usage: "number of commits posted by --notify",
+	},
+}
+
+// discordMaxContent is the longest message Discord accepts.
+const discordMaxContent = 2000
+
+// notifyChat posts the first count commits to the webhook named by target,
+// whose scheme, slack or discord, selects the message format. The rest of
+// target is the webhook url without https://.
+func notifyChat(target, keyword string, commits []*commit, count int) error {
+	i := strings.Index(target, "://")
+	if i < 0 {
+		return fmt.Errorf("invalid --notify %q, want slack://... or discord://...", target)
+	}
+	scheme, url := target[:i], "https://"+target[i+3:]
+	if len(commits) > count {
+		commits = commits[:count]
+	}
+
+	var payload interface{}
+	switch scheme {
+	case "slack":
+		lines := []string{fmt.Sprintf("*gommit-m: %s*", keyword)}
+		for _, c := range commits {
+			lines = append(lines, fmt.Sprintf("• <%s|%s> %s", c.CommitURL, slackEscape(c.Repo), slackEscape(c.Message)))
+		}
+		payload = map[string]string{"text": strings.Join(lines, "\n")}
+	case "discord":
+		content := fmt.Sprintf("**gommit-m: %s**", keyword)
+		for _, c := range commits {
+			line := fmt.Sprintf("\n• [%s](<%s>) %s", c.Repo, c.CommitURL, c.Message)
+			if len(content)+len(line) > discordMaxContent {
+				break
+			}
+			content += line
+		}
+		payload = map[string]string{"content": content}
+	default:
+		return fmt.Errorf("unknown --notify scheme: %s", scheme)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	res, err := http.DefaultClient.Do(req.WithContext(runContext))
+	if err != nil {
+		return err
+	}
+	defer closeBody(res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", scheme, res.Status)
+	}
+	return nil
+}
+
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// notifyChats posts to every --notify target, reporting failures as it goes.
+func notifyChats(c *cli.Context, keyword string, commits []*commit) []error {
+	errs := []error{}
+	if len(commits) == 0 {
+		return errs
+	}
+	for _, target := range c.StringSlice("notify") {
+		if err := notifyChat(target, keyword, commits, c.Int("notify-count")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+const checkpointDir = "checkpoints"
+
+// checkpoint records the progress of a long crawl after every page, so an
+// interrupted crawl can continue with --resume.
+type checkpoint struct {
+	Key      string          `json:"key"`
+	Keyword  string          `json:"keyword"`
+	LastPage int             `json:"last_page"`
+	Written  int             `json:"written"`
+	Dropped  int             `json:"dropped"`
+	Seen     map[string]bool `json:"seen"`
+	Commits  []*commit       `json:"commits,omitempty"`
+	// Offset is the size of the export output after LastPage.
+	Offset  int64     `json:"offset,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+func checkpointFile(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return dataPath(checkpointDir, hex.EncodeToString(sum[:])+".json.gz")
+}
+
+// loadCheckpoint returns the saved checkpoint for the key, or a fresh one
+// when there is none or resume is false.
+func loadCheckpoint(key, keyword string, resume bool) *checkpoint {
+	cp := &checkpoint{}
+	if resume {
+		if err := loadJSON(checkpointFile(key), cp); err == nil && cp.Key == key {
+			return cp
+		}
+	}
+	return &checkpoint{Key: key, Keyword: keyword, Seen: map[string]bool{}}
+}
+
+func (cp *checkpoint) save() error {
+	cp.Updated = time.Now()
+	return saveJSON(checkpointFile(cp.Key), cp)
+}
+
+func (cp *checkpoint) remove() {
+	os.Remove(checkpointFile(cp.Key))
+}
+
+// deduper returns a deduper continuing from the checkpoint's seen set.
+func (cp *checkpoint) deduper(byMessage bool) *deduper {
+	d := newDeduper(byMessage)
+	d.seen = cp.Seen
+	d.dropped = cp.Dropped
+	return d
+}
+
+// resumeFrom returns the page range left to crawl.
+func (cp *checkpoint) resumeFrom(pages pageRange) pageRange {
+	if cp.LastPage >= pages.first {
+		pages.first = cp.LastPage + 1
+	}
+	return pages
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"regexp"
+)
+
+var (
+	// paths and file names, e.g. src/main.go or README.md
+	clusterPathPattern = regexp.MustCompile(`[\w.-]*[/\\][\w./\\-]*|\b[\w-]+\.[A-Za-z]\w{0,4}\b`)
+	// numbers, versions and abbreviated hashes
+	clusterNumberPattern = regexp.MustCompile(`\b(v?\d+(\.\d+)*|[0-9a-f]{7,40})\b`)
+)
+
+// clusterKey is the message with the trivial tokens, paths and numbers,
+// replaced by placeholders and normalized, so near-duplicates share it.
+func clusterKey(message string) string {
+	key := clusterPathPattern.ReplaceAllString(firstLine(message), " PATH ")
+	key = clusterNumberPattern.ReplaceAllString(key, " NUM ")
+	return normalizeMessage(key)
+}
+
+// clusterCommits groups the near-duplicate messages, returning the first
+// commit of each group with the size of the group set.
+func clusterCommits(commits []*commit) []*commit {
+	representatives := []*commit{}
+	byKey := map[string]*commit{}
+	for _, c := range commits {
+		key := clusterKey(c.Message)
+		if r, ok := byKey[key]; ok {
+			r.ClusterSize++
+			continue
+		}
+		c.ClusterSize = 1
+		byKey[key] = c
+		representatives = append(representatives, c)
+	}
+	return representatives
+}
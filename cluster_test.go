@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestClusterKey(t *testing.T) {
+	for _, tt := range []struct{ a, b string }{
+		{"Bump lodash to 4.17.21", "Bump lodash to v4.17.15"},
+		{"Fix typo in README.md", "fix typo in docs/index.html"},
+		{"Revert 0123456abc", "Revert deadbeef"},
+		{"Update config.yml\n\nwith a body", "Update Gemfile.lock"},
+	} {
+		if ka, kb := clusterKey(tt.a), clusterKey(tt.b); ka != kb {
+			t.Errorf("%q and %q have the keys %q and %q", tt.a, tt.b, ka, kb)
+		}
+	}
+	if ka, kb := clusterKey("Bump lodash to 4.17.21"), clusterKey("Bump react to 4.17.21"); ka == kb {
+		t.Errorf("different packages have the same key %q", ka)
+	}
+}
+
+func TestClusterCommits(t *testing.T) {
+	commits := []*commit{
+		{Sha1: "aaaaaaa", Message: "Bump lodash to 4.17.21"},
+		{Sha1: "bbbbbbb", Message: "Fix typo"},
+		{Sha1: "ccccccc", Message: "bump lodash to 4.17.20"},
+		{Sha1: "ddddddd", Message: "Bump lodash to 4.17.19."},
+	}
+	got := clusterCommits(commits)
+	if len(got) != 2 {
+		t.Fatalf("%d clusters, want 2", len(got))
+	}
+	if got[0].Sha1 != "aaaaaaa" || got[0].ClusterSize != 3 {
+		t.Errorf("first cluster is %s of %d, want aaaaaaa of 3", got[0].Sha1, got[0].ClusterSize)
+	}
+	if got[1].Sha1 != "bbbbbbb" || got[1].ClusterSize != 1 {
+		t.Errorf("second cluster is %s of %d, want bbbbbbb of 1", got[1].Sha1, got[1].ClusterSize)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/codegangsta/cli"
+)
+
+var commitCommand = cli.Command{
+	Name:      "commit",
+	Usage:     "search, pick a message and git commit with it",
+	ArgsUsage: "keyword [page]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "edit, e",
+			Usage: "open the editor prefilled with the selected message",
+		},
+	},
+	Action: func(c *cli.Context) {
+		keyword := c.Args().First()
+		if keyword == "" {
+			cli.ShowCommandHelp(c, "commit")
+			os.Exit(1)
+		}
+		page := parsePage(c.Args().Get(1))
+
+		url := buildUrl(keyword, page)
+		result, err := cachedCrawl(keyword, page, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		showResult(result, url, keyword, page)
+
+		selected := pickCommit(result.Commits)
+		if selected == nil {
+			return
+		}
+
+		args := []string{"commit", "-m", selected.Message}
+		if c.Bool("edit") {
+			args = append(args, "--edit")
+		}
+		cmd := exec.Command("git", args...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				os.Exit(exitCode(exitErr))
+			}
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func exitCode(err *exec.ExitError) int {
+	if code := err.ExitCode(); code > 0 {
+		return code
+	}
+	return 1
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	commitsCacheFile = "commits.json"
+	// commits do not change, only the GitHub account their author is
+	// linked to may
+	commitsCacheTTL = 30 * 24 * time.Hour
+)
+
+type commitsEntry struct {
+	Commit  *githubCommit `json:"commit"`
+	Fetched time.Time     `json:"fetched"`
+}
+
+// commitCache holds the GitHub commits looked up by enrichment, keyed by
+// "owner/repo/sha", loaded from the cache directory on first use.
+var commitCache struct {
+	sync.Mutex
+	loaded  bool
+	dirty   bool
+	entries map[string]*commitsEntry
+}
+
+func cachedGithubCommit(key string) (*githubCommit, bool) {
+	commitCache.Lock()
+	defer commitCache.Unlock()
+	if !commitCache.loaded {
+		commitCache.loaded = true
+		commitCache.entries = map[string]*commitsEntry{}
+		if err := loadJSON(cachePath(commitsCacheFile), &commitCache.entries); err != nil {
+			logger.Warn("failed to load the commit cache", "error", err)
+		}
+	}
+	entry, ok := commitCache.entries[key]
+	if !ok || entry.Commit == nil || time.Since(entry.Fetched) > commitsCacheTTL {
+		return nil, false
+	}
+	return entry.Commit, true
+}
+
+func cacheGithubCommit(key string, gc *githubCommit) {
+	commitCache.Lock()
+	defer commitCache.Unlock()
+	commitCache.entries[key] = &commitsEntry{Commit: gc, Fetched: time.Now()}
+	commitCache.dirty = true
+}
+
+// saveCommitCache writes the commits looked up since the last save,
+// dropping expired entries.
+func saveCommitCache() error {
+	commitCache.Lock()
+	defer commitCache.Unlock()
+	if !commitCache.dirty {
+		return nil
+	}
+	for key, entry := range commitCache.entries {
+		if time.Since(entry.Fetched) > commitsCacheTTL {
+			delete(commitCache.entries, key)
+		}
+	}
+	commitCache.dirty = false
+	return saveJSON(cachePath(commitsCacheFile), commitCache.entries)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCommitCache(t *testing.T) {
+	defer func(dir string) { cacheDirOverride = dir }(cacheDirOverride)
+	cacheDirOverride = t.TempDir()
+	commitCache.loaded, commitCache.entries = false, nil
+
+	if _, ok := cachedGithubCommit("octo/cat/0123abc"); ok {
+		t.Fatal("empty cache has a commit")
+	}
+	gc := &githubCommit{}
+	gc.Commit.Message = "fix typo"
+	gc.Commit.Author.Date = "2020-01-01T00:00:00Z"
+	cacheGithubCommit("octo/cat/0123abc", gc)
+	commitCache.entries["octo/cat/expired"] = &commitsEntry{Commit: gc, Fetched: time.Now().Add(-commitsCacheTTL - time.Hour)}
+	if err := saveCommitCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	commitCache.loaded, commitCache.entries = false, nil
+	got, ok := cachedGithubCommit("octo/cat/0123abc")
+	if !ok || got.Commit.Message != "fix typo" || got.Commit.Author.Date != "2020-01-01T00:00:00Z" {
+		t.Errorf("cached commit = %+v, %v", got, ok)
+	}
+	if _, ok := commitCache.entries["octo/cat/expired"]; ok {
+		t.Error("expired commit was saved")
+	}
+}
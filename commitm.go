@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// commitM searches commit-m with its JSON API when the instance has one,
+// and otherwise scrapes the search pages.
+type commitM struct{}
+
+func (commitM) Name() string {
+	return "commit-m"
+}
+
+func (commitM) URL(query string, page int) string {
+	return fmt.Sprintf("%s/commits/search?keyword=%s&page=%d", strings.TrimSuffix(endpoint, "/"), url.QueryEscape(query), page)
+}
+
+func (b commitM) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	if template := commitMAPI(ctx); template != "" {
+		result, err := fetchAPI(ctx, template, query, page)
+		if err == nil {
+			return result, nil
+		}
+		debugf("json api: %s, scraping instead", err)
+	}
+	return crawl(ctx, b.URL(query, page))
+}
+
+func crawl(ctx context.Context, url string) (QueryResult, error) {
+	debugf("fetch %s", url)
+	res, err := fetchPage(ctx, url)
+	if err != nil {
+		return QueryResult{Commits: []*commit{}}, err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	closeBody(res.Body)
+	if err != nil {
+		return QueryResult{Commits: []*commit{}}, err
+	}
+	parseStart := time.Now()
+	result, err := parseSearchPage(url, bytes.NewReader(body))
+	tracef("parse %s: %s", url, ms(time.Since(parseStart)))
+	debugf("parsed %d commits, %q results, %q pages", len(result.Commits), result.ResultCount, result.TotalPages)
+	return result, err
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiProbeFile = "api.json"
+	apiProbeTTL  = 7 * 24 * time.Hour
+)
+
+// apiTemplates are the places a commit-m instance may answer searches
+// with JSON. They are tried in order; the first that works is used
+// instead of scraping the HTML.
+var apiTemplates = []string{
+	"%s/commits/search?keyword=%s&page=%d",
+	"%s/commits/search.json?keyword=%s&page=%d",
+	"%s/api/commits/search?keyword=%s&page=%d",
+}
+
+// apiProbe records which template, if any, an endpoint answered.
+type apiProbe struct {
+	Template string    `json:"template"`
+	Checked  time.Time `json:"checked"`
+}
+
+var (
+	apiOnce     sync.Once
+	apiTemplate string
+)
+
+// commitMAPI returns the JSON API template of the endpoint, or "" when it
+// only has HTML pages. The answer is cached for a week.
+func commitMAPI(ctx context.Context) string {
+	apiOnce.Do(func() {
+		probes := map[string]*apiProbe{}
+		loadJSON(cachePath(apiProbeFile), &probes)
+		if p, ok := probes[endpoint]; ok && time.Since(p.Checked) < apiProbeTTL {
+			apiTemplate = p.Template
+			return
+		}
+		for _, template := range apiTemplates {
+			if _, err := fetchAPI(ctx, template, "fix", 1); err == nil {
+				apiTemplate = template
+				break
+			}
+		}
+		debugf("json api of %s: %q", endpoint, apiTemplate)
+		probes[endpoint] = &apiProbe{Template: apiTemplate, Checked: time.Now()}
+		saveJSON(cachePath(apiProbeFile), probes)
+	})
+	return apiTemplate
+}
+
+// apiCommit accepts the field names commit-m's own JSON uses as well as
+// the ones of gommit-m's --json output.
+type apiCommit struct {
+	Message    string `json:"message"`
+	Repo       string `json:"repo"`
+	Repository string `json:"repository"`
+	RepoURL    string `json:"repo_url"`
+	Sha1       string `json:"sha1"`
+	Sha        string `json:"sha"`
+	CommitURL  string `json:"commit_url"`
+	URL        string `json:"url"`
+}
+
+type apiResult struct {
+	Commits     []*apiCommit    `json:"commits"`
+	ResultCount json.Number     `json:"result_count"`
+	TotalPages  json.Number     `json:"total_pages"`
+	Error       json.RawMessage `json:"error"`
+}
+
+func fetchAPI(ctx context.Context, template, query string, page int) (QueryResult, error) {
+	u := fmt.Sprintf(template, strings.TrimSuffix(endpoint, "/"), url.QueryEscape(query), page)
+	var raw json.RawMessage
+	if _, err := getJSON(ctx, u, nil, &raw); err != nil {
+		return QueryResult{Commits: []*commit{}}, err
+	}
+
+	res := &apiResult{}
+	if err := json.Unmarshal(raw, res); err != nil {
+		// a bare list of commits
+		res.Commits = nil
+		if err := json.Unmarshal(raw, &res.Commits); err != nil {
+			return QueryResult{Commits: []*commit{}}, &parseError{url: u}
+		}
+	} else if res.Commits == nil {
+		return QueryResult{Commits: []*commit{}}, &parseError{url: u}
+	}
+
+	commits := []*commit{}
+	for _, ac := range res.Commits {
+		commits = append(commits, &commit{
+			Repo:      firstNonEmpty(ac.Repo, ac.Repository),
+			RepoURL:   ac.RepoURL,
+			Sha1:      firstNonEmpty(ac.Sha1, ac.Sha),
+			CommitURL: firstNonEmpty(ac.CommitURL, ac.URL),
+			Message:   strings.TrimSpace(ac.Message),
+		})
+	}
+	count := res.ResultCount.String()
+	if count == "" {
+		count = strconv.Itoa(len(commits))
+	}
+	pages := res.TotalPages.String()
+	if pages == "" {
+		pages = "1"
+	}
+	return QueryResult{
+		Commits:     commits,
+		ResultCount: count + " results",
+		TotalPages:  pages,
+	}, nil
+}
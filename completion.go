@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/codegangsta/cli"
+)
+
+// completeKeywords prints subcommand names and past search keywords for
+// shell completion of the first argument.
+func completeKeywords(c *cli.Context) {
+	if len(c.Args()) > 0 {
+		return
+	}
+	for _, command := range c.App.Commands {
+		fmt.Println(command.Name)
+	}
+	for _, keyword := range historyKeywords() {
+		fmt.Println(keyword)
+	}
+}
+
+// completeSavedSearches prints the names of saved searches.
+func completeSavedSearches(c *cli.Context) {
+	if len(c.Args()) > 0 {
+		return
+	}
+	searches, err := loadSavedSearches()
+	if err != nil {
+		return
+	}
+	names := []string{}
+	for name := range searches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
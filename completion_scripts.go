@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+var completionCommand = cli.Command{
+	Name:      "completion",
+	Usage:     "print a shell completion script",
+	ArgsUsage: "bash|zsh|fish|powershell",
+	BashComplete: func(c *cli.Context) {
+		if len(c.Args()) > 0 {
+			return
+		}
+		for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+			fmt.Println(shell)
+		}
+	},
+	Action: func(c *cli.Context) {
+		nodes := completionNodes(c.App)
+		name := c.App.Name
+		switch c.Args().First() {
+		case "bash":
+			fmt.Print(bashCompletion(name, nodes))
+		case "zsh":
+			fmt.Print("autoload -U +X bashcompinit && bashcompinit\n\n" + bashCompletion(name, nodes))
+		case "fish":
+			fmt.Print(fishCompletion(name, nodes))
+		case "powershell":
+			fmt.Print(powershellCompletion(name, nodes))
+		default:
+			fmt.Fprintln(os.Stderr, "usage: gommit-m completion bash|zsh|fish|powershell")
+			os.Exit(1)
+		}
+	},
+}
+
+// completionNode is a command (the app itself at path "") with the names of
+// its subcommands and flags. Positional values such as saved search names
+// are completed at run time through --generate-bash-completion.
+type completionNode struct {
+	path     string
+	commands []string
+	flags    []string
+}
+
+func completionNodes(app *cli.App) []completionNode {
+	nodes := []completionNode{{
+		path:     "",
+		commands: commandNames(app.Commands),
+		flags:    completionFlags(app.Flags),
+	}}
+	return append(nodes, commandNodes("", app.Commands)...)
+}
+
+func commandNodes(parent string, commands []cli.Command) []completionNode {
+	nodes := []completionNode{}
+	for _, command := range commands {
+		path := strings.TrimSpace(parent + " " + command.Name)
+		nodes = append(nodes, completionNode{
+			path:     path,
+			commands: commandNames(command.Subcommands),
+			flags:    completionFlags(command.Flags),
+		})
+		nodes = append(nodes, commandNodes(path, command.Subcommands)...)
+	}
+	return nodes
+}
+
+func commandNames(commands []cli.Command) []string {
+	names := []string{}
+	for _, command := range commands {
+		names = append(names, command.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completionFlags returns every spelling of the flags, "--name" and "-n".
+func completionFlags(flags []cli.Flag) []string {
+	names := []string{}
+	for _, flag := range flags {
+		name, ok := declaredName(flag)
+		if !ok {
+			continue
+		}
+		for _, n := range strings.Split(name, ",") {
+			n = strings.TrimSpace(n)
+			if len(n) == 1 {
+				names = append(names, "-"+n)
+			} else {
+				names = append(names, "--"+n)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func completionFuncName(name string) string {
+	return "_" + strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
+
+func bashCompletion(name string, nodes []completionNode) string {
+	fn := completionFuncName(name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n\n", name)
+	fmt.Fprintf(&b, "%s_commands() {\n\tcase \"$1\" in\n", fn)
+	for _, n := range nodes {
+		if len(n.commands) > 0 {
+			fmt.Fprintf(&b, "\t\"%s\") echo \"%s\" ;;\n", n.path, strings.Join(n.commands, " "))
+		}
+	}
+	fmt.Fprintf(&b, "\tesac\n}\n\n")
+	fmt.Fprintf(&b, "%s_flags() {\n\tcase \"$1\" in\n", fn)
+	for _, n := range nodes {
+		if len(n.flags) > 0 {
+			fmt.Fprintf(&b, "\t\"%s\") echo \"%s\" ;;\n", n.path, strings.Join(n.flags, " "))
+		}
+	}
+	fmt.Fprintf(&b, "\tesac\n}\n\n")
+	fmt.Fprintf(&b, `%[1]s() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" path="" w
+	for w in "${COMP_WORDS[@]:1:COMP_CWORD-1}"; do
+		case " $(%[1]s_commands "$path") " in
+		*" $w "*) path="${path:+$path }$w" ;;
+		esac
+	done
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "$(%[1]s_flags "$path")" -- "$cur"))
+	else
+		COMPREPLY=($(compgen -W "$(%[1]s_commands "$path") $(%[2]s $path --generate-bash-completion 2>/dev/null)" -- "$cur"))
+	fi
+}
+
+complete -F %[1]s %[2]s
+`, fn, name)
+	return b.String()
+}
+
+func fishCompletion(name string, nodes []completionNode) string {
+	fn := "_" + completionFuncName(name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n\n", name)
+	for _, list := range []struct {
+		suffix string
+		values func(completionNode) []string
+	}{
+		{"commands", func(n completionNode) []string { return n.commands }},
+		{"flags", func(n completionNode) []string { return n.flags }},
+	} {
+		fmt.Fprintf(&b, "function %s_%s\n\tswitch \"$argv[1]\"\n", fn, list.suffix)
+		for _, n := range nodes {
+			if values := list.values(n); len(values) > 0 {
+				fmt.Fprintf(&b, "\t\tcase '%s'\n\t\t\tprintf '%%s\\n' %s\n", n.path, strings.Join(values, " "))
+			}
+		}
+		fmt.Fprintf(&b, "\tend\nend\n\n")
+	}
+	fmt.Fprintf(&b, `function %[1]s_path
+	set -l path ''
+	for w in (commandline -opc)[2..-1]
+		if contains -- $w (%[1]s_commands "$path")
+			set path (string trim -- "$path $w")
+		end
+	end
+	echo $path
+end
+
+function %[1]s_complete
+	set -l path (%[1]s_path)
+	if string match -q -- '-*' (commandline -ct)
+		%[1]s_flags "$path"
+		return
+	end
+	%[1]s_commands "$path"
+	if test -n "$path"
+		%[2]s (string split ' ' -- $path) --generate-bash-completion 2>/dev/null
+	else
+		%[2]s --generate-bash-completion 2>/dev/null
+	end
+end
+
+complete -c %[2]s -f -a '(%[1]s_complete)'
+`, fn, name)
+	return b.String()
+}
+
+func powershellCompletion(name string, nodes []completionNode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# powershell completion for %s\n\n", name)
+	fmt.Fprintf(&b, "Register-ArgumentCompleter -Native -CommandName '%s' -ScriptBlock {\n", name)
+	fmt.Fprintf(&b, "\tparam($wordToComplete, $commandAst, $cursorPosition)\n\n")
+	for _, list := range []struct {
+		variable string
+		values   func(completionNode) []string
+	}{
+		{"commands", func(n completionNode) []string { return n.commands }},
+		{"flags", func(n completionNode) []string { return n.flags }},
+	} {
+		fmt.Fprintf(&b, "\t$%s = @{\n", list.variable)
+		for _, n := range nodes {
+			if values := list.values(n); len(values) > 0 {
+				fmt.Fprintf(&b, "\t\t'%s' = @('%s')\n", n.path, strings.Join(values, "', '"))
+			}
+		}
+		fmt.Fprintf(&b, "\t}\n")
+	}
+	fmt.Fprintf(&b, `
+	$path = ''
+	$words = $commandAst.CommandElements | Select-Object -Skip 1 |
+		Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() }
+	foreach ($w in $words) {
+		if ($commands[$path] -contains $w) { $path = ($path + ' ' + $w).Trim() }
+	}
+
+	if ($wordToComplete -like '-*') {
+		$candidates = @($flags[$path])
+	} else {
+		$completionArgs = @($path -split ' ' | Where-Object { $_ }) + '--generate-bash-completion'
+		$candidates = @($commands[$path]) + @(& '%s' @completionArgs 2>$null)
+	}
+	$candidates | Where-Object { $_ -like "$wordToComplete*" } | Sort-Object -Unique | ForEach-Object {
+		[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+	}
+}
+`, name)
+	return b.String()
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+)
+
+const (
+	configFile      = "config.toml"
+	defaultEndpoint = "http://commit-m.minamijoyo.com"
+)
+
+// endpoint is the commit-m instance searched.
+var endpoint = defaultEndpoint
+
+// duration is a time.Duration written as a string ("30s") in the config.
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+type colorsConfig struct {
+	Repo      string `toml:"repo"`
+	Sha1      string `toml:"sha1"`
+	Highlight string `toml:"highlight"`
+}
+
+// config is the content of config.toml. Flags given on the command line
+// take precedence over it.
+type config struct {
+	Endpoint    string       `toml:"endpoint"`
+	Format      string       `toml:"format"`
+	Timeout     duration     `toml:"timeout"`
+	GithubToken string       `toml:"github_token"`
+	Proxy       string       `toml:"proxy"`
+	NoColor     bool         `toml:"no_color"`
+	Colors      colorsConfig `toml:"colors"`
+
+	DefaultProfile string             `toml:"default_profile"`
+	Profiles       map[string]profile `toml:"profile"`
+
+	Formatters map[string]string       `toml:"formatters"`
+	SMTP       smtpConfig              `toml:"smtp"`
+	APIKeys    map[string]apiKeyConfig `toml:"api_keys"`
+	// Profanity extends the words filtered out by --safe.
+	Profanity []string `toml:"profanity"`
+	// Watches are the searches watch runs without a keyword.
+	Watches []watchConfig `toml:"watch"`
+}
+
+// profile is a [profile.<name>] section, selected with --profile. Its
+// settings replace those at the top level of the config.
+type profile struct {
+	Endpoint    string   `toml:"endpoint"`
+	Format      string   `toml:"format"`
+	Timeout     duration `toml:"timeout"`
+	GithubToken string   `toml:"github_token"`
+	Proxy       string   `toml:"proxy"`
+}
+
+func (cfg *config) useProfile(name string) error {
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	cfg.Endpoint = firstNonEmpty(p.Endpoint, cfg.Endpoint)
+	cfg.Format = firstNonEmpty(p.Format, cfg.Format)
+	cfg.GithubToken = firstNonEmpty(p.GithubToken, cfg.GithubToken)
+	cfg.Proxy = firstNonEmpty(p.Proxy, cfg.Proxy)
+	if p.Timeout.Duration != 0 {
+		cfg.Timeout = p.Timeout
+	}
+	return nil
+}
+
+var globalFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "config",
+		EnvVar: "GOMMITM_CONFIG",
+		Usage:  "config file (default config.toml in the config directory)",
+	},
+	cli.StringFlag{
+		Name:   "profile",
+		EnvVar: "GOMMITM_PROFILE",
+		Usage:  "use the [profile.<name>] section of the config",
+	},
+	cli.StringFlag{
+		Name:  "endpoint",
+		Usage: "commit-m instance to search (default " + defaultEndpoint + "), see --gitlab-url and --sourcegraph-url for the other backends",
+	},
+	cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "timeout for HTTP requests (e.g. 30s)",
+	},
+	cli.StringFlag{
+		Name:  "proxy",
+		Usage: "HTTP proxy url (default from HTTP_PROXY/HTTPS_PROXY)",
+	},
+	cli.StringFlag{
+		Name:  "ca-cert",
+		Usage: "PEM file of CA certificates trusted in addition to the system ones, e.g. of a TLS-intercepting proxy",
+	},
+	cli.BoolFlag{
+		Name:  "insecure-skip-verify",
+		Usage: "do not verify TLS certificates (insecure)",
+	},
+	cli.StringFlag{
+		Name:   "user-agent",
+		EnvVar: "GOMMITM_USER_AGENT",
+		Usage:  "User-Agent of requests, e.g. with a contact address for bulk crawls (default gommit-m/VERSION and the project url)",
+	},
+	cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "disable colored output",
+	},
+	cli.BoolFlag{
+		Name:   "debug, v",
+		EnvVar: "GOMMITM_DEBUG",
+		Usage:  "log requests, redirects and parse statistics to stderr",
+	},
+	cli.StringFlag{
+		Name:  "log-format",
+		Value: "text",
+		Usage: "format of warnings and debug messages on stderr: text, or json for one JSON object per line",
+	},
+	cli.StringFlag{
+		Name:  "lang-ui",
+		Usage: "language of messages: en or ja (default from LANG)",
+	},
+	cli.IntFlag{
+		Name:  "max-retries",
+		Value: 3,
+		Usage: "times a request answered with 429 or 5xx is retried, after its Retry-After delay (0 disables)",
+	},
+	cli.IntFlag{
+		Name:  "concurrency",
+		Value: 1,
+		Usage: "pages requested at a time; more is faster but less polite",
+	},
+	cli.IntFlag{
+		Name:  "github-concurrency",
+		Value: 4,
+		Usage: "GitHub API lookups of --enrich requested at a time, slowing down as the rate limit nears",
+	},
+	cli.BoolFlag{
+		Name:  "strict",
+		Usage: "fail when a search page is not laid out as expected, instead of warning and parsing what can be",
+	},
+	cli.BoolFlag{
+		Name:  "trace",
+		Usage: "report DNS, connect, TLS, first byte and total time of requests, and parse time",
+	},
+}
+
+// configKeys are the top level keys of config. Any other key names a flag
+// whose default it sets, e.g. json = true.
+var configKeys = map[string]bool{
+	"endpoint": true, "format": true, "timeout": true, "github_token": true, "proxy": true,
+	"no_color": true, "colors": true, "default_profile": true, "profile": true,
+	"formatters": true, "smtp": true, "api_keys": true, "profanity": true,
+	"watch": true,
+}
+
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides cfg with GOMMITM_* environment variables, so they sit
+// between the config file and the command line flags.
+func applyEnv(cfg *config) error {
+	for _, v := range []struct {
+		name  string
+		value *string
+	}{
+		{"GOMMITM_ENDPOINT", &cfg.Endpoint},
+		{"GOMMITM_FORMAT", &cfg.Format},
+		{"GOMMITM_GITHUB_TOKEN", &cfg.GithubToken},
+		{"GOMMITM_PROXY", &cfg.Proxy},
+		{"GOMMITM_COLOR_REPO", &cfg.Colors.Repo},
+		{"GOMMITM_COLOR_SHA1", &cfg.Colors.Sha1},
+		{"GOMMITM_COLOR_HIGHLIGHT", &cfg.Colors.Highlight},
+	} {
+		if s := os.Getenv(v.name); s != "" {
+			*v.value = s
+		}
+	}
+	if s := os.Getenv("GOMMITM_TIMEOUT"); s != "" {
+		if err := cfg.Timeout.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("GOMMITM_TIMEOUT: %s", err)
+		}
+	}
+	if s := os.Getenv("GOMMITM_NO_COLOR"); s != "" {
+		noColor, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("GOMMITM_NO_COLOR: %s", err)
+		}
+		cfg.NoColor = noColor
+	}
+	return nil
+}
+
+// configure loads the config file and applies it, together with the global
+// flags, to the HTTP client, colors and the defaults of unset flags.
+func configure(c *cli.Context) error {
+	setUILang(c.String("lang-ui"))
+
+	path := c.String("config")
+	if path == "" {
+		path = configPath(configFile)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	if name := firstNonEmpty(c.String("profile"), cfg.DefaultProfile); name != "" {
+		if err := cfg.useProfile(name); err != nil {
+			return err
+		}
+	}
+	if err := applyEnv(cfg); err != nil {
+		return err
+	}
+	// The flags below are read as soon as they are set up, so the config
+	// defaults have to be in place first.
+	if err := setFlagDefaults(c, path); err != nil {
+		return err
+	}
+	setUILang(c.String("lang-ui"))
+
+	endpoint = firstNonEmpty(c.String("endpoint"), cfg.Endpoint, defaultEndpoint)
+	for name, command := range cfg.Formatters {
+		formatters[name] = command
+	}
+	smtpSettings = cfg.SMTP
+	apiKeySettings = cfg.APIKeys
+	profanity = append(profanity, cfg.Profanity...)
+	watchSettings = cfg.Watches
+
+	timeout := cfg.Timeout.Duration
+	if c.IsSet("timeout") {
+		timeout = c.Duration("timeout")
+	}
+	http.DefaultClient.Timeout = timeout
+	http.DefaultTransport = baseTransport
+	tuneTransport()
+	if err := setTLS(c); err != nil {
+		return err
+	}
+
+	if proxy := firstNonEmpty(c.String("proxy"), cfg.Proxy); proxy != "" {
+		proxyOverride = proxy
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy: %s", err)
+		}
+		baseTransport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if err := setFixtures(c); err != nil {
+		return err
+	}
+	setRetries(c.Int("max-retries"))
+	if concurrency = c.Int("concurrency"); concurrency < 1 {
+		return fmt.Errorf("invalid concurrency: %d", concurrency)
+	}
+	if githubConcurrency = c.Int("github-concurrency"); githubConcurrency < 1 {
+		return fmt.Errorf("invalid github concurrency: %d", githubConcurrency)
+	}
+	if err := useKeyring(c); err != nil {
+		logger.Warn("failed to read the tokens stored by auth login", "error", err)
+	}
+	if err := setAuth(c); err != nil {
+		return err
+	}
+	strictParsing = c.Bool("strict")
+	if c.Bool("trace") {
+		enableTrace()
+	}
+	if err := setLogger(c.String("log-format"), logLevel(c.Bool("debug")), false); err != nil {
+		return err
+	}
+	if c.Bool("debug") {
+		enableDebug()
+	}
+	debugf("config %s, endpoint %s, timeout %s", path, endpoint, timeout)
+
+	if c.Bool("no-color") || cfg.NoColor {
+		color.NoColor = true
+	}
+	if err := setTheme(cfg.Colors); err != nil {
+		return err
+	}
+
+	if cfg.Format != "" && !c.IsSet("format") {
+		if err := c.Set("format", cfg.Format); err != nil {
+			return err
+		}
+	}
+	userAgentOverride = c.String("user-agent")
+	// GITHUB_TOKEN from the environment still wins over the config file.
+	if cfg.GithubToken != "" && c.String("github-token") == "" {
+		if err := c.Set("github-token", cfg.GithubToken); err != nil {
+			return err
+		}
+	}
+	return selectBackend(c)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// setFlagDefaults sets the flags not given on the command line from the
+// other top level keys of the config file.
+func setFlagDefaults(c *cli.Context, path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	values := map[string]interface{}{}
+	if _, err := toml.DecodeFile(path, &values); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	flags := map[string]bool{}
+	for _, flag := range c.App.Flags {
+		if name, ok := declaredName(flag); ok {
+			flags[flagName(name)] = true
+		}
+	}
+	for key, value := range values {
+		name := strings.Replace(key, "_", "-", -1)
+		if configKeys[key] || c.IsSet(name) {
+			continue
+		}
+		if !flags[name] {
+			return fmt.Errorf("%s: unknown key %s", path, key)
+		}
+		list, ok := value.([]interface{})
+		if !ok {
+			list = []interface{}{value}
+		}
+		for _, v := range list {
+			if err := c.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("%s: %s: %s", path, key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/codegangsta/cli"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
+)
+
+// initConfig is the part of config written by config init.
+type initConfig struct {
+	Endpoint    string        `toml:"endpoint,omitempty"`
+	Format      string        `toml:"format,omitempty"`
+	GithubToken string        `toml:"github_token,omitempty"`
+	Proxy       string        `toml:"proxy,omitempty"`
+	NoColor     bool          `toml:"no_color,omitempty"`
+	Colors      *colorsConfig `toml:"colors,omitempty"`
+}
+
+var configCommand = cli.Command{
+	Name:  "config",
+	Usage: "manage the config file",
+	Subcommands: []cli.Command{
+		{
+			Name:  "init",
+			Usage: "ask for the endpoint, output format, colors, GitHub token and proxy, check them and write the config",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "overwrite an existing config",
+				},
+			},
+			Action: func(c *cli.Context) {
+				path := firstNonEmpty(c.GlobalString("config"), configPath(configFile))
+				if _, err := os.Stat(path); err == nil && !c.Bool("force") {
+					fmt.Fprintf(os.Stderr, "%s already exists, use --force to overwrite\n", path)
+					os.Exit(1)
+				}
+				cfg, err := askConfig(bufio.NewReader(os.Stdin))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if err := writeConfig(path, cfg); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				fmt.Println("wrote", path)
+			},
+		},
+	},
+}
+
+// ask prints the question and reads the answer, def when empty, until
+// check accepts it.
+func ask(r *bufio.Reader, question, def string, check func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Printf("%s [%s]: ", question, def)
+		} else {
+			fmt.Printf("%s: ", question)
+		}
+		line, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		answer := firstNonEmpty(strings.TrimSpace(line), def)
+		if check == nil {
+			return answer, nil
+		}
+		cerr := check(answer)
+		if cerr == nil {
+			return answer, nil
+		}
+		fmt.Println(" ", cerr)
+		if err == io.EOF {
+			return "", cerr
+		}
+	}
+}
+
+// askSecret is ask without echoing the answer on a terminal.
+func askSecret(r *bufio.Reader, question string, check func(string) error) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return ask(r, question, "", check)
+	}
+	for {
+		answer, err := readSecret(question + ": ")
+		if err != nil {
+			return "", err
+		}
+		cerr := check(answer)
+		if cerr == nil {
+			return answer, nil
+		}
+		fmt.Println(" ", cerr)
+	}
+}
+
+func confirm(r *bufio.Reader, question, def string) (bool, error) {
+	answer, err := ask(r, question+" (y/n)", def, func(answer string) error {
+		if answer != "y" && answer != "n" {
+			return fmt.Errorf("answer y or n")
+		}
+		return nil
+	})
+	return answer == "y", err
+}
+
+func askConfig(r *bufio.Reader) (*initConfig, error) {
+	cfg := &initConfig{}
+	var err error
+	if cfg.Proxy, err = ask(r, "HTTP proxy url (empty for none)", "", checkProxy); err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	if cfg.Proxy != "" {
+		proxyURL, _ := url.Parse(cfg.Proxy)
+		client.Transport = &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+	}
+	var endpoint string
+	for {
+		if endpoint, err = ask(r, "endpoint of commit-m", defaultEndpoint, checkEndpointURL); err != nil {
+			return nil, err
+		}
+		rerr := requestEndpoint(client, endpoint)
+		if rerr == nil {
+			break
+		}
+		fmt.Println(" ", rerr)
+		keep, err := confirm(r, "keep it anyway?", "n")
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			break
+		}
+	}
+	if endpoint != defaultEndpoint {
+		cfg.Endpoint = endpoint
+	}
+
+	format, err := ask(r, "output format (table, json, sexp, msgpack, cbor or proto)", "table", func(answer string) error {
+		if answer != "table" && answer != "proto" && !structuredFormat(answer) {
+			return fmt.Errorf("unknown format: %s", answer)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if format != "table" {
+		cfg.Format = format
+	}
+
+	colors, err := ask(r, "colors: default, none, or the repo,sha1,highlight colors (e.g. green,cyan,red)", "default", checkColors)
+	if err != nil {
+		return nil, err
+	}
+	switch colors {
+	case "default":
+	case "none":
+		cfg.NoColor = true
+	default:
+		parts := strings.Split(colors, ",")
+		cfg.Colors = &colorsConfig{Repo: parts[0], Sha1: parts[1], Highlight: parts[2]}
+	}
+
+	token, err := askSecret(r, "GitHub token for --enrich and --rank=stars (empty for none)", func(answer string) error {
+		return checkGithubToken(client, answer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return cfg, nil
+	}
+	store, err := confirm(r, "store the token in the OS keyring instead of the config?", "y")
+	if err != nil {
+		return nil, err
+	}
+	if store {
+		if err := keyring.Set(keyringService, "github", token); err != nil {
+			fmt.Printf("  could not store the token, writing it to the config: %v\n", err)
+		} else if err := setStoredName("github", true); err != nil {
+			return nil, err
+		} else {
+			return cfg, nil
+		}
+	}
+	cfg.GithubToken = token
+	return cfg, nil
+}
+
+func checkProxy(answer string) error {
+	if answer == "" {
+		return nil
+	}
+	u, err := url.Parse(answer)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid proxy url: %s", answer)
+	}
+	return nil
+}
+
+func checkEndpointURL(answer string) error {
+	u, err := url.Parse(answer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid endpoint, want http(s)://host: %s", answer)
+	}
+	return nil
+}
+
+// requestEndpoint requests the top page of the endpoint.
+func requestEndpoint(client *http.Client, endpoint string) error {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	closeBody(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", endpoint, res.Status)
+	}
+	return nil
+}
+
+func checkColors(answer string) error {
+	if answer == "default" || answer == "none" {
+		return nil
+	}
+	parts := strings.Split(answer, ",")
+	if len(parts) != 3 {
+		return fmt.Errorf("want default, none or three colors separated by commas")
+	}
+	for _, name := range parts {
+		if _, err := colorByName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGithubToken looks up the user of the token.
+func checkGithubToken(client *http.Client, answer string) error {
+	if answer == "" {
+		return nil
+	}
+	g := newGithubClient(answer)
+	g.http = client
+	user := struct {
+		Login string `json:"login"`
+	}{}
+	if err := g.get("/user", &user); err != nil {
+		return err
+	}
+	fmt.Printf("  token of %s\n", user.Login)
+	return nil
+}
+
+// writeConfig writes the config, readable only by the user when it holds
+// the token.
+func writeConfig(path string, cfg *initConfig) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if cfg.GithubToken != "" {
+		mode = 0600
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if err := toml.NewEncoder(f).Encode(cfg); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAskConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	answers := strings.Join([]string{
+		"",             // proxy
+		"ftp://x",      // invalid endpoint
+		server.URL,     // endpoint
+		"yaml",         // unknown format
+		"json",         // format
+		"red,blue",     // too few colors
+		"green,cyan,x", // unknown color
+		"green,cyan,red",
+		"", // no token
+	}, "\n") + "\n"
+	cfg, err := askConfig(bufio.NewReader(strings.NewReader(answers)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := writeConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `endpoint = "` + server.URL + `"
+format = "json"
+
+[colors]
+  repo = "green"
+  sha1 = "cyan"
+  highlight = "red"
+`
+	if string(data) != want {
+		t.Errorf("config:\n%s\nwant:\n%s", data, want)
+	}
+	loaded, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Endpoint != server.URL || loaded.Format != "json" || loaded.Colors.Highlight != "red" {
+		t.Errorf("loaded config = %+v", loaded)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/codegangsta/cli"
+)
+
+func runConfigured(t *testing.T, config string) error {
+	path := filepath.Join(t.TempDir(), configFile)
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := cli.NewApp()
+	app.Writer = ioutil.Discard
+	app.HideHelp = true
+	app.HideVersion = true
+	for _, flags := range [][]cli.Flag{searchFlags, dirFlags, globalFlags, authFlags, backendFlags, fixtureFlags, profileFlags} {
+		app.Flags = append(app.Flags, flags...)
+	}
+	app.Before = configure
+	app.Action = func(c *cli.Context) {}
+	return app.Run([]string{"gommit-m", "--config", path})
+}
+
+func TestConfigSetsGlobalFlags(t *testing.T) {
+	defer func(n int) { concurrency = n }(concurrency)
+	defer func(strict bool) { strictParsing = strict }(strictParsing)
+
+	if err := runConfigured(t, "concurrency = 3\nstrict = true\n"); err != nil {
+		t.Fatal(err)
+	}
+	if concurrency != 3 {
+		t.Errorf("concurrency = %d, want 3", concurrency)
+	}
+	if !strictParsing {
+		t.Error("strict = true in the config was ignored")
+	}
+
+	if err := runConfigured(t, "concurrency = 0\n"); err == nil {
+		t.Error("concurrency = 0 in the config was accepted")
+	}
+}
+
+func TestConfigureRebuildsTransport(t *testing.T) {
+	defer func(transport http.RoundTripper) { http.DefaultTransport = transport }(http.DefaultTransport)
+
+	for i := 0; i < 2; i++ {
+		if err := runConfigured(t, "max_retries = 2\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	retry, ok := http.DefaultTransport.(*retryTransport)
+	if !ok || retry.base != baseTransport {
+		t.Errorf("transport = %#v, want retries over the base transport", http.DefaultTransport)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// allPagesDelay is the politeness delay between pages for --all.
+const allPagesDelay = time.Second
+
+// concurrency is the number of pages requested at a time, set by
+// --concurrency.
+var concurrency = 1
+
+// pageRange is a range of result pages. last 0 means up to the last page.
+type pageRange struct {
+	first, last int
+}
+
+// parsePageRange parses "all", a single page "3" (pages 1 to 3) or an
+// inclusive range "2-10".
+func parsePageRange(s string) (pageRange, error) {
+	if s == "" || s == "all" {
+		return pageRange{first: 1}, nil
+	}
+	if parts := strings.SplitN(s, "-", 2); len(parts) == 2 {
+		first, ferr := strconv.Atoi(parts[0])
+		last, lerr := strconv.Atoi(parts[1])
+		if ferr != nil || lerr != nil || first < 1 || last < first {
+			return pageRange{}, fmt.Errorf("invalid page range: %s", s)
+		}
+		return pageRange{first: first, last: last}, nil
+	}
+	last, err := strconv.Atoi(s)
+	if err != nil || last < 1 {
+		return pageRange{}, fmt.Errorf("invalid page range: %s", s)
+	}
+	return pageRange{first: 1, last: last}, nil
+}
+
+// crawlPages fetches the pages of the range for the keyword one by one,
+// waiting delay between requests, and hands each page to fn. It stops at
+// the last result page, on the first error, or when fn returns false.
+func crawlPages(keyword string, pages pageRange, delay time.Duration, fn func(page int, result QueryResult) bool) error {
+	return crawlPagesWith(func(keyword string, page int) (QueryResult, error) {
+		return cachedCrawl(keyword, page, 0)
+	}, keyword, pages, delay, fn)
+}
+
+// crawlPagesWith is crawlPages fetching each page with fetch. Once the
+// first page has told the number of pages, up to concurrency pages are
+// fetched at a time, still handed to fn in order.
+func crawlPagesWith(fetch func(keyword string, page int) (QueryResult, error), keyword string, pages pageRange, delay time.Duration, fn func(page int, result QueryResult) bool) error {
+	last := pages.last
+	for page := pages.first; last == 0 || page <= last; {
+		if page > pages.first && delay > 0 && !pause(delay) {
+			return fmt.Errorf("page %d: %s", page, runContext.Err())
+		}
+		n := 1
+		if page > pages.first && concurrency > 1 {
+			n = concurrency
+			if last > 0 && last-page+1 < n {
+				n = last - page + 1
+			}
+		}
+		results, errs := fetchPages(fetch, keyword, page, n)
+		for i := range results {
+			if errs[i] != nil {
+				return fmt.Errorf("page %d: %s", page, errs[i])
+			}
+			if total, err := strconv.Atoi(results[i].TotalPages); err == nil && (last == 0 || total < last) {
+				last = total
+			}
+			if !fn(page, results[i]) || len(results[i].Commits) == 0 {
+				return nil
+			}
+			page++
+			if last > 0 && page > last {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// fetchPages fetches n pages from first in parallel.
+func fetchPages(fetch func(keyword string, page int) (QueryResult, error), keyword string, first, n int) ([]QueryResult, []error) {
+	results, errs := make([]QueryResult, n), make([]error, n)
+	if n == 1 {
+		results[0], errs[0] = fetch(keyword, first)
+		return results, errs
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = fetch(keyword, first+i)
+		}(i)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// crawlAll fetches every result page of the keyword into one result,
+// reporting the number of duplicates dropped on stderr. Progress is kept in
+// a checkpoint; with resume an interrupted crawl continues where it left.
+func crawlAll(keyword string, dedupeMessages, resume bool) (QueryResult, error) {
+	cp := loadCheckpoint("all:"+keyword, keyword, resume)
+	if cp.LastPage > 0 {
+		fmt.Fprintf(os.Stderr, "resuming after page %d\n", cp.LastPage)
+	}
+	all := QueryResult{Commits: append([]*commit{}, cp.Commits...)}
+	dedupe := cp.deduper(dedupeMessages)
+	err := crawlPages(keyword, cp.resumeFrom(pageRange{first: 1}), allPagesDelay, func(page int, result QueryResult) bool {
+		all.Commits = append(all.Commits, dedupe.filter(result.Commits)...)
+		all.ResultCount = result.ResultCount
+
+		cp.LastPage = page
+		cp.Commits = all.Commits
+		cp.Dropped = dedupe.dropped
+		if err := cp.save(); err != nil {
+			logger.Warn("failed to save checkpoint", "error", err)
+		}
+		return true
+	})
+	all.TotalPages = strconv.Itoa(cp.LastPage)
+	if dedupe.dropped > 0 {
+		fmt.Fprintf(os.Stderr, "dropped %d duplicates\n", dedupe.dropped)
+	}
+	if err == nil {
+		cp.remove()
+	}
+	return all, err
+}
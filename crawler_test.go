@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestParsePageRange(t *testing.T) {
+	for _, tt := range []struct {
+		in          string
+		first, last int
+		ok          bool
+	}{
+		{"", 1, 0, true},
+		{"all", 1, 0, true},
+		{"5", 1, 5, true},
+		{"2-4", 2, 4, true},
+		{"3-3", 3, 3, true},
+		{"3-1", 0, 0, false},
+		{"0", 0, 0, false},
+		{"0-2", 0, 0, false},
+		{"2-", 0, 0, false},
+		{"-2", 0, 0, false},
+		{"a-b", 0, 0, false},
+		{"ten", 0, 0, false},
+	} {
+		got, err := parsePageRange(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("parsePageRange(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got.first != tt.first || got.last != tt.last {
+			t.Errorf("parsePageRange(%q) = %d-%d, want %d-%d", tt.in, got.first, got.last, tt.first, tt.last)
+		}
+	}
+}
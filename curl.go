@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// proxyOverride is the proxy set by --proxy or the config, if any.
+var proxyOverride string
+
+// redacted replaces the secrets left out of curl commands.
+const redacted = "REDACTED"
+
+// curlCommand returns a curl invocation equivalent to the request made for
+// url, so connectivity can be checked outside of the Go HTTP stack. Unless
+// showSecrets is set, credentials are replaced by REDACTED and hidden
+// reports whether any was.
+func curlCommand(url string, showSecrets bool) (command string, hidden bool) {
+	args := []string{"curl", "-sS", "-L", "--compressed"}
+	args = append(args, "-H", shellQuote("User-Agent: "+userAgent()))
+	for name, values := range endpointHeader {
+		for _, v := range values {
+			if !showSecrets && isSecretHeader(name) {
+				v, hidden = redacted, true
+			}
+			args = append(args, "-H", shellQuote(name+": "+v))
+		}
+	}
+	if timeout := http.DefaultClient.Timeout; timeout > 0 {
+		args = append(args, "--max-time", fmt.Sprintf("%g", timeout.Seconds()))
+	}
+	proxy := proxyOverride
+	if proxy == "" {
+		if req, err := http.NewRequest("GET", url, nil); err == nil {
+			if u, err := http.ProxyFromEnvironment(req); err == nil && u != nil {
+				proxy = u.String()
+			}
+		}
+	}
+	if u, err := neturl.Parse(proxy); err == nil && !showSecrets {
+		if _, ok := u.User.Password(); ok {
+			u.User = neturl.UserPassword(u.User.Username(), redacted)
+			proxy, hidden = u.String(), true
+		}
+	}
+	if proxy != "" {
+		args = append(args, "--proxy", shellQuote(proxy))
+	}
+	if caCertPath != "" {
+		args = append(args, "--cacert", shellQuote(caCertPath))
+	}
+	if insecureTLS {
+		args = append(args, "--insecure")
+	}
+	return strings.Join(append(args, shellQuote(url)), " "), hidden
+}
+
+// isSecretHeader reports whether the header carries credentials, like
+// Authorization or the X-Api-Key of --header.
+func isSecretHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"authorization", "cookie", "token", "key", "secret", "password"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
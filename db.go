@@ -0,0 +1,245 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const dbFile = "gommit-m.db"
+
+const dbSchema = `
+CREATE TABLE IF NOT EXISTS commits (
+	repo       TEXT NOT NULL,
+	repo_url   TEXT NOT NULL,
+	sha1       TEXT NOT NULL,
+	commit_url TEXT NOT NULL,
+	message    TEXT NOT NULL,
+	keyword    TEXT NOT NULL,
+	fetched_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (repo, sha1, keyword)
+);
+CREATE INDEX IF NOT EXISTS commits_fetched_at ON commits (fetched_at);
+`
+
+// sha1Column reads back the sha1 of a row. Commits without one, such as
+// those of grep.app, are stored under their url so they do not collide on
+// the primary key.
+const sha1Column = "CASE WHEN sha1 = commit_url THEN '' ELSE sha1 END"
+
+func rowKey(c *commit) string {
+	return firstNonEmpty(c.Sha1, c.CommitURL)
+}
+
+func dbPath() string {
+	return dataPath(dbFile)
+}
+
+func openDB(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(dbSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// storeCommits records the commits found for the keyword, refreshing the
+// fetch time of commits already stored.
+func storeCommits(db *sql.DB, keyword string, commits []*commit) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO commits
+		(repo, repo_url, sha1, commit_url, message, keyword, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, c := range commits {
+		if _, err := stmt.Exec(c.Repo, c.RepoURL, rowKey(c), c.CommitURL, c.Message, keyword, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// recordFetched stores the commits crawled for the keyword in the local
+// database.
+func recordFetched(keyword string, commits []*commit) error {
+	db, err := openDB(dbPath())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return storeCommits(db, keyword, commits)
+}
+
+// writeResultsDB adds the commits to the database at path, for --output.
+// Commits already there for the keyword are replaced, not duplicated.
+func writeResultsDB(path, keyword string, commits []*commit) error {
+	db, err := openDB(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return storeCommits(db, keyword, commits)
+}
+
+type dbQuery struct {
+	Term    string
+	Repo    string
+	Keyword string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+func searchDB(db *sql.DB, q *dbQuery) ([]*commit, error) {
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+	if q.Term != "" {
+		where = append(where, "message LIKE ?")
+		args = append(args, "%"+q.Term+"%")
+	}
+	if q.Repo != "" {
+		where = append(where, "repo LIKE ?")
+		args = append(args, strings.Replace(q.Repo, "*", "%", -1))
+	}
+	if q.Keyword != "" {
+		where = append(where, "keyword = ?")
+		args = append(args, q.Keyword)
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "fetched_at >= ?")
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "fetched_at < ?")
+		args = append(args, q.Until.UTC())
+	}
+	query := fmt.Sprintf(`SELECT repo, repo_url, %s, commit_url, message
+		FROM commits WHERE %s GROUP BY repo, sha1 ORDER BY MAX(fetched_at) DESC`,
+		sha1Column, strings.Join(where, " AND "))
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commits := []*commit{}
+	for rows.Next() {
+		c := &commit{}
+		if err := rows.Scan(&c.Repo, &c.RepoURL, &c.Sha1, &c.CommitURL, &c.Message); err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+	return commits, rows.Err()
+}
+
+var dbCommand = cli.Command{
+	Name:  "db",
+	Usage: "query the local database of every fetched commit",
+	Subcommands: []cli.Command{
+		{
+			Name:      "search",
+			Usage:     "search stored commit messages",
+			ArgsUsage: "term",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "repo",
+					Usage: "only commits of repositories matching the pattern (* is a wildcard)",
+				},
+				cli.StringFlag{
+					Name:  "keyword",
+					Usage: "only commits fetched by searching for the keyword",
+				},
+				cli.StringFlag{
+					Name:  "since",
+					Usage: "only commits fetched on or after the date (YYYY-MM-DD)",
+				},
+				cli.StringFlag{
+					Name:  "until",
+					Usage: "only commits fetched before the date (YYYY-MM-DD)",
+				},
+				cli.IntFlag{
+					Name:  "limit, n",
+					Value: 100,
+					Usage: "maximum number of commits",
+				},
+			},
+			Action: func(c *cli.Context) {
+				if c.Args().First() == "" {
+					cli.ShowCommandHelp(c, "search")
+					os.Exit(exitUsage)
+				}
+				q := &dbQuery{
+					Term:    c.Args().First(),
+					Repo:    c.String("repo"),
+					Keyword: c.String("keyword"),
+					Limit:   c.Int("limit"),
+				}
+				var err error
+				if q.Since, err = parseDate(c.String("since")); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if q.Until, err = parseDate(c.String("until")); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+
+				db, err := openDB(dbPath())
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				defer db.Close()
+
+				commits, err := searchDB(db, q)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				result := QueryResult{
+					Commits:     commits,
+					ResultCount: fmt.Sprintf("%d results", len(commits)),
+					TotalPages:  "1",
+				}
+				showResult(result, dbPath(), q.Term, 1)
+			},
+		},
+		dbQueryCommand,
+		dbReindexCommand,
+	},
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreCommitsWithoutSha1(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), dbFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	commits := []*commit{
+		{Repo: "octo/cat", CommitURL: "https://grep.app/search?q=typo&f.repo=octo/cat&l=1", Message: "fix typo"},
+		{Repo: "octo/cat", CommitURL: "https://grep.app/search?q=typo&f.repo=octo/cat&l=2", Message: "fix another typo"},
+	}
+	if err := storeCommits(db, "typo", commits); err != nil {
+		t.Fatal(err)
+	}
+	found, err := searchDB(db, &dbQuery{Keyword: "typo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("found %d commits, want 2", len(found))
+	}
+	for _, c := range found {
+		if c.Sha1 != "" {
+			t.Errorf("sha1 = %q, want it empty as stored", c.Sha1)
+		}
+	}
+}
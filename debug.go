@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// debugEnabled is set by -v/--debug.
+var debugEnabled bool
+
+func debugf(format string, args ...interface{}) {
+	if debugEnabled {
+		logger.Debug(fmt.Sprintf(format, args...))
+	}
+}
+
+// debugTransport logs every request made through it with its status and
+// duration.
+type debugTransport struct {
+	base http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	debugf("%s %s", req.Method, req.URL)
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		debugf("%s %s: %s (%s)", req.Method, req.URL, err, time.Since(start))
+		return nil, err
+	}
+	debugf("%s %s: %s (%s)", req.Method, req.URL, res.Status, time.Since(start))
+	return res, nil
+}
+
+// enableDebug wraps the default transport, which every HTTP client in this
+// package uses, and logs redirects followed by the default client.
+func enableDebug() {
+	debugEnabled = true
+	http.DefaultTransport = &debugTransport{base: http.DefaultTransport}
+	http.DefaultClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		debugf("redirect %s -> %s", via[len(via)-1].URL, req.URL)
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// deduper drops commits already seen by (repo, sha1) and, when byMessage
+// is set, commits whose normalized message was already seen.
+type deduper struct {
+	byMessage bool
+	seen      map[string]bool
+	dropped   int
+}
+
+func newDeduper(byMessage bool) *deduper {
+	return &deduper{byMessage: byMessage, seen: map[string]bool{}}
+}
+
+func (d *deduper) filter(commits []*commit) []*commit {
+	unique := []*commit{}
+	for _, c := range commits {
+		keys := []string{"commit:" + commitKey(c)}
+		if d.byMessage {
+			keys = append(keys, "message:"+messageHash(c.Message))
+		}
+		duplicate := false
+		for _, key := range keys {
+			if d.seen[key] {
+				duplicate = true
+			}
+		}
+		if duplicate {
+			d.dropped++
+			continue
+		}
+		for _, key := range keys {
+			d.seen[key] = true
+		}
+		unique = append(unique, c)
+	}
+	return unique
+}
+
+// normalizeMessage lower cases the message, drops punctuation and collapses
+// whitespace, so trivially different messages compare equal.
+func normalizeMessage(message string) string {
+	words := strings.FieldsFunc(strings.ToLower(message), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	return strings.Join(words, " ")
+}
+
+func messageHash(message string) string {
+	sum := sha1.Sum([]byte(normalizeMessage(message)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestDeduperFilter(t *testing.T) {
+	fromGitHub := []*commit{
+		{Repo: "octo/cat", Sha1: "0123456", Message: "Fix typo", Source: "github"},
+		{Repo: "octo/dog", Sha1: "89abcde", Message: "fix typo.", Source: "github"},
+	}
+	fromCommitM := []*commit{
+		// the same commit found again by the other source
+		{Repo: "octo/cat", Sha1: "0123456", Message: "Fix typo", Source: "commit-m"},
+		{Repo: "octo/cat", Sha1: "fedcba9", Message: "Fix  TYPO", Source: "commit-m"},
+		// no sha1: identified by the url
+		{CommitURL: "https://example.com/1", Message: "Add tests"},
+		{CommitURL: "https://example.com/1", Message: "Add tests"},
+	}
+
+	for _, tt := range []struct {
+		byMessage bool
+		kept      []string
+		dropped   int
+	}{
+		{false, []string{"0123456", "89abcde", "fedcba9", ""}, 2},
+		{true, []string{"0123456", ""}, 4},
+	} {
+		d := newDeduper(tt.byMessage)
+		got := append(d.filter(fromGitHub), d.filter(fromCommitM)...)
+		if len(got) != len(tt.kept) || d.dropped != tt.dropped {
+			t.Errorf("byMessage %v: kept %d, dropped %d, want %d and %d", tt.byMessage, len(got), d.dropped, len(tt.kept), tt.dropped)
+			continue
+		}
+		for i, c := range got {
+			if c.Sha1 != tt.kept[i] {
+				t.Errorf("byMessage %v: kept %q at %d, want %q", tt.byMessage, c.Sha1, i, tt.kept[i])
+			}
+		}
+	}
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	for in, want := range map[string]string{
+		"Fix typo":        "fix typo",
+		"  fix\ttypo!  ":  "fix typo",
+		"fix: typo (#12)": "fix typo 12",
+		"":                "",
+		"修正しました。":         "修正しました",
+	} {
+		if got := normalizeMessage(in); got != want {
+			t.Errorf("normalizeMessage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/codegangsta/cli"
+)
+
+const appDirName = "gommit-m"
+
+// Directory overrides set by --config-dir, --cache-dir and --data-dir.
+var (
+	configDirOverride string
+	cacheDirOverride  string
+	dataDirOverride   string
+)
+
+var dirFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "config-dir",
+		EnvVar: "GOMMITM_CONFIG_DIR",
+		Usage:  "directory for configuration and saved searches",
+	},
+	cli.StringFlag{
+		Name:   "cache-dir",
+		EnvVar: "GOMMITM_CACHE_DIR",
+		Usage:  "directory for cached results and lookups",
+	},
+	cli.StringFlag{
+		Name:   "data-dir",
+		EnvVar: "GOMMITM_DATA_DIR",
+		Usage:  "directory for history, bookmarks and the database",
+	},
+}
+
+func setDirs(c *cli.Context) {
+	for _, d := range []struct {
+		flag     string
+		override *string
+	}{
+		{"config-dir", &configDirOverride},
+		{"cache-dir", &cacheDirOverride},
+		{"data-dir", &dataDirOverride},
+	} {
+		if dir := c.String(d.flag); dir != "" {
+			*d.override = dir
+		}
+	}
+	migrateLegacyDir()
+}
+
+func homeDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	return "."
+}
+
+// configDir is $XDG_CONFIG_HOME/gommit-m, or the platform equivalent
+// (~/Library/Application Support on macOS, %AppData% on Windows).
+func configDir() string {
+	if configDirOverride != "" {
+		return configDirOverride
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = filepath.Join(homeDir(), ".config")
+	}
+	return filepath.Join(dir, appDirName)
+}
+
+// cacheDir is $XDG_CACHE_HOME/gommit-m, or the platform equivalent
+// (~/Library/Caches on macOS, %LocalAppData% on Windows).
+func cacheDir() string {
+	if cacheDirOverride != "" {
+		return cacheDirOverride
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = filepath.Join(homeDir(), ".cache")
+	}
+	return filepath.Join(dir, appDirName)
+}
+
+// dataDir is $XDG_DATA_HOME/gommit-m, or the platform equivalent
+// (~/Library/Application Support on macOS, %LocalAppData% on Windows).
+func dataDir() string {
+	if dataDirOverride != "" {
+		return dataDirOverride
+	}
+	var dir string
+	switch runtime.GOOS {
+	case "windows":
+		dir = os.Getenv("LocalAppData")
+	case "darwin":
+		dir = filepath.Join(homeDir(), "Library", "Application Support")
+	default:
+		dir = os.Getenv("XDG_DATA_HOME")
+		if dir == "" || !filepath.IsAbs(dir) {
+			dir = filepath.Join(homeDir(), ".local", "share")
+		}
+	}
+	if dir == "" {
+		dir = homeDir()
+	}
+	return filepath.Join(dir, appDirName)
+}
+
+func configPath(elem ...string) string {
+	return filepath.Join(append([]string{configDir()}, elem...)...)
+}
+
+func cachePath(elem ...string) string {
+	return filepath.Join(append([]string{cacheDir()}, elem...)...)
+}
+
+func dataPath(elem ...string) string {
+	return filepath.Join(append([]string{dataDir()}, elem...)...)
+}
+
+// migrateLegacyDir moves files from ~/.gommit-m, used by earlier versions,
+// to the directories they belong in now.
+func migrateLegacyDir() {
+	legacy := filepath.Join(homeDir(), ".gommit-m")
+	files, err := ioutil.ReadDir(legacy)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		var target string
+		switch f.Name() {
+		case "last.json":
+			target = dataPath(sessionFile)
+		case savedSearchesFile:
+			target = configPath(f.Name())
+		case "cache":
+			target = cachePath(cacheResultsDir)
+		case starsCacheFile, commitsCacheFile:
+			target = cachePath(f.Name())
+		default:
+			target = dataPath(f.Name())
+		}
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(legacy, f.Name()), target); err != nil {
+			logger.Warn("failed to migrate", "file", f.Name(), "error", err)
+		}
+	}
+	os.Remove(legacy)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpConfig is the [smtp] table of the config, used by watch --email-to.
+// The password may be given in GOMMITM_SMTP_PASSWORD instead.
+type smtpConfig struct {
+	Host     string `toml:"host"`
+	Port     int    `toml:"port"`
+	User     string `toml:"user"`
+	Password string `toml:"password"`
+	From     string `toml:"from"`
+}
+
+var smtpSettings smtpConfig
+
+func (s smtpConfig) addr() string {
+	port := s.Port
+	if port == 0 {
+		port = 587
+	}
+	return net.JoinHostPort(s.Host, strconv.Itoa(port))
+}
+
+// digest is the plain text mail listing the commits.
+func digest(from string, to []string, keyword string, commits []*commit) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	subject := fmt.Sprintf("gommit-m: %d new commits for %q", len(commits), keyword)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	for _, c := range commits {
+		fmt.Fprintf(&b, "%s %s\r\n  %s\r\n  %s\r\n\r\n", c.Repo, c.Sha1, c.Message, c.CommitURL)
+	}
+	return []byte(b.String())
+}
+
+// sendDigest mails the commits to the addresses with the [smtp] settings.
+// Port 465 is implicit TLS; other ports upgrade with STARTTLS when the
+// server offers it.
+func sendDigest(to []string, keyword string, commits []*commit) error {
+	s := smtpSettings
+	if s.Host == "" {
+		return fmt.Errorf("--email-to needs an [smtp] host in %s", configFile)
+	}
+	if password := os.Getenv("GOMMITM_SMTP_PASSWORD"); password != "" {
+		s.Password = password
+	}
+	from := firstNonEmpty(s.From, s.User)
+	msg := digest(from, to, keyword, commits)
+	var auth smtp.Auth
+	if s.User != "" {
+		auth = smtp.PlainAuth("", s.User, s.Password, s.Host)
+	}
+	if s.Port != 465 {
+		return smtp.SendMail(s.addr(), auth, from, to, msg)
+	}
+
+	conn, err := tls.Dial("tcp", s.addr(), &tls.Config{ServerName: s.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
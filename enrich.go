@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// enrichValue is the value of --enrich. Given without a value it enables
+// author enrichment, otherwise it takes a comma separated list of fields.
+type enrichValue struct {
+	fields map[string]bool
+}
+
+var enrichFields = []string{"author", "stats"}
+
+type diffStats struct {
+	Files     int `json:"files"`
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+}
+
+func (s *diffStats) String() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d files +%d -%d", s.Files, s.Additions, s.Deletions)
+}
+
+// filterMaxChanges drops commits whose diffstat shows more than max changed
+// lines. Commits without stats are kept.
+func filterMaxChanges(commits []*commit, max int) []*commit {
+	filtered := []*commit{}
+	for _, c := range commits {
+		if c.Stats == nil || c.Stats.Additions+c.Stats.Deletions <= max {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// filterDates drops commits whose author date, from author enrichment, is
+// before since or after the day of until. Either may be zero. Commits
+// without a date are kept.
+func filterDates(commits []*commit, since, until time.Time) []*commit {
+	if since.IsZero() && until.IsZero() {
+		return commits
+	}
+	filtered := []*commit{}
+	for _, c := range commits {
+		date, err := time.Parse(time.RFC3339, c.Date)
+		if err != nil ||
+			(since.IsZero() || !date.Before(since)) && (until.IsZero() || date.Before(until.AddDate(0, 0, 1))) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// filterAuthors keeps the commits whose enriched author matches one of
+// the patterns of authors, when given, and none of exclude. Patterns are
+// logins or names, with * as a wildcard ("*[bot]"), matched ignoring case.
+func filterAuthors(commits []*commit, authors, exclude []string) []*commit {
+	if len(authors) == 0 && len(exclude) == 0 {
+		return commits
+	}
+	matches := func(patterns []string, author string) bool {
+		for _, p := range patterns {
+			if globPattern(p).MatchString(author) {
+				return true
+			}
+		}
+		return false
+	}
+	filtered := []*commit{}
+	for _, c := range commits {
+		if (len(authors) == 0 || matches(authors, c.Author)) && !matches(exclude, c.Author) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+func (e *enrichValue) Set(value string) error {
+	if e.fields == nil {
+		e.fields = map[string]bool{}
+	}
+	if value == "true" {
+		value = "author"
+	}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !contains(enrichFields, field) {
+			return fmt.Errorf("unknown enrich field %q (one of %s)", field, strings.Join(enrichFields, ", "))
+		}
+		e.fields[field] = true
+	}
+	return nil
+}
+
+func (e *enrichValue) String() string {
+	fields := []string{}
+	for field := range e.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, ",")
+}
+
+func (e *enrichValue) IsBoolFlag() bool {
+	return true
+}
+
+func (e *enrichValue) Has(field string) bool {
+	return e != nil && e.fields[field]
+}
+
+// with returns the value with the field enabled too, for filters needing
+// the field.
+func (e *enrichValue) with(field string) *enrichValue {
+	fields := map[string]bool{field: true}
+	if e != nil {
+		for f := range e.fields {
+			fields[f] = true
+		}
+	}
+	return &enrichValue{fields: fields}
+}
+
+func (e *enrichValue) enabled() bool {
+	return e != nil && len(e.fields) > 0
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
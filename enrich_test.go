@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterDates(t *testing.T) {
+	commits := []*commit{
+		{Sha1: "old", Date: "2019-12-31T23:59:59Z"},
+		{Sha1: "first", Date: "2020-01-01T00:00:00Z"},
+		{Sha1: "last", Date: "2022-12-31T12:00:00Z"},
+		{Sha1: "new", Date: "2023-01-01T00:00:00Z"},
+		{Sha1: "undated"},
+	}
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		since, until time.Time
+		want         []string
+	}{
+		{since, until, []string{"first", "last", "undated"}},
+		{since, time.Time{}, []string{"first", "last", "new", "undated"}},
+		{time.Time{}, until, []string{"old", "first", "last", "undated"}},
+		{time.Time{}, time.Time{}, []string{"old", "first", "last", "new", "undated"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, c := range filterDates(commits, tt.since, tt.until) {
+			got = append(got, c.Sha1)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("filterDates(%v, %v) = %v, want %v", tt.since, tt.until, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("filterDates(%v, %v) = %v, want %v", tt.since, tt.until, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFilterAuthors(t *testing.T) {
+	commits := []*commit{
+		{Sha1: "1", Author: "yuroyoro"},
+		{Sha1: "2", Author: "dependabot[bot]"},
+		{Sha1: "3", Author: "renovate[bot]"},
+		{Sha1: "4", Author: "Alice"},
+		{Sha1: "5"},
+	}
+	tests := []struct {
+		authors, exclude []string
+		want             string
+	}{
+		{nil, nil, "12345"},
+		{[]string{"yuroyoro"}, nil, "1"},
+		{[]string{"alice", "yuroyoro"}, nil, "14"},
+		{nil, []string{"*[bot]"}, "145"},
+		{[]string{"*[bot]"}, []string{"renovate*"}, "2"},
+	}
+	for _, tt := range tests {
+		got := ""
+		for _, c := range filterAuthors(commits, tt.authors, tt.exclude) {
+			got += c.Sha1
+		}
+		if got != tt.want {
+			t.Errorf("filterAuthors(%v, %v) = %s, want %s", tt.authors, tt.exclude, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "fmt"
+
+// Exit codes of a search, documented in the README.
+const (
+	exitOK        = 0
+	exitUsage     = 1
+	exitNetwork   = 2
+	exitParse     = 3
+	exitNoResults = 4 // only with --fail-empty
+
+	exitInterrupted = 130
+)
+
+// parseError is returned when a fetched page does not look like a commit-m
+// search result.
+type parseError struct {
+	url string
+	// reason is what looked wrong on a page with --strict.
+	reason string
+}
+
+func (e *parseError) Error() string {
+	if e.reason != "" {
+		return fmt.Sprintf(tr("%s: unexpected page structure: %s"), e.url, e.reason)
+	}
+	return fmt.Sprintf(tr("%s: unexpected page, no search results found"), e.url)
+}
+
+// failureExitCode classifies an error from fetching results. Anything that
+// is not a parse error happened before there was a page to parse, unless
+// the command was interrupted.
+func failureExitCode(err error) int {
+	if interrupted() {
+		return exitInterrupted
+	}
+	if _, ok := err.(*parseError); ok {
+		return exitParse
+	}
+	return exitNetwork
+}
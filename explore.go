@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// exploreKeywords are the keywords explore picks from, chosen for the
+// variety of their results.
+var exploreKeywords = []string{
+	"typo", "oops", "finally", "magic", "hack", "workaround", "temporary", "revert",
+	"refactor", "cleanup", "initial commit", "wip", "forgot", "again", "hotfix",
+	"sorry", "why", "happy", "coffee", "friday", "yolo", "todo", "nobody", "legacy",
+}
+
+var exploreCommand = cli.Command{
+	Name:  "explore",
+	Usage: "show a random page of results of a random keyword, for browsing",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "history",
+			Usage: "pick the keyword from the keywords searched before that had results",
+		},
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: 24 * time.Hour,
+			Usage: "reuse cached pages fetched within this duration",
+		},
+	},
+	Action: func(c *cli.Context) {
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		keywords := exploreKeywords
+		if c.Bool("history") {
+			var err error
+			if keywords, err = keywordsWithResults(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if len(keywords) == 0 {
+				fmt.Fprintln(os.Stderr, "no keyword in the history had results")
+				os.Exit(1)
+			}
+		}
+		keyword := keywords[rnd.Intn(len(keywords))]
+		fmt.Fprintf(os.Stderr, "keyword: %s\n\n", keyword)
+
+		page := 1
+		result, err := cachedCrawl(keyword, page, c.Duration("cache-ttl"))
+		if total, _ := strconv.Atoi(result.TotalPages); err == nil && total > 1 {
+			page = rnd.Intn(total) + 1
+			if page > 1 {
+				result, err = cachedCrawl(keyword, page, c.Duration("cache-ttl"))
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(failureExitCode(err))
+		}
+		if serr := saveSession(keyword, page, result); serr != nil {
+			logger.Warn("failed to save session", "error", serr)
+		}
+		showResult(result, buildUrl(keyword, page), keyword, page)
+	},
+}
+
+// keywordsWithResults returns the distinct keywords of the history that had
+// results.
+func keywordsWithResults() ([]string, error) {
+	history, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	keywords := []string{}
+	for _, h := range history {
+		if h.ResultCount > 0 && !seen[h.Keyword] {
+			seen[h.Keyword] = true
+			keywords = append(keywords, h.Keyword)
+		}
+	}
+	return keywords, nil
+}
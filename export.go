@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// exportRecord is one line of a dataset export.
+type exportRecord struct {
+	commit
+	Keyword   string    `json:"keyword"`
+	Page      int       `json:"page"`
+	Position  int       `json:"position"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+var exportCommand = cli.Command{
+	Name:  "export",
+	Usage: "crawl result pages for a keyword and write them as a dataset",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "keyword, k",
+			Usage: "keyword to crawl",
+		},
+		cli.StringFlag{
+			Name:  "pages",
+			Value: "all",
+			Usage: "pages to crawl: all, N or FIRST-LAST",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: "jsonl",
+			Usage: "dataset format: jsonl, parquet, msgpack, cbor or proto (length-delimited ExportRecord of proto/commit_search.proto)",
+		},
+		cli.StringFlag{
+			Name:  "out, o",
+			Usage: "file to write (default stdout)",
+		},
+		cli.DurationFlag{
+			Name:  "delay",
+			Value: time.Second,
+			Usage: "politeness delay between requests",
+		},
+		cli.BoolFlag{
+			Name:  "dedupe-messages",
+			Usage: "also drop commits whose normalized message was already written",
+		},
+		cli.BoolFlag{
+			Name:  "resume",
+			Usage: "continue an interrupted export, appending to --out",
+		},
+	},
+	Action: func(c *cli.Context) {
+		keyword := c.String("keyword")
+		if keyword == "" {
+			keyword = c.Args().First()
+		}
+		if keyword == "" {
+			cli.ShowCommandHelp(c, "export")
+			os.Exit(1)
+		}
+		pages, err := parsePageRange(c.String("pages"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		format := c.String("format")
+		if _, err := newRecordWriter(format, ioutil.Discard); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if c.Bool("resume") && !appendable(format) {
+			fmt.Fprintf(os.Stderr, "--resume is not supported with --format %s\n", format)
+			os.Exit(1)
+		}
+
+		key := fmt.Sprintf("export:%s:%s:%s:%s", keyword, c.String("pages"), c.String("format"), c.String("out"))
+		cp := loadCheckpoint(key, keyword, c.Bool("resume"))
+		if cp.LastPage > 0 {
+			fmt.Fprintf(os.Stderr, "resuming after page %d (%d commits written)\n", cp.LastPage, cp.Written)
+		}
+
+		var out io.Writer = os.Stdout
+		var outFile *os.File
+		if path := c.String("out"); path != "" {
+			flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			if cp.LastPage > 0 {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+			f, err := os.OpenFile(path, flags, 0644)
+			if err == nil && cp.Offset > 0 {
+				// drop the lines of a page interrupted half way
+				err = f.Truncate(cp.Offset)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			out, outFile = f, f
+		}
+
+		records, _ := newRecordWriter(format, out)
+		dedupe := cp.deduper(c.Bool("dedupe-messages"))
+		written := cp.Written
+		// streamed, so memory stays flat however many pages are exported
+		position, now := 0, time.Now().UTC()
+		err = streamPages(keyword, cp.resumeFrom(pages), c.Duration("delay"), func(page int, found *commit) error {
+			if len(dedupe.filter([]*commit{found})) == 0 {
+				return nil
+			}
+			position++
+			if err := records.Write(&exportRecord{
+				commit:    *found,
+				Keyword:   keyword,
+				Page:      page,
+				Position:  position,
+				FetchedAt: now,
+			}); err != nil {
+				return err
+			}
+			written++
+			return nil
+		}, func(page int) bool {
+			position, now = 0, time.Now().UTC()
+			fmt.Fprintf(os.Stderr, "\rpage %d: %d commits", page, written)
+
+			cp.LastPage = page
+			cp.Written = written
+			cp.Dropped = dedupe.dropped
+			if outFile != nil {
+				cp.Offset, _ = outFile.Seek(0, io.SeekCurrent)
+			}
+			if err := cp.save(); err != nil {
+				logger.Warn("failed to save checkpoint", "error", err)
+			}
+			return true
+		})
+		if cerr := records.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "wrote %d commits, dropped %d duplicates\n", written, dedupe.dropped)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, "run again with --resume to continue")
+			if interrupted() {
+				os.Exit(exitInterrupted)
+			}
+			os.Exit(1)
+		}
+		cp.remove()
+	},
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// recordWriter writes the records of an export in one format. Close
+// finishes the dataset and must be called even after an error, so what was
+// written stays readable.
+type recordWriter interface {
+	Write(r *exportRecord) error
+	Close() error
+}
+
+// appendable reports whether an export in the format can be continued
+// with --resume, by appending to the file.
+func appendable(format string) bool {
+	return format != "parquet"
+}
+
+func newRecordWriter(format string, w io.Writer) (recordWriter, error) {
+	switch format {
+	case "jsonl":
+		return jsonlWriter{json.NewEncoder(w)}, nil
+	case "msgpack", "cbor":
+		enc, _ := newBinaryEncoder(format, w)
+		return binaryWriter{enc}, nil
+	case "proto":
+		return protoWriter{w}, nil
+	case "parquet":
+		return &parquetWriter{parquet.NewGenericWriter[parquetRecord](w, parquet.Compression(&parquet.Zstd))}, nil
+	}
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (w jsonlWriter) Write(r *exportRecord) error {
+	return w.enc.Encode(r)
+}
+
+func (w jsonlWriter) Close() error {
+	return nil
+}
+
+type binaryWriter struct {
+	enc encoder
+}
+
+func (w binaryWriter) Write(r *exportRecord) error {
+	return w.enc.Encode(r)
+}
+
+func (w binaryWriter) Close() error {
+	return nil
+}
+
+type protoWriter struct {
+	w io.Writer
+}
+
+func (w protoWriter) Write(r *exportRecord) error {
+	_, err := w.w.Write(appendDelimited(nil, &pbExportRecord{r}))
+	return err
+}
+
+func (w protoWriter) Close() error {
+	return nil
+}
+
+// parquetRecord is the row of a parquet export, the flat columns of
+// exportRecord.
+type parquetRecord struct {
+	Keyword    string    `parquet:"keyword,dict"`
+	Page       int32     `parquet:"page"`
+	Position   int32     `parquet:"position"`
+	FetchedAt  time.Time `parquet:"fetched_at,timestamp(millisecond)"`
+	Repo       string    `parquet:"repo,dict"`
+	RepoURL    string    `parquet:"repo_url,dict"`
+	Sha1       string    `parquet:"sha1"`
+	CommitURL  string    `parquet:"commit_url"`
+	Message    string    `parquet:"message"`
+	Body       string    `parquet:"body,optional"`
+	Author     string    `parquet:"author,optional"`
+	Date       string    `parquet:"date,optional"`
+	Source     string    `parquet:"source,optional,dict"`
+	Stars      int32     `parquet:"stars,optional"`
+	Dead       bool      `parquet:"dead"`
+	ArchiveURL string    `parquet:"archive_url,optional"`
+}
+
+type parquetWriter struct {
+	w *parquet.GenericWriter[parquetRecord]
+}
+
+func (w *parquetWriter) Write(r *exportRecord) error {
+	_, err := w.w.Write([]parquetRecord{{
+		Keyword:    r.Keyword,
+		Page:       int32(r.Page),
+		Position:   int32(r.Position),
+		FetchedAt:  r.FetchedAt,
+		Repo:       r.Repo,
+		RepoURL:    r.RepoURL,
+		Sha1:       r.Sha1,
+		CommitURL:  r.CommitURL,
+		Message:    r.Message,
+		Body:       r.Body,
+		Author:     r.Author,
+		Date:       r.Date,
+		Source:     r.Source,
+		Stars:      int32(r.Stars),
+		Dead:       r.Dead,
+		ArchiveURL: r.ArchiveURL,
+	}})
+	return err
+}
+
+func (w *parquetWriter) Close() error {
+	return w.w.Close()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// withFallback searches primary, and fallback when primary fails or does
+// not answer within timeout.
+type withFallback struct {
+	primary  Backend
+	fallback Backend
+	timeout  time.Duration
+}
+
+func (b withFallback) Name() string {
+	return b.primary.Name()
+}
+
+func (b withFallback) URL(query string, page int) string {
+	return b.primary.URL(query, page)
+}
+
+func (b withFallback) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	primaryCtx := ctx
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		primaryCtx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+	result, err := b.primary.Search(primaryCtx, query, page)
+	if err == nil {
+		return result, nil
+	}
+	debugf("%s: %s, falling back to %s", b.primary.Name(), err, b.fallback.Name())
+	result, ferr := b.fallback.Search(ctx, query, page)
+	if ferr != nil {
+		// the primary error says more about what went wrong
+		return result, err
+	}
+	result.Fallback = b.fallback.Name()
+	return result, nil
+}
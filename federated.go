@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// federated searches all of its backends concurrently and merges their
+// results, each commit tagged with the backend it came from.
+type federated []Backend
+
+func (f federated) Name() string {
+	names := []string{}
+	for _, b := range f {
+		names = append(names, b.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// URL is the url of the first backend; there is no page showing them all.
+func (f federated) URL(query string, page int) string {
+	return f[0].URL(query, page)
+}
+
+func (f federated) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	results := make([]QueryResult, len(f))
+	errs := make([]error, len(f))
+	var wg sync.WaitGroup
+	for i, b := range f {
+		wg.Add(1)
+		go func(i int, b Backend) {
+			defer wg.Done()
+			results[i], errs[i] = b.Search(ctx, query, page)
+		}(i, b)
+	}
+	wg.Wait()
+
+	merged := QueryResult{Commits: []*commit{}}
+	seen := map[string]bool{}
+	count, pages, failed := 0, 1, 0
+	for i, result := range results {
+		if errs[i] != nil {
+			logger.Warn("backend failed", "backend", f[i].Name(), "error", errs[i])
+			failed++
+			continue
+		}
+		for _, c := range result.Commits {
+			if c.Source == "" {
+				c.Source = f[i].Name()
+			}
+			if key := commitKey(c); !seen[key] {
+				seen[key] = true
+				merged.Commits = append(merged.Commits, c)
+			}
+		}
+		count += parseResultCount(result.ResultCount)
+		if n, _ := strconv.Atoi(result.TotalPages); n > pages {
+			pages = n
+		}
+	}
+	if failed == len(f) {
+		return merged, errs[0]
+	}
+	merged.ResultCount = fmt.Sprintf("%d results", count)
+	merged.TotalPages = strconv.Itoa(pages)
+	return merged, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var feedCommand = cli.Command{
+	Name:         "feed",
+	Usage:        "write an Atom feed of the results of a saved search (also served by serve at /feed/<name>)",
+	ArgsUsage:    "saved-search",
+	BashComplete: completeSavedSearches,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "out, o",
+			Usage: "file to write (default stdout)",
+		},
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: time.Hour,
+			Usage: "reuse cached results fetched within this duration",
+		},
+	},
+	Action: func(c *cli.Context) {
+		name := c.Args().First()
+		if name == "" {
+			cli.ShowCommandHelp(c, "feed")
+			os.Exit(1)
+		}
+		searches, err := loadSavedSearches()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		s, ok := searches[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "no saved search: %s\n", name)
+			os.Exit(1)
+		}
+
+		out := io.Writer(os.Stdout)
+		if path := c.String("out"); path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := writeFeed(out, s, c.Duration("cache-ttl")); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Author  string   `xml:"author>name,omitempty"`
+	Summary string   `xml:"summary"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// savedQuery returns the keyword, page and excluded words of a saved
+// search. Its flags are written as --name=value, so the arguments without
+// dashes are the keyword and page.
+func savedQuery(s *savedSearch) (keyword string, page int, exclude []string) {
+	positional := []string{}
+	for _, arg := range s.Args {
+		if strings.HasPrefix(arg, "--exclude=") {
+			exclude = append(exclude, strings.TrimPrefix(arg, "--exclude="))
+		} else if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 0 {
+		keyword = positional[0]
+	}
+	if len(positional) > 1 {
+		return keyword, parsePage(positional[1]), exclude
+	}
+	return keyword, 1, exclude
+}
+
+func writeFeed(w io.Writer, s *savedSearch, ttl time.Duration) error {
+	keyword, page, exclude := savedQuery(s)
+	result, err := cachedCrawl(keyword, page, ttl)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	url := buildUrl(keyword, page)
+	feed := &atomFeed{
+		Title:   fmt.Sprintf("gommit-m: %s", s.Name),
+		ID:      url,
+		Link:    atomLink{Href: url, Rel: "alternate"},
+		Updated: now,
+	}
+	for _, c := range excludeCommits(result.Commits, exclude) {
+		updated := now
+		if t, err := time.Parse(time.RFC3339, c.Date); err == nil {
+			updated = t.UTC().Format(time.RFC3339)
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   c.Message,
+			ID:      c.CommitURL,
+			Link:    atomLink{Href: c.CommitURL},
+			Updated: updated,
+			Author:  c.Author,
+			Summary: fmt.Sprintf("%s %s", c.Repo, c.Sha1),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// userAgentOverride is the User-Agent set by --user-agent, if any.
+var userAgentOverride string
+
+// userAgent identifies gommit-m to the sites it fetches pages from.
+func userAgent() string {
+	if userAgentOverride != "" {
+		return userAgentOverride
+	}
+	return "gommit-m/" + version + " (+https://github.com/yuroyoro/gommit-m)"
+}
+
+// statusError is returned when a fetched page answers with another status
+// than 200.
+type statusError struct {
+	url    string
+	status string
+	code   int
+	// retryAfter is the Retry-After header of 429 and 503 responses.
+	retryAfter string
+}
+
+func (e *statusError) Error() string {
+	if e.retryAfter != "" {
+		return fmt.Sprintf("%s: %s (retry after %s)", e.url, e.status, e.retryAfter)
+	}
+	return fmt.Sprintf("%s: %s", e.url, e.status)
+}
+
+// maxDrain is how much of an unread body closeBody reads so the
+// connection can be reused.
+const maxDrain = 256 << 10
+
+// closeBody reads what is left of the body before closing it: the
+// transport only reuses the connection of a body read to the end, and
+// decoders stop at the end of the value they decode.
+func closeBody(body io.ReadCloser) error {
+	io.Copy(ioutil.Discard, io.LimitReader(body, maxDrain))
+	return body.Close()
+}
+
+// baseTransport is the transport at the bottom of the wrappers configure
+// stacks on http.DefaultTransport. run and history configure again for each
+// command they replay, so the chain is rebuilt on it every time.
+var baseTransport = http.DefaultTransport.(*http.Transport)
+
+// tuneTransport keeps more idle connections per host than the default two,
+// so the pages of multi-page crawls and the enrichment lookups done in
+// between reuse their connections, over HTTP/2 where the server offers it.
+func tuneTransport() {
+	baseTransport.ForceAttemptHTTP2 = true
+	baseTransport.MaxIdleConns = 100
+	baseTransport.MaxIdleConnsPerHost = 8
+	baseTransport.IdleConnTimeout = 90 * time.Second
+}
+
+// gzipBody decompresses the body when it is read and closes both readers.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	io.Copy(ioutil.Discard, io.LimitReader(b.Reader, maxDrain))
+	b.Reader.Close()
+	return closeBody(b.body)
+}
+
+// fetchPage gets the page, asking for a gzipped response. The body of the
+// returned response is decompressed; any status other than 200 is a
+// statusError.
+func fetchPage(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	// set explicitly, the transport no longer decompresses the response
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		closeBody(res.Body)
+		return nil, &statusError{url: url, status: res.Status, code: res.StatusCode, retryAfter: res.Header.Get("Retry-After")}
+	}
+	if strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(res.Body)
+		if err != nil {
+			res.Body.Close()
+			return nil, fmt.Errorf("%s: %s", url, err)
+		}
+		res.Body = &gzipBody{Reader: zr, body: res.Body}
+		res.Header.Del("Content-Encoding")
+		res.Header.Del("Content-Length")
+		res.ContentLength = -1
+		res.Uncompressed = true
+	}
+	return res, nil
+}
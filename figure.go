@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/mattn/go-runewidth"
+)
+
+// bubbleWidth is the width the message is wrapped to in the speech bubble.
+const bubbleWidth = 40
+
+// figures say the message in a speech bubble. The bubble tail is drawn by
+// the first lines.
+var figures = map[string]string{
+	"cow": `        \   ^__^
+         \  (oo)\_______
+            (__)\       )\/\
+                ||----w |
+                ||     ||`,
+	"tux": `        \
+         \   .--.
+            |o_o |
+            |:_/ |
+           //   \ \
+          (|     | )
+         /'\_   _/'\
+         \___)=(___/`,
+	"cat": `        \
+         \   /\_/\
+            ( o.o )
+             > ^ <`,
+}
+
+var figureFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "cow",
+		Usage: "have a cow say the first message (same as --figure=cow)",
+	},
+	cli.StringFlag{
+		Name:  "figure",
+		Usage: "have a figure say the first message: " + strings.Join(figureNames(), ", "),
+	},
+}
+
+func figureNames() []string {
+	names := []string{}
+	for name := range figures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectedFigure returns the figure of --figure or --cow, or "" for none.
+func selectedFigure(c *cli.Context) string {
+	name := c.String("figure")
+	if name == "" && c.Bool("cow") {
+		name = "cow"
+	}
+	if _, ok := figures[name]; name != "" && !ok {
+		fmt.Fprintf(os.Stderr, tr("unknown figure: %s\n"), name)
+		os.Exit(1)
+	}
+	return name
+}
+
+// printFigure prints the figure saying the message of the commit.
+func printFigure(name string, c *commit) {
+	lines := wrapText(c.Message, bubbleWidth)
+	width := 0
+	for _, line := range lines {
+		if w := runewidth.StringWidth(line); w > width {
+			width = w
+		}
+	}
+	fmt.Println(" " + strings.Repeat("_", width+2))
+	for i, line := range lines {
+		left, right := "|", "|"
+		switch {
+		case len(lines) == 1:
+			left, right = "<", ">"
+		case i == 0:
+			left, right = "/", "\\"
+		case i == len(lines)-1:
+			left, right = "\\", "/"
+		}
+		fmt.Printf("%s %s %s\n", left, runewidth.FillRight(line, width), right)
+	}
+	fmt.Println(" " + strings.Repeat("-", width+2))
+	fmt.Println(figures[name])
+	fmt.Printf("    -- %s %s\n", c.Repo, c.displayURL())
+}
+
+// wrapText breaks the text into lines of at most width cells, at spaces
+// when possible.
+func wrapText(text string, width int) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for runewidth.StringWidth(word) > width {
+				head := runewidth.Truncate(word, width, "")
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				lines = append(lines, head)
+				word = word[len(head):]
+			}
+			if word == "" {
+				continue
+			}
+			switch {
+			case line == "":
+				line = word
+			case runewidth.StringWidth(line+" "+word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
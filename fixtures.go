@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+var fixtureFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "record",
+		Usage: "save every HTTP response to the directory, for --replay",
+	},
+	cli.StringFlag{
+		Name:  "replay",
+		Usage: "answer HTTP requests with the responses saved by --record in the directory, never touching the network",
+	},
+}
+
+// fixture is a recorded HTTP exchange. Only the method and url of the
+// request are kept, so credentials are never written to disk.
+type fixture struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// fixtureTransport records the responses of base to dir or, when replay is
+// set, answers from the responses recorded there.
+type fixtureTransport struct {
+	base   http.RoundTripper
+	dir    string
+	replay bool
+}
+
+// fixtureFile is the file of the request in dir, named after its method,
+// url and body.
+func fixtureFile(dir string, req *http.Request, body []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL)
+	h.Write(body)
+	return filepath.Join(dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	path := fixtureFile(t.dir, req, body)
+
+	if t.replay {
+		f := &fixture{}
+		if err := loadJSON(path, f); err != nil {
+			return nil, err
+		}
+		if f.URL == "" {
+			return nil, fmt.Errorf("no recorded response for %s %s in %s", req.Method, req.URL, t.dir)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+			StatusCode:    f.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        f.Header,
+			Body:          ioutil.NopCloser(strings.NewReader(f.Body)),
+			ContentLength: int64(len(f.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	header := res.Header.Clone()
+	header.Del("Set-Cookie")
+	// the body is saved decoded
+	saved := data
+	if strings.EqualFold(header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err == nil {
+			saved, err = ioutil.ReadAll(zr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("recording %s: %s", req.URL, err)
+		}
+	}
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	f := &fixture{Method: req.Method, URL: req.URL.String(), Status: res.StatusCode, Header: header, Body: string(saved)}
+	if err := saveJSON(path, f); err != nil {
+		return nil, fmt.Errorf("recording %s: %s", req.URL, err)
+	}
+	return res, nil
+}
+
+// setFixtures installs the transport of --record or --replay.
+func setFixtures(c *cli.Context) error {
+	record, replay := c.String("record"), c.String("replay")
+	switch {
+	case record != "" && replay != "":
+		return fmt.Errorf("--record and --replay cannot be used together")
+	case record != "":
+		http.DefaultTransport = &fixtureTransport{base: http.DefaultTransport, dir: record}
+	case replay != "":
+		http.DefaultTransport = &fixtureTransport{base: http.DefaultTransport, dir: replay, replay: true}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// replayFixtures answers the requests of the test from testdata/fixtures.
+func replayFixtures(t *testing.T) {
+	saved := http.DefaultTransport
+	http.DefaultTransport = &fixtureTransport{dir: filepath.Join("testdata", "fixtures"), replay: true}
+	t.Cleanup(func() { http.DefaultTransport = saved })
+}
+
+// captureStdout returns what fn prints, without colors.
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, output, noColor := os.Stdout, color.Output, color.NoColor
+	os.Stdout, color.Output, color.NoColor = w, w, true
+	defer func() {
+		os.Stdout, color.Output, color.NoColor = stdout, output, noColor
+	}()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		done <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, []byte(got)) {
+		t.Errorf("%s differs, run go test -update to accept:\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
+
+func TestCrawlGolden(t *testing.T) {
+	replayFixtures(t)
+	for _, tt := range []struct {
+		golden  string
+		keyword string
+		page    int
+	}{
+		{"typo_page1.txt", "typo", 1},
+		{"nothing_page1.txt", "zzzzqqqq", 1},
+	} {
+		url := commitM{}.URL(tt.keyword, tt.page)
+		result, err := crawl(context.Background(), url)
+		if err != nil {
+			t.Errorf("%s: %s", tt.golden, err)
+			continue
+		}
+		checkGolden(t, tt.golden, captureStdout(t, func() {
+			showResult(result, url, tt.keyword, tt.page)
+		}))
+	}
+}
+
+func TestReplayMissing(t *testing.T) {
+	replayFixtures(t)
+	if _, err := crawl(context.Background(), "http://commit-m.invalid/commits/search?keyword=unrecorded&page=1"); err == nil {
+		t.Error("crawl of an unrecorded url succeeded")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const formatterPrefix = "plugin:"
+
+// formatters are the external formatters registered in the [formatters]
+// table of the config, name = "command and arguments".
+var formatters = map[string]string{}
+
+// runFormatter writes the commits as NDJSON, one commit per line, to the
+// stdin of the formatter and lets it write to stdout.
+func runFormatter(name string, commits []*commit) error {
+	command := strings.Fields(formatters[name])
+	if len(command) == 0 {
+		return fmt.Errorf("unknown formatter: %s (add it to [formatters] in %s)", name, configFile)
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(stdin)
+	for _, c := range commits {
+		if err := enc.Encode(c); err != nil {
+			break
+		}
+	}
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("formatter %s: %s", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+)
+
+// fortuneKeywords are searched by fortune when no keyword is given.
+var fortuneKeywords = []string{"fuck", "wtf", "oops", "typo", "sorry", "damn", "why", "hack", "magic", "finally"}
+
+var fortuneCommand = cli.Command{
+	Name:      "fortune",
+	Usage:     "print a random commit message, for MOTDs and shell greetings",
+	ArgsUsage: "[keyword]",
+	Flags: append([]cli.Flag{
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: 24 * time.Hour,
+			Usage: "reuse cached pages fetched within this duration",
+		},
+	}, figureFlags...),
+	Action: func(c *cli.Context) {
+		figure := selectedFigure(c)
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		keyword := strings.Join(c.Args(), " ")
+		if keyword == "" {
+			keyword = fortuneKeywords[rnd.Intn(len(fortuneKeywords))]
+		}
+
+		found, err := randomCommit(rnd, keyword, c.Duration("cache-ttl"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(failureExitCode(err))
+		}
+		if found == nil {
+			fmt.Println(tr("No Results Found."))
+			os.Exit(1)
+		}
+		printFortune(found, figure)
+	},
+}
+
+// printFortune prints the message followed by where it comes from, said by
+// the figure if any.
+func printFortune(c *commit, figure string) {
+	if figure != "" {
+		printFigure(figure, c)
+		return
+	}
+	fmt.Println(c.Message)
+	fmt.Fprintf(color.Output, "    -- %s %s\n", theme.repo("%s", c.Repo), c.CommitURL)
+}
+
+// randomCommit picks a random page of the results of the keyword, then a
+// random commit of the page.
+func randomCommit(rnd *rand.Rand, keyword string, ttl time.Duration) (*commit, error) {
+	result, err := cachedCrawl(keyword, 1, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if total, _ := strconv.Atoi(result.TotalPages); total > 1 {
+		if page := rnd.Intn(total) + 1; page > 1 {
+			if result, err = cachedCrawl(keyword, page, ttl); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(result.Commits) == 0 {
+		return nil, nil
+	}
+	return result.Commits[rnd.Intn(len(result.Commits))], nil
+}
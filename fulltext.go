@@ -0,0 +1,209 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/codegangsta/cli"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	indexDir       = "index.bleve"
+	indexStateFile = "index_state.json"
+)
+
+// indexedCommit is the document stored in the full-text index.
+type indexedCommit struct {
+	Repo      string `json:"repo"`
+	RepoURL   string `json:"repo_url"`
+	Sha1      string `json:"sha1"`
+	CommitURL string `json:"commit_url"`
+	Message   string `json:"message"`
+}
+
+type indexState struct {
+	IndexedUntil time.Time `json:"indexed_until"`
+}
+
+var dbQueryCommand = cli.Command{
+	Name:      "query",
+	Usage:     "full-text search stored commits with phrase, fuzzy and ranked matching",
+	ArgsUsage: "query",
+	Description: `The query uses the query string syntax: "fix typo" matches the phrase,
+   typo~1 matches words within one edit, +word requires and -word excludes a word.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "fuzzy",
+			Usage: "match every word of the query within edit distance --fuzziness",
+		},
+		cli.IntFlag{
+			Name:  "fuzziness",
+			Value: 1,
+			Usage: "edit distance for --fuzzy",
+		},
+		cli.IntFlag{
+			Name:  "limit, n",
+			Value: 50,
+			Usage: "maximum number of commits",
+		},
+	},
+	Action: func(c *cli.Context) {
+		q := strings.Join(c.Args(), " ")
+		if q == "" {
+			cli.ShowCommandHelp(c, "query")
+			os.Exit(1)
+		}
+
+		index, err := openIndex()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer index.Close()
+		if err := syncIndex(index); err != nil {
+			logger.Warn("failed to update index", "error", err)
+		}
+
+		var bq query.Query = bleve.NewQueryStringQuery(q)
+		if c.Bool("fuzzy") {
+			terms := []query.Query{}
+			for _, word := range strings.Fields(q) {
+				fq := bleve.NewFuzzyQuery(strings.ToLower(word))
+				fq.SetFuzziness(c.Int("fuzziness"))
+				terms = append(terms, fq)
+			}
+			bq = bleve.NewConjunctionQuery(terms...)
+		}
+		req := bleve.NewSearchRequestOptions(bq, c.Int("limit"), 0, false)
+		req.Fields = []string{"*"}
+		res, err := index.Search(req)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		commits := []*commit{}
+		for _, hit := range res.Hits {
+			commits = append(commits, &commit{
+				Repo:      fieldString(hit.Fields, "repo"),
+				RepoURL:   fieldString(hit.Fields, "repo_url"),
+				Sha1:      fieldString(hit.Fields, "sha1"),
+				CommitURL: fieldString(hit.Fields, "commit_url"),
+				Message:   fieldString(hit.Fields, "message"),
+			})
+		}
+		result := QueryResult{
+			Commits:     commits,
+			ResultCount: fmt.Sprintf("%d results", res.Total),
+			TotalPages:  "1",
+		}
+		showResult(result, dataPath(indexDir), q, 1)
+	},
+}
+
+var dbReindexCommand = cli.Command{
+	Name:  "reindex",
+	Usage: "rebuild the full-text index from the database",
+	Action: func(c *cli.Context) {
+		os.RemoveAll(dataPath(indexDir))
+		os.Remove(dataPath(indexStateFile))
+		index, err := openIndex()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer index.Close()
+		if err := syncIndex(index); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		count, _ := index.DocCount()
+		fmt.Printf("indexed %d commits\n", count)
+	},
+}
+
+func openIndex() (bleve.Index, error) {
+	path := dataPath(indexDir)
+	index, err := bleve.Open(path)
+	if err == bleve.ErrorIndexPathDoesNotExist {
+		return bleve.New(path, bleve.NewIndexMapping())
+	}
+	return index, err
+}
+
+// syncIndex adds the commits stored in the database since the last sync to
+// the index.
+func syncIndex(index bleve.Index) error {
+	state := &indexState{}
+	if err := loadJSON(dataPath(indexStateFile), state); err != nil {
+		return err
+	}
+
+	db, err := openDB(dbPath())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	until, err := indexCommitsSince(db, index, state.IndexedUntil)
+	if err != nil {
+		return err
+	}
+	state.IndexedUntil = until
+	return saveJSON(dataPath(indexStateFile), state)
+}
+
+func indexCommitsSince(db *sql.DB, index bleve.Index, since time.Time) (time.Time, error) {
+	rows, err := db.Query(`SELECT repo, repo_url, sha1, `+sha1Column+`, commit_url, message, MAX(fetched_at)
+		FROM commits WHERE fetched_at > ? GROUP BY repo, sha1`, since.UTC())
+	if err != nil {
+		return since, err
+	}
+	defer rows.Close()
+
+	until := since
+	batch := index.NewBatch()
+	for rows.Next() {
+		doc := &indexedCommit{}
+		var key, max string
+		if err := rows.Scan(&doc.Repo, &doc.RepoURL, &key, &doc.Sha1, &doc.CommitURL, &doc.Message, &max); err != nil {
+			return since, err
+		}
+		fetched, err := parseSQLiteTime(max)
+		if err != nil {
+			return since, err
+		}
+		if err := batch.Index(doc.Repo+"@"+key, doc); err != nil {
+			return since, err
+		}
+		if fetched.After(until) {
+			until = fetched
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return since, err
+	}
+	return until, index.Batch(batch)
+}
+
+// parseSQLiteTime parses a timestamp returned as text, as the driver does
+// for aggregates such as MAX(fetched_at).
+func parseSQLiteTime(s string) (time.Time, error) {
+	for _, layout := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse timestamp %q", s)
+}
+
+func fieldString(fields map[string]interface{}, name string) string {
+	s, _ := fields[name].(string)
+	return s
+}
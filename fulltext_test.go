@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+func TestSyncIndexFindsRecordedCommits(t *testing.T) {
+	defer func(dir string) { dataDirOverride = dir }(dataDirOverride)
+	dataDirOverride = t.TempDir()
+
+	commits := []*commit{
+		{Repo: "yuroyoro/gommit-m", Sha1: "0123456789abcdef", CommitURL: "https://github.com/yuroyoro/gommit-m/commit/0123456789abcdef", Message: "fix typo in readme"},
+	}
+	if err := recordFetched("typo", commits); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := openIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	if err := syncIndex(index); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := index.Search(bleve.NewSearchRequest(bleve.NewQueryStringQuery("readme")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "yuroyoro/gommit-m@0123456789abcdef" {
+		t.Errorf("search readme = %v, want the recorded commit", res.Hits)
+	}
+
+	state := &indexState{}
+	if err := loadJSON(dataPath(indexStateFile), state); err != nil {
+		t.Fatal(err)
+	}
+	if state.IndexedUntil.IsZero() {
+		t.Error("indexed until was not advanced")
+	}
+	if err := syncIndex(index); err != nil {
+		t.Errorf("second sync: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+func hasGh() bool {
+	_, err := exec.LookPath("gh")
+	return err == nil
+}
+
+// githubToken returns the token given by --github-token or GITHUB_TOKEN,
+// falling back to the one stored by `gh auth login`.
+func githubToken(c *cli.Context) string {
+	if token := c.String("github-token"); token != "" {
+		return token
+	}
+	if !hasGh() {
+		return ""
+	}
+	out, err := exec.Command("gh", "auth", "token").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ghBrowse opens the commit in the browser with `gh browse`.
+func ghBrowse(c *commit) error {
+	if !hasGh() {
+		return fmt.Errorf("gh is not installed")
+	}
+	repo, ok := repoPath(c)
+	if !ok {
+		return fmt.Errorf("not a github repository: %s", c.Repo)
+	}
+	_, _, sha, ok := parseCommitURL(c.CommitURL)
+	if !ok {
+		sha = c.Sha1
+	}
+	cmd := exec.Command("gh", "browse", sha, "--repo", repo)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
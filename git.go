@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+func git(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+const fileNameWeight = 3
+
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// stopWords are identifiers too common in source code to make useful
+// search keywords.
+var stopWords = map[string]bool{
+	"and": true, "break": true, "case": true, "class": true, "const": true,
+	"def": true, "default": true, "defer": true, "else": true, "end": true,
+	"err": true, "error": true, "false": true, "for": true, "from": true,
+	"func": true, "function": true, "go": true, "if": true, "import": true,
+	"int": true, "let": true, "new": true, "nil": true, "not": true,
+	"null": true, "package": true, "private": true, "public": true,
+	"return": true, "self": true, "static": true, "string": true,
+	"struct": true, "switch": true, "the": true, "this": true, "true": true,
+	"type": true, "var": true, "void": true, "with": true,
+}
+
+// stagedKeywords derives search keywords from the staged changes: words of
+// the changed file names (weighted higher) and of identifiers on added
+// lines, most frequent first.
+func stagedKeywords() ([]string, error) {
+	out, err := git("diff", "--cached", "--name-only")
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	add := func(identifier string, weight int) {
+		for _, word := range splitIdentifier(identifier) {
+			if len(word) > 2 && !stopWords[word] {
+				counts[word] += weight
+			}
+		}
+	}
+	for _, path := range strings.Fields(out) {
+		add(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), fileNameWeight)
+	}
+
+	diff, err := git("diff", "--cached", "--unified=0", "--no-color")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(diff, "\n") {
+		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		for _, identifier := range identifierPattern.FindAllString(line, -1) {
+			add(identifier, 1)
+		}
+	}
+	return rankWords(counts), nil
+}
+
+// splitIdentifier splits snake_case, kebab-case and camelCase identifiers
+// into lower case words.
+func splitIdentifier(s string) []string {
+	words := []string{}
+	current := []rune{}
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return words
+}
+
+func rankWords(counts map[string]int) []string {
+	words := []string{}
+	for word := range counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	return words
+}
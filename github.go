@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const githubAPI = "https://api.github.com"
+
+var commitURLPattern = regexp.MustCompile(`github\.com/([^/]+)/([^/]+)/commit/([0-9a-fA-F]+)`)
+
+type githubClient struct {
+	http  *http.Client
+	token string
+}
+
+func newGithubClient(token string) *githubClient {
+	timeout := 30 * time.Second
+	if http.DefaultClient.Timeout != 0 {
+		timeout = http.DefaultClient.Timeout
+	}
+	return &githubClient{
+		http:  &http.Client{Timeout: timeout},
+		token: token,
+	}
+}
+
+// githubCommit is the subset of the GitHub "get a commit" response used
+// for enrichment.
+type githubCommit struct {
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+			Date string `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Stats struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+	Files []struct {
+		Filename string `json:"filename"`
+	} `json:"files"`
+}
+
+// parseCommitURL extracts owner, repository and sha from a GitHub commit URL.
+func parseCommitURL(commitURL string) (owner, repo, sha string, ok bool) {
+	m := commitURLPattern.FindStringSubmatch(commitURL)
+	if m == nil {
+		return "", "", "", false
+	}
+	return m[1], m[2], m[3], true
+}
+
+func (g *githubClient) get(path string, v interface{}) error {
+	return g.getContext(runContext, path, v)
+}
+
+func (g *githubClient) getContext(ctx context.Context, path string, v interface{}) error {
+	if err := githubQuota.wait(); err != nil {
+		return g.rateLimitError(err)
+	}
+	req, err := http.NewRequest("GET", githubAPI+path, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("User-Agent", userAgent())
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+
+	res, err := g.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(res.Body)
+	githubQuota.update(res.Header)
+	if (res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests) && res.Header.Get("X-RateLimit-Remaining") == "0" {
+		return g.rateLimitError(githubQuota.exhaustedError())
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("github: GET %s: %s", path, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (g *githubClient) rateLimitError(err error) error {
+	if g.token == "" {
+		return fmt.Errorf("%v, set --github-token or GITHUB_TOKEN", err)
+	}
+	return err
+}
+
+func (g *githubClient) commit(c *commit) (*githubCommit, error) {
+	owner, repo, sha, ok := parseCommitURL(c.CommitURL)
+	if !ok {
+		return nil, fmt.Errorf("not a github commit url: %s", c.CommitURL)
+	}
+	key := owner + "/" + repo + "/" + sha
+	if gc, ok := cachedGithubCommit(key); ok {
+		return gc, nil
+	}
+	gc := &githubCommit{}
+	if err := g.get(fmt.Sprintf("/repos/%s/%s/commits/%s", owner, repo, sha), gc); err != nil {
+		return gc, err
+	}
+	cacheGithubCommit(key, gc)
+	return gc, nil
+}
+
+// enrich looks up each commit on GitHub and fills in the body (the part of
+// the full message after the subject line) when body is set, and the
+// fields named by enrich. Up to githubConcurrency commits are looked up at a
+// time, and lookups are cached on disk for commitsCacheTTL. When the API
+// quota runs out the lookups stop, leaving the remaining commits as they
+// are, and the error tells how many were enriched.
+func (g *githubClient) enrich(commits []*commit, body bool, enrich *enrichValue) error {
+	queue := make(chan *commit)
+	var mu sync.Mutex
+	var firstErr error
+	enriched := 0
+	var wg sync.WaitGroup
+	for i := 0; i < githubConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range queue {
+				gc, err := g.commit(c)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				enrichCommit(c, gc, body, enrich)
+				mu.Lock()
+				enriched++
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, c := range commits {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		githubQuota.warnLow()
+		if githubQuota.exhausted() {
+			mu.Lock()
+			firstErr = g.rateLimitError(githubQuota.exhaustedError())
+			mu.Unlock()
+			break
+		}
+		queue <- c
+	}
+	close(queue)
+	wg.Wait()
+	if firstErr != nil {
+		firstErr = fmt.Errorf("%v (enriched %d of %d commits)", firstErr, enriched, len(commits))
+	}
+	if err := saveCommitCache(); err != nil {
+		logger.Warn("failed to save the commit cache", "error", err)
+	}
+	return firstErr
+}
+
+func enrichCommit(c *commit, gc *githubCommit, body bool, enrich *enrichValue) {
+	if body {
+		c.Body = messageBody(gc.Commit.Message)
+	}
+	if enrich.Has("author") {
+		c.Author = gc.Commit.Author.Name
+		if gc.Author != nil {
+			c.Author = gc.Author.Login
+		}
+		c.Date = gc.Commit.Author.Date
+	}
+	if enrich.Has("stats") {
+		c.Stats = &diffStats{
+			Files:     len(gc.Files),
+			Additions: gc.Stats.Additions,
+			Deletions: gc.Stats.Deletions,
+		}
+	}
+}
+
+func firstLine(message string) string {
+	return strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+}
+
+func messageBody(message string) string {
+	parts := strings.SplitN(message, "\n", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// githubSearchPerPage is the page size of GitHub commit search. The API
+// returns at most 1000 results for a query.
+const (
+	githubSearchPerPage    = 30
+	githubSearchMaxResults = 1000
+)
+
+// githubSearch searches commit messages with the GitHub commit search API.
+type githubSearch struct {
+	client *githubClient
+}
+
+type githubSearchResult struct {
+	TotalCount int `json:"total_count"`
+	Items      []struct {
+		Sha     string `json:"sha"`
+		HTMLURL string `json:"html_url"`
+		Commit  struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name string `json:"name"`
+				Date string `json:"date"`
+			} `json:"author"`
+		} `json:"commit"`
+		Author *struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		Repository struct {
+			FullName string `json:"full_name"`
+			HTMLURL  string `json:"html_url"`
+		} `json:"repository"`
+	} `json:"items"`
+}
+
+func (githubSearch) Name() string {
+	return "github"
+}
+
+func (githubSearch) URL(query string, page int) string {
+	return "https://github.com/search?type=commits&q=" + url.QueryEscape(query) + "&p=" + strconv.Itoa(page)
+}
+
+func (g githubSearch) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	path := fmt.Sprintf("/search/commits?q=%s&page=%d&per_page=%d", url.QueryEscape(query), page, githubSearchPerPage)
+	res := &githubSearchResult{}
+	if err := g.client.getContext(ctx, path, res); err != nil {
+		return QueryResult{Commits: []*commit{}}, err
+	}
+
+	commits := []*commit{}
+	for _, item := range res.Items {
+		sha := item.Sha
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		c := &commit{
+			Repo:      item.Repository.FullName,
+			RepoURL:   item.Repository.HTMLURL,
+			Sha1:      sha,
+			CommitURL: item.HTMLURL,
+			Message:   firstLine(item.Commit.Message),
+			Body:      messageBody(item.Commit.Message),
+			Author:    item.Commit.Author.Name,
+			Date:      item.Commit.Author.Date,
+			Source:    "github",
+		}
+		if item.Author != nil {
+			c.Author = item.Author.Login
+		}
+		commits = append(commits, c)
+	}
+
+	total := res.TotalCount
+	if total > githubSearchMaxResults {
+		total = githubSearchMaxResults
+	}
+	pages := (total + githubSearchPerPage - 1) / githubSearchPerPage
+	if pages == 0 {
+		pages = 1
+	}
+	return QueryResult{
+		Commits:     commits,
+		ResultCount: fmt.Sprintf("%d results", res.TotalCount),
+		TotalPages:  strconv.Itoa(pages),
+	}, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// lowGithubQuota is the number of requests left at which lookups are
+	// spaced out, and enrichment warns that the quota is nearly used up.
+	lowGithubQuota = 50
+	// maxThrottleDelay is the longest delay between lookups when the quota
+	// is low.
+	maxThrottleDelay = 2 * time.Second
+	// maxQuotaWait is how long a lookup waits for an exhausted quota to
+	// reset, failing when the reset is further away.
+	maxQuotaWait = time.Minute
+)
+
+// githubConcurrency is the number of GitHub lookups of --enrich requested
+// at a time, set by --github-concurrency.
+var githubConcurrency = 4
+
+// rateLimit is the GitHub API quota reported by the last response, shared
+// by the clients of the process.
+type rateLimit struct {
+	sync.Mutex
+	known     bool
+	remaining int
+	limit     int
+	reset     time.Time
+	warned    bool
+}
+
+var githubQuota rateLimit
+
+// update records the X-RateLimit headers of the response.
+func (q *rateLimit) update(h http.Header) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	q.Lock()
+	defer q.Unlock()
+	q.known = true
+	q.remaining = remaining
+	q.limit = limit
+	q.reset = time.Unix(reset, 0)
+}
+
+// wait spaces lookups out over the time left until the reset when the
+// quota is low. When it is exhausted it blocks until the reset, if that is
+// within maxQuotaWait, and fails otherwise.
+func (q *rateLimit) wait() error {
+	q.Lock()
+	known, remaining, reset := q.known, q.remaining, q.reset
+	q.Unlock()
+	if !known || remaining >= lowGithubQuota {
+		return nil
+	}
+	delay := time.Until(reset)
+	if remaining > 0 {
+		delay /= time.Duration(remaining)
+		if delay > maxThrottleDelay {
+			delay = maxThrottleDelay
+		}
+	} else if delay > maxQuotaWait {
+		return q.exhaustedError()
+	} else {
+		logger.Info("waiting for the GitHub API quota to reset", "delay", delay.Round(time.Second))
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-runContext.Done():
+			return runContext.Err()
+		}
+	}
+	if remaining == 0 {
+		q.Lock()
+		q.known = false
+		q.Unlock()
+	}
+	return nil
+}
+
+// warnLow warns once when fewer than lowGithubQuota requests are left.
+func (q *rateLimit) warnLow() {
+	q.Lock()
+	defer q.Unlock()
+	if q.known && !q.warned && q.remaining < lowGithubQuota {
+		q.warned = true
+		logger.Warn("GitHub API quota nearly exhausted", "remaining", q.remaining, "limit", q.limit, "reset", q.reset.Format("15:04"))
+	}
+}
+
+func (q *rateLimit) exhausted() bool {
+	q.Lock()
+	defer q.Unlock()
+	return q.known && q.remaining == 0 && time.Until(q.reset) > maxQuotaWait
+}
+
+func (q *rateLimit) exhaustedError() error {
+	q.Lock()
+	defer q.Unlock()
+	return fmt.Errorf("github: API rate limit of %d requests exhausted until %s", q.limit, q.reset.Format("15:04"))
+}
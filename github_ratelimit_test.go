@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	var q rateLimit
+	if err := q.wait(); err != nil {
+		t.Fatalf("wait with an unknown quota: %v", err)
+	}
+
+	h := http.Header{}
+	h.Set("X-RateLimit-Limit", "60")
+	h.Set("X-RateLimit-Remaining", "0")
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	q.update(h)
+	if !q.exhausted() {
+		t.Error("quota with no requests left until an hour later is not exhausted")
+	}
+	if err := q.wait(); err == nil {
+		t.Error("wait for a reset an hour later succeeded")
+	}
+
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+	q.update(h)
+	if q.exhausted() {
+		t.Error("quota resetting now is exhausted")
+	}
+	if err := q.wait(); err != nil {
+		t.Errorf("wait for a reset now: %v", err)
+	}
+
+	h.Set("X-RateLimit-Remaining", "4999")
+	q.update(h)
+	start := time.Now()
+	if err := q.wait(); err != nil || time.Since(start) > time.Second {
+		t.Errorf("wait with plenty of quota took %v: %v", time.Since(start), err)
+	}
+}
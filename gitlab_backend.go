@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	gitlabDefaultEndpoint = "https://gitlab.com"
+	gitlabSearchPerPage   = 20
+)
+
+// gitlabSearch searches commits with the GitLab search API. Searching a
+// whole instance needs advanced search to be enabled there, so a project
+// can be given to search only its commits.
+type gitlabSearch struct {
+	endpoint string
+	token    string
+	project  string
+
+	mu       sync.Mutex
+	projects map[int]*gitlabProject
+}
+
+type gitlabCommit struct {
+	ShortID       string `json:"short_id"`
+	Title         string `json:"title"`
+	Message       string `json:"message"`
+	AuthorName    string `json:"author_name"`
+	CommittedDate string `json:"committed_date"`
+	ProjectID     int    `json:"project_id"`
+	WebURL        string `json:"web_url"`
+}
+
+type gitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+func newGitlabSearch(endpoint, token, project string) *gitlabSearch {
+	return &gitlabSearch{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    token,
+		project:  project,
+		projects: map[int]*gitlabProject{},
+	}
+}
+
+func (g *gitlabSearch) Name() string {
+	return "gitlab"
+}
+
+func (g *gitlabSearch) URL(query string, page int) string {
+	return fmt.Sprintf("%s/search?scope=commits&search=%s&page=%d", g.endpoint, url.QueryEscape(query), page)
+}
+
+func (g *gitlabSearch) header() http.Header {
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("PRIVATE-TOKEN", g.token)
+	}
+	return header
+}
+
+func (g *gitlabSearch) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	api := g.endpoint + "/api/v4"
+	if g.project != "" {
+		api += "/projects/" + url.PathEscape(g.project)
+	}
+	path := fmt.Sprintf("%s/search?scope=commits&search=%s&page=%d&per_page=%d",
+		api, url.QueryEscape(query), page, gitlabSearchPerPage)
+	found := []gitlabCommit{}
+	header, err := getJSON(ctx, path, g.header(), &found)
+	if err != nil {
+		return QueryResult{Commits: []*commit{}}, fmt.Errorf("gitlab: %s", err)
+	}
+
+	commits := []*commit{}
+	for _, gc := range found {
+		c := &commit{
+			Sha1:      gc.ShortID,
+			CommitURL: gc.WebURL,
+			Message:   firstNonEmpty(gc.Title, firstLine(gc.Message)),
+			Body:      messageBody(gc.Message),
+			Author:    gc.AuthorName,
+			Date:      gc.CommittedDate,
+			Source:    "gitlab",
+		}
+		if p, err := g.lookupProject(ctx, gc.ProjectID); err == nil {
+			c.Repo = p.PathWithNamespace
+			c.RepoURL = p.WebURL
+			if c.CommitURL == "" {
+				c.CommitURL = p.WebURL + "/-/commit/" + gc.ShortID
+			}
+		} else {
+			debugf("gitlab: project %d: %s", gc.ProjectID, err)
+			c.Repo = strconv.Itoa(gc.ProjectID)
+		}
+		commits = append(commits, c)
+	}
+
+	// search results do not always carry X-Total; guess one more page
+	// while pages are full.
+	total, _ := strconv.Atoi(header.Get("X-Total"))
+	pages, _ := strconv.Atoi(header.Get("X-Total-Pages"))
+	if pages == 0 {
+		pages = page
+		if len(found) == gitlabSearchPerPage {
+			pages++
+		}
+	}
+	if total == 0 {
+		total = (page-1)*gitlabSearchPerPage + len(found)
+	}
+	return QueryResult{
+		Commits:     commits,
+		ResultCount: fmt.Sprintf("%d results", total),
+		TotalPages:  strconv.Itoa(pages),
+	}, nil
+}
+
+func (g *gitlabSearch) lookupProject(ctx context.Context, id int) (*gitlabProject, error) {
+	g.mu.Lock()
+	p, ok := g.projects[id]
+	g.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+	p = &gitlabProject{}
+	if _, err := getJSON(ctx, fmt.Sprintf("%s/api/v4/projects/%d", g.endpoint, id), g.header(), p); err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	g.projects[id] = p
+	g.mu.Unlock()
+	return p, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// gqlCommit is a commit in a GraphQL response. The enrichment fields are
+// looked up on GitHub only when a query asks for them, once per commit.
+type gqlCommit struct {
+	*commit
+	github *githubClient
+
+	once sync.Once
+	gc   *githubCommit
+	err  error
+
+	starsOnce sync.Once
+	stars     int
+	starsErr  error
+}
+
+func (c *gqlCommit) githubCommit() (*githubCommit, error) {
+	c.once.Do(func() {
+		c.gc, c.err = c.github.commit(c.commit)
+		if err := saveCommitCache(); err != nil {
+			logger.Warn("failed to save the commit cache", "error", err)
+		}
+	})
+	return c.gc, c.err
+}
+
+func (c *gqlCommit) repoStars() (int, error) {
+	c.starsOnce.Do(func() {
+		// the commit may be shared with the cache, so it is not modified
+		clone := *c.commit
+		c.starsErr = c.github.fetchStars([]*commit{&clone})
+		c.stars = clone.Stars
+	})
+	return c.stars, c.starsErr
+}
+
+func commitField(typ graphql.Output, description string, fn func(c *gqlCommit) (interface{}, error)) *graphql.Field {
+	return &graphql.Field{
+		Type:        typ,
+		Description: description,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fn(p.Source.(*gqlCommit))
+		},
+	}
+}
+
+// enrichedField resolves a field from the GitHub commit.
+func enrichedField(typ graphql.Output, description string, fn func(gc *githubCommit) interface{}) *graphql.Field {
+	return commitField(typ, description, func(c *gqlCommit) (interface{}, error) {
+		gc, err := c.githubCommit()
+		if err != nil {
+			return nil, err
+		}
+		return fn(gc), nil
+	})
+}
+
+var gqlStatsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "DiffStats",
+	Fields: graphql.Fields{
+		"files":     &graphql.Field{Type: graphql.Int},
+		"additions": &graphql.Field{Type: graphql.Int},
+		"deletions": &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var gqlCommitType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Commit",
+	Fields: graphql.Fields{
+		"repo":       commitField(graphql.String, "owner/name of the repository", func(c *gqlCommit) (interface{}, error) { return c.Repo, nil }),
+		"repoUrl":    commitField(graphql.String, "", func(c *gqlCommit) (interface{}, error) { return c.RepoURL, nil }),
+		"sha1":       commitField(graphql.String, "", func(c *gqlCommit) (interface{}, error) { return c.Sha1, nil }),
+		"commitUrl":  commitField(graphql.String, "", func(c *gqlCommit) (interface{}, error) { return c.CommitURL, nil }),
+		"message":    commitField(graphql.String, "subject line of the message", func(c *gqlCommit) (interface{}, error) { return c.Message, nil }),
+		"source":     commitField(graphql.String, "backend the commit was found on", func(c *gqlCommit) (interface{}, error) { return c.Source, nil }),
+		"dead":       commitField(graphql.Boolean, "the commit url no longer exists", func(c *gqlCommit) (interface{}, error) { return c.Dead, nil }),
+		"archiveUrl": commitField(graphql.String, "", func(c *gqlCommit) (interface{}, error) { return c.ArchiveURL, nil }),
+		"body": enrichedField(graphql.String, "message after the subject line, from GitHub", func(gc *githubCommit) interface{} {
+			return messageBody(gc.Commit.Message)
+		}),
+		"fullMessage": enrichedField(graphql.String, "whole message, from GitHub", func(gc *githubCommit) interface{} {
+			return gc.Commit.Message
+		}),
+		"author": enrichedField(graphql.String, "GitHub login, or name, of the author", func(gc *githubCommit) interface{} {
+			if gc.Author != nil {
+				return gc.Author.Login
+			}
+			return gc.Commit.Author.Name
+		}),
+		"date": enrichedField(graphql.String, "author date, from GitHub", func(gc *githubCommit) interface{} {
+			return gc.Commit.Author.Date
+		}),
+		"stats": enrichedField(gqlStatsType, "size of the change, from GitHub", func(gc *githubCommit) interface{} {
+			return map[string]interface{}{"files": len(gc.Files), "additions": gc.Stats.Additions, "deletions": gc.Stats.Deletions}
+		}),
+		"stars": commitField(graphql.Int, "stars of the repository, from GitHub", func(c *gqlCommit) (interface{}, error) {
+			return c.repoStars()
+		}),
+	},
+})
+
+var gqlSearchResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchResult",
+	Fields: graphql.Fields{
+		"commits":     &graphql.Field{Type: graphql.NewList(gqlCommitType)},
+		"resultCount": &graphql.Field{Type: graphql.Int},
+		"page":        &graphql.Field{Type: graphql.Int},
+		"totalPages":  &graphql.Field{Type: graphql.Int},
+		"hasNextPage": &graphql.Field{Type: graphql.Boolean},
+		"fallback":    &graphql.Field{Type: graphql.String, Description: "backend searched because the primary one failed"},
+	},
+})
+
+func newGraphQLSchema(s *server) (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"search": &graphql.Field{
+					Type:        gqlSearchResultType,
+					Description: "one page of commits whose message contains the keyword",
+					Args: graphql.FieldConfigArgument{
+						"keyword": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+						"page":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						keyword, _ := p.Args["keyword"].(string)
+						page, _ := p.Args["page"].(int)
+						if page < 1 {
+							page = 1
+						}
+						result, err := s.cache.crawl(keyword, page)
+						if err != nil {
+							return nil, err
+						}
+						commits := make([]*gqlCommit, len(result.Commits))
+						for i, c := range result.Commits {
+							commits[i] = &gqlCommit{commit: c, github: s.github}
+						}
+						total, _ := strconv.Atoi(result.TotalPages)
+						return map[string]interface{}{
+							"commits":     commits,
+							"resultCount": parseResultCount(result.ResultCount),
+							"page":        page,
+							"totalPages":  total,
+							"hasNextPage": page < total,
+							"fallback":    result.Fallback,
+						}, nil
+					},
+				},
+			},
+		}),
+	})
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphql serves /graphql: a POST of {"query", "variables",
+// "operationName"} or a GET with the query in the query parameter.
+func (s *server) graphql(w http.ResponseWriter, r *http.Request) {
+	req := graphqlRequest{}
+	switch r.Method {
+	case "GET":
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid variables: %s", err))
+				return
+			}
+		}
+	case "POST":
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %s", err))
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+		return
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("query is required"))
+		return
+	}
+	if keyword, ok := req.Variables["keyword"].(string); ok {
+		noteRequest(r, keyword, "")
+	}
+	result := graphql.Do(graphql.Params{
+		Schema:         s.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+	writeJSON(w, http.StatusOK, result)
+}
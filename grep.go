@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+var grepCommand = cli.Command{
+	Name:      "grep",
+	Usage:     "search the messages of the local database and the last search with a regular expression, without the network",
+	ArgsUsage: "pattern",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "ignore-case, i",
+			Usage: "match case insensitively",
+		},
+		cli.StringFlag{
+			Name:  "repo",
+			Usage: "only commits of repositories matching the pattern (* is a wildcard)",
+		},
+		cli.BoolFlag{
+			Name:  "session",
+			Usage: "only search the results of the last search",
+		},
+		cli.IntFlag{
+			Name:  "limit, n",
+			Value: 100,
+			Usage: "maximum number of commits",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the commits as json",
+		},
+	},
+	Action: func(c *cli.Context) {
+		pattern := c.Args().First()
+		if pattern == "" {
+			cli.ShowCommandHelp(c, "grep")
+			os.Exit(exitUsage)
+		}
+		if c.Bool("ignore-case") {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+
+		commits, err := grepStored(re, c.String("repo"), c.Bool("session"), c.Int("limit"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if c.Bool("json") {
+			printJSON(commits)
+			return
+		}
+		result := QueryResult{
+			Commits:     commits,
+			ResultCount: fmt.Sprintf("%d results", len(commits)),
+			TotalPages:  "1",
+		}
+		showResult(result, dbPath(), c.Args().First(), 1)
+	},
+}
+
+// grepStored returns the commits of the last search, then those of the
+// local database, whose message matches re.
+func grepStored(re *regexp.Regexp, repo string, sessionOnly bool, limit int) ([]*commit, error) {
+	candidates := []*commit{}
+	if s, err := loadSession(); err == nil {
+		candidates = append(candidates, s.Commits...)
+	} else if sessionOnly {
+		return nil, err
+	}
+	if !sessionOnly {
+		db, err := openDB(dbPath())
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		stored, err := searchDB(db, &dbQuery{Repo: repo})
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, stored...)
+	}
+
+	repoPattern := globPattern(repo)
+	seen := map[string]bool{}
+	matched := []*commit{}
+	for _, c := range candidates {
+		if seen[commitKey(c)] || !re.MatchString(c.Message) || repoPattern != nil && !repoPattern.MatchString(c.Repo) {
+			continue
+		}
+		seen[commitKey(c)] = true
+		matched = append(matched, c)
+		if limit > 0 && len(matched) >= limit {
+			break
+		}
+	}
+	return matched, nil
+}
+
+// globPattern compiles the * wildcards of --repo, or returns nil for an
+// empty pattern.
+func globPattern(glob string) *regexp.Regexp {
+	if glob == "" {
+		return nil
+	}
+	return regexp.MustCompile("(?i)^" + strings.Replace(regexp.QuoteMeta(glob), `\*`, ".*", -1) + "$")
+}
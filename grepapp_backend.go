@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	grepAppAPI           = "https://grep.app/api/search"
+	grepAppSearchPerPage = 10
+	grepAppMaxPages      = 100
+)
+
+var (
+	markedLinePattern = regexp.MustCompile(`(?s)<tr[^>]*>.*?</tr>`)
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+)
+
+// grepApp searches the code indexed by grep.app. Hits are lines of files
+// rather than commits, so each row links to the file and the matched line
+// takes the place of the message.
+type grepApp struct{}
+
+type grepAppResponse struct {
+	Hits struct {
+		Total int `json:"total"`
+		Hits  []struct {
+			Repo struct {
+				Raw string `json:"raw"`
+			} `json:"repo"`
+			Branch struct {
+				Raw string `json:"raw"`
+			} `json:"branch"`
+			Path struct {
+				Raw string `json:"raw"`
+			} `json:"path"`
+			Content struct {
+				Snippet string `json:"snippet"`
+			} `json:"content"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+func (grepApp) Name() string {
+	return "grep.app"
+}
+
+func (grepApp) URL(query string, page int) string {
+	return fmt.Sprintf("https://grep.app/search?q=%s&page=%d", url.QueryEscape(query), page)
+}
+
+func (g grepApp) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	res := &grepAppResponse{}
+	path := fmt.Sprintf("%s?q=%s&page=%d", grepAppAPI, url.QueryEscape(query), page)
+	if _, err := getJSON(ctx, path, nil, res); err != nil {
+		return QueryResult{Commits: []*commit{}}, fmt.Errorf("grep.app: %s", err)
+	}
+
+	commits := []*commit{}
+	for _, hit := range res.Hits.Hits {
+		repoURL := "https://github.com/" + hit.Repo.Raw
+		commits = append(commits, &commit{
+			Repo:      hit.Repo.Raw,
+			RepoURL:   repoURL,
+			CommitURL: repoURL + "/blob/" + hit.Branch.Raw + "/" + hit.Path.Raw,
+			Message:   markedLine(hit.Content.Snippet),
+			Source:    "grep.app",
+		})
+	}
+
+	pages := (res.Hits.Total + grepAppSearchPerPage - 1) / grepAppSearchPerPage
+	if pages > grepAppMaxPages {
+		pages = grepAppMaxPages
+	}
+	if pages == 0 {
+		pages = 1
+	}
+	return QueryResult{
+		Commits:     commits,
+		ResultCount: fmt.Sprintf("%d results", res.Hits.Total),
+		TotalPages:  strconv.Itoa(pages),
+	}, nil
+}
+
+// markedLine returns the text of the first line of a snippet with a match
+// marked in it, or of the first line.
+func markedLine(snippet string) string {
+	lines := markedLinePattern.FindAllString(snippet, -1)
+	if len(lines) == 0 {
+		lines = []string{snippet}
+	}
+	line := lines[0]
+	for _, l := range lines {
+		if strings.Contains(l, "<mark") {
+			line = l
+			break
+		}
+	}
+	// the line number is in a cell of its own
+	text := html.UnescapeString(tagPattern.ReplaceAllString(strings.Replace(line, "</td>", "\t", -1), ""))
+	fields := strings.SplitN(strings.TrimSpace(text), "\t", 2)
+	return strings.TrimSpace(fields[len(fields)-1])
+}
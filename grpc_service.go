@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// commitSearchService is the HandlerType of the CommitSearch service.
+type commitSearchService interface {
+	search(ctx context.Context, req *pbSearchRequest) (*pbSearchResponse, error)
+	searchAll(req *pbSearchRequest, stream grpc.ServerStream) error
+}
+
+type commitSearchServer struct {
+	cache *resultCache
+}
+
+var commitSearchServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gommitm.v1.CommitSearch",
+	HandlerType: (*commitSearchService)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Search",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := &pbSearchRequest{}
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			s := srv.(commitSearchService)
+			if interceptor == nil {
+				return s.search(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/gommitm.v1.CommitSearch/Search"}
+			return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.search(ctx, req.(*pbSearchRequest))
+			})
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "SearchAll",
+		ServerStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			req := &pbSearchRequest{}
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			return srv.(commitSearchService).searchAll(req, stream)
+		},
+	}},
+	Metadata: "proto/commit_search.proto",
+}
+
+func newGRPCServer(cache *resultCache, limiter *clientLimiter, keys *apiKeys) *grpc.Server {
+	opts := append([]grpc.ServerOption{grpc.ForceServerCodec(wireCodec{})}, grpcGuard(keys.grpcAllow, limiter.grpcAllow)...)
+	s := grpc.NewServer(opts...)
+	s.RegisterService(&commitSearchServiceDesc, &commitSearchServer{cache: cache})
+	return s
+}
+
+// grpcGuard runs the checks before every call, failing the call with the
+// first error.
+func grpcGuard(checks ...func(ctx context.Context) error) []grpc.ServerOption {
+	allow := func(ctx context.Context) error {
+		for _, check := range checks {
+			if err := check(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := allow(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := allow(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+func searchStatus(err error) error {
+	if failureExitCode(err) == exitParse {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+func (s *commitSearchServer) search(ctx context.Context, req *pbSearchRequest) (*pbSearchResponse, error) {
+	if req.keyword == "" {
+		return nil, status.Error(codes.InvalidArgument, "keyword is required")
+	}
+	page := int(req.page)
+	if page < 1 {
+		page = 1
+	}
+	result, err := s.cache.crawl(req.keyword, page)
+	if err != nil {
+		return nil, searchStatus(err)
+	}
+	pages, _ := strconv.Atoi(result.TotalPages)
+	return &pbSearchResponse{
+		commits:     result.Commits,
+		resultCount: int32(parseResultCount(result.ResultCount)),
+		totalPages:  int32(pages),
+		page:        int32(page),
+		url:         buildUrl(req.keyword, page),
+	}, nil
+}
+
+func (s *commitSearchServer) searchAll(req *pbSearchRequest, stream grpc.ServerStream) error {
+	if req.keyword == "" {
+		return status.Error(codes.InvalidArgument, "keyword is required")
+	}
+	first := int(req.page)
+	if first < 1 {
+		first = 1
+	}
+	var sendErr error
+	err := crawlPages(req.keyword, pageRange{first: first}, allPagesDelay, func(page int, result QueryResult) bool {
+		for _, c := range result.Commits {
+			if sendErr = stream.SendMsg(&pbCommit{c}); sendErr != nil {
+				return false
+			}
+		}
+		return stream.Context().Err() == nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return searchStatus(err)
+	}
+	return stream.Context().Err()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of proto/commit_search.proto, encoded by hand with
+// protowire so no generated code is needed.
+
+type pbMessage interface {
+	marshal() []byte
+	unmarshal(b []byte) error
+}
+
+// wireCodec is the gRPC codec for pbMessage. It is named "proto" so it
+// speaks with clients generated from the .proto.
+type wireCodec struct{}
+
+func (wireCodec) Name() string {
+	return "proto"
+}
+
+func (wireCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(pbMessage)
+	if !ok {
+		return nil, fmt.Errorf("grpc: cannot marshal %T", v)
+	}
+	return m.marshal(), nil
+}
+
+func (wireCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(pbMessage)
+	if !ok {
+		return fmt.Errorf("grpc: cannot unmarshal into %T", v)
+	}
+	return m.unmarshal(data)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendInt32(b []byte, num protowire.Number, v int32) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(v)))
+}
+
+// decodeFields calls field for each field in b. field returns the length
+// of the value it consumed, or 0 to skip an unknown field.
+func decodeFields(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) int) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if n = field(num, typ, b); n == 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeString(typ protowire.Type, b []byte, s *string) int {
+	if typ != protowire.BytesType {
+		return 0
+	}
+	v, n := protowire.ConsumeString(b)
+	if n > 0 {
+		*s = v
+	}
+	return n
+}
+
+func consumeInt32(typ protowire.Type, b []byte, v *int32) int {
+	if typ != protowire.VarintType {
+		return 0
+	}
+	x, n := protowire.ConsumeVarint(b)
+	if n > 0 {
+		*v = int32(x)
+	}
+	return n
+}
+
+type pbSearchRequest struct {
+	keyword string
+	page    int32
+}
+
+func (m *pbSearchRequest) marshal() []byte {
+	b := appendString(nil, 1, m.keyword)
+	return appendInt32(b, 2, m.page)
+}
+
+func (m *pbSearchRequest) unmarshal(b []byte) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &m.keyword)
+		case 2:
+			return consumeInt32(typ, b, &m.page)
+		}
+		return 0
+	})
+}
+
+type pbCommit struct {
+	*commit
+}
+
+func (m *pbCommit) marshal() []byte {
+	b := appendString(nil, 1, m.Repo)
+	b = appendString(b, 2, m.RepoURL)
+	b = appendString(b, 3, m.Sha1)
+	b = appendString(b, 4, m.CommitURL)
+	b = appendString(b, 5, m.Message)
+	b = appendString(b, 6, m.Author)
+	b = appendString(b, 7, m.Date)
+	return appendString(b, 8, m.Source)
+}
+
+func (m *pbCommit) unmarshal(b []byte) error {
+	if m.commit == nil {
+		m.commit = &commit{}
+	}
+	fields := map[protowire.Number]*string{
+		1: &m.Repo, 2: &m.RepoURL, 3: &m.Sha1, 4: &m.CommitURL,
+		5: &m.Message, 6: &m.Author, 7: &m.Date, 8: &m.Source,
+	}
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if s, ok := fields[num]; ok {
+			return consumeString(typ, b, s)
+		}
+		return 0
+	})
+}
+
+type pbSearchResponse struct {
+	commits     []*commit
+	resultCount int32
+	totalPages  int32
+	page        int32
+	url         string
+}
+
+func (m *pbSearchResponse) marshal() []byte {
+	var b []byte
+	for _, c := range m.commits {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, (&pbCommit{c}).marshal())
+	}
+	b = appendInt32(b, 2, m.resultCount)
+	b = appendInt32(b, 3, m.totalPages)
+	b = appendInt32(b, 4, m.page)
+	return appendString(b, 5, m.url)
+}
+
+func (m *pbSearchResponse) unmarshal(b []byte) error {
+	var err error
+	derr := decodeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			if typ != protowire.BytesType {
+				return 0
+			}
+			v, n := protowire.ConsumeBytes(b)
+			if n > 0 {
+				c := &pbCommit{}
+				if cerr := c.unmarshal(v); cerr != nil {
+					err = cerr
+				}
+				m.commits = append(m.commits, c.commit)
+			}
+			return n
+		case 2:
+			return consumeInt32(typ, b, &m.resultCount)
+		case 3:
+			return consumeInt32(typ, b, &m.totalPages)
+		case 4:
+			return consumeInt32(typ, b, &m.page)
+		case 5:
+			return consumeString(typ, b, &m.url)
+		}
+		return 0
+	})
+	if derr != nil {
+		return derr
+	}
+	return err
+}
+
+// pbExportRecord is only written, by export --format proto.
+type pbExportRecord struct {
+	*exportRecord
+}
+
+func (m *pbExportRecord) marshal() []byte {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, (&pbCommit{&m.commit}).marshal())
+	b = appendString(b, 2, m.Keyword)
+	b = appendInt32(b, 3, int32(m.Page))
+	b = appendInt32(b, 4, int32(m.Position))
+	return appendString(b, 5, m.FetchedAt.Format(time.RFC3339Nano))
+}
+
+// appendDelimited appends the message preceded by its size, for streams
+// of messages.
+func appendDelimited(b []byte, m interface{ marshal() []byte }) []byte {
+	return protowire.AppendBytes(b, m.marshal())
+}
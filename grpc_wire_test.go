@@ -0,0 +1,45 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestSearchResponseDelimited(t *testing.T) {
+	want := &pbSearchResponse{
+		commits: []*commit{
+			{Repo: "yuroyoro/gommit-m", RepoURL: "https://github.com/yuroyoro/gommit-m", Sha1: "0123abc", CommitURL: "https://github.com/yuroyoro/gommit-m/commit/0123abc", Message: "fix typo"},
+		},
+		resultCount: 120,
+		totalPages:  12,
+		page:        2,
+		url:         "http://commit-m.minamijoyo.com/commits/search?keyword=typo&page=2",
+	}
+	b := appendDelimited(nil, want)
+	b = appendDelimited(b, &pbSearchResponse{})
+
+	var got []*pbSearchResponse
+	for len(b) > 0 {
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			t.Fatalf("invalid length prefix: %v", protowire.ParseError(n))
+		}
+		m := &pbSearchResponse{}
+		if err := m.unmarshal(v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, m)
+		b = b[n:]
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d messages, want 2", len(got))
+	}
+	if !reflect.DeepEqual(got[0], want) {
+		t.Errorf("got %+v, want %+v", got[0], want)
+	}
+	if !reflect.DeepEqual(got[1], &pbSearchResponse{}) {
+		t.Errorf("got %+v, want an empty response", got[1])
+	}
+}
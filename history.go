@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+const (
+	historyFile    = "history.json"
+	maxHistorySize = 1000
+)
+
+type historyEntry struct {
+	Keyword     string    `json:"keyword"`
+	Page        int       `json:"page"`
+	Args        []string  `json:"args"`
+	ResultCount int       `json:"result_count"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+var historyCommand = cli.Command{
+	Name:      "history",
+	Usage:     "list past searches, or re-run the numbered one",
+	ArgsUsage: "[number]",
+	Action: func(c *cli.Context) {
+		history, err := loadHistory()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		given := c.Args().First()
+		if given == "" {
+			for i, h := range history {
+				fmt.Printf("%4d  %s  %-30s page %d (%d results)\n",
+					i+1,
+					h.Timestamp.Local().Format("2006-01-02 15:04"),
+					h.Keyword,
+					h.Page,
+					h.ResultCount,
+				)
+			}
+			return
+		}
+
+		n, err := strconv.Atoi(given)
+		if err != nil || n < 1 || n > len(history) {
+			fmt.Fprintf(os.Stderr, "no such history entry: %s\n", given)
+			os.Exit(1)
+		}
+		h := history[n-1]
+		fmt.Printf("re-running: %s\n\n", strings.Join(h.Args, " "))
+		c.App.Run(append([]string{os.Args[0]}, h.Args...))
+	},
+}
+
+// historyKeywords returns the distinct keywords searched so far, most
+// recent first.
+func historyKeywords() []string {
+	history, err := loadHistory()
+	if err != nil {
+		return nil
+	}
+	keywords := []string{}
+	seen := map[string]bool{}
+	for i := len(history) - 1; i >= 0; i-- {
+		if k := history[i].Keyword; !seen[k] {
+			seen[k] = true
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
+}
+
+func loadHistory() ([]*historyEntry, error) {
+	history := []*historyEntry{}
+	err := loadJSON(dataPath(historyFile), &history)
+	return history, err
+}
+
+func recordHistory(c *cli.Context, keyword string, page int, resultCount int) error {
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	args := append(flagArgs(c, searchFlags), keyword, strconv.Itoa(page))
+	history = append(history, &historyEntry{
+		Keyword:     keyword,
+		Page:        page,
+		Args:        args,
+		ResultCount: resultCount,
+		Timestamp:   time.Now(),
+	})
+	if len(history) > maxHistorySize {
+		history = history[len(history)-maxHistorySize:]
+	}
+	return saveJSON(dataPath(historyFile), history)
+}
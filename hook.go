@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+const (
+	hookMarker      = "# installed by gommit-m"
+	hookKeywords    = 3
+	hookSuggestions = 5
+)
+
+var hookScript = `#!/bin/sh
+` + hookMarker + `
+exec gommit-m hook prepare-commit-msg "$@"
+`
+
+var hookCommand = cli.Command{
+	Name:  "hook",
+	Usage: "manage the prepare-commit-msg hook that suggests messages",
+	Subcommands: []cli.Command{
+		{
+			Name:  "install",
+			Usage: "install the hook into the current repository",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "force, f",
+					Usage: "overwrite an existing prepare-commit-msg hook",
+				},
+			},
+			Action: func(c *cli.Context) {
+				path, err := hookPath()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if data, err := ioutil.ReadFile(path); err == nil && !strings.Contains(string(data), hookMarker) && !c.Bool("force") {
+					fmt.Fprintf(os.Stderr, "%s already exists, use --force to overwrite\n", path)
+					os.Exit(1)
+				}
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if err := ioutil.WriteFile(path, []byte(hookScript), 0755); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				fmt.Println("installed", path)
+			},
+		},
+		{
+			Name:  "uninstall",
+			Usage: "remove the hook from the current repository",
+			Action: func(c *cli.Context) {
+				path, err := hookPath()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				data, err := ioutil.ReadFile(path)
+				if err != nil || !strings.Contains(string(data), hookMarker) {
+					fmt.Fprintf(os.Stderr, "%s was not installed by gommit-m\n", path)
+					os.Exit(1)
+				}
+				if err := os.Remove(path); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				fmt.Println("removed", path)
+			},
+		},
+		{
+			Name:      "prepare-commit-msg",
+			Usage:     "run as the prepare-commit-msg hook (called by git)",
+			ArgsUsage: "file [source [sha1]]",
+			Action: func(c *cli.Context) {
+				// never get in the way of committing: failures are ignored
+				file := c.Args().First()
+				switch c.Args().Get(1) {
+				case "message", "merge", "squash", "commit":
+					return
+				}
+				if file == "" {
+					return
+				}
+				suggestions := suggestFromStaged(hookKeywords, hookSuggestions)
+				if len(suggestions) == 0 {
+					return
+				}
+				f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+				if err != nil {
+					return
+				}
+				defer f.Close()
+				fmt.Fprintln(f, "#\n# Suggestions from commit-m:")
+				for _, s := range suggestions {
+					fmt.Fprintf(f, "#   %s\n", s.Message)
+				}
+			},
+		},
+	},
+}
+
+func hookPath() (string, error) {
+	dir, err := git("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+	return filepath.Join(dir, "prepare-commit-msg"), nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// getJSON requests url with the headers and decodes the JSON response into
+// v. It returns the response headers, which some APIs use for paging.
+func getJSON(ctx context.Context, url string, header http.Header, v interface{}) (http.Header, error) {
+	return doJSON(ctx, "GET", url, header, nil, v)
+}
+
+func doJSON(ctx context.Context, method, url string, header http.Header, body io.Reader, v interface{}) (http.Header, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", userAgent())
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return res.Header, fmt.Errorf("%s %s: %s", method, req.URL.Redacted(), res.Status)
+	}
+	return res.Header, json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// uiLang is the language of user facing messages, set by --lang-ui or the
+// locale environment variables.
+var uiLang = "en"
+
+// translations maps the English messages, which are used as keys, to the
+// message in each supported language.
+var translations = map[string]map[string]string{
+	"ja": {
+		"No Results Found.":                            "見つかりませんでした。",
+		"Search Result : %s : %d/%s pages\n":           "検索結果 : %s : %d/%s ページ\n",
+		"Repository":                                   "リポジトリ",
+		"message":                                      "メッセージ",
+		"unknown format: %s\n":                         "不明な出力形式です: %s\n",
+		"unknown rank: %s\n":                           "不明な並び順です: %s\n",
+		"unknown figure: %s\n":                         "不明なフィギュアです: %s\n",
+		"no such result: %d\n":                         "該当する結果がありません: %d\n",
+		"no such result: %s\n":                         "該当する結果がありません: %s\n",
+		"already on the first page":                    "最初のページです",
+		"  (%s failed, showing results from %s)\n":     "  (%s が失敗したため %s の結果を表示しています)\n",
+		"People also write:":                           "よく使われる言い回し:",
+		"copied:":                                      "コピーしました:",
+		"bookmarked:":                                  "ブックマークしました:",
+		"%s: unexpected page, no search results found": "%s: 検索結果のページではありません",
+		"... up to the last page reported by the first page":                                                                    "... 最初のページに表示される最後のページまで",
+		"\n[number] to select (q to quit): ":                                                                                    "\n[番号] で選択 (q で終了): ",
+		"\n[number][o=open, c=copy, b=bookmark], n=next page, p=previous page, /word=narrow, u=undo (q to quit): ":              "\n[番号][o=開く, c=コピー, b=ブックマーク], n=次のページ, p=前のページ, /語=絞り込み, u=元に戻す (q で終了): ",
+		"--full-message, --enrich, --check-links, --rank=stars and --min-stars need the network and are ignored with --offline": "--full-message, --enrich, --check-links, --rank=stars, --min-stars はネットワークが必要なため --offline では無視されます",
+		"%s: unexpected page structure: %s":                                                                                     "%s: ページの構造が想定と異なります: %s",
+		"unexpected page structure":                                                                                             "ページの構造が想定と異なります",
+		"showing the %d commits of the first %s pages, continue with --resume\n":                                                "最初の %[2]s ページの %[1]d 件を表示します。--resume で続きを取得できます\n",
+		"interrupted, writing the results collected so far":                                                                     "中断しました。取得済みの結果を出力します",
+		"warning: TLS certificates are not verified":                                                                            "警告: TLS 証明書を検証しません",
+		"  narrowed: %s\n": "  絞り込み: %s\n",
+		"nothing to undo":  "元に戻す絞り込みがありません",
+		"credentials are REDACTED, print them with --show-secrets": "認証情報は REDACTED と表示しています。--show-secrets で表示できます",
+	},
+}
+
+// tr returns the translation of the English message s, or s itself.
+func tr(s string) string {
+	if t, ok := translations[uiLang][s]; ok {
+		return t
+	}
+	return s
+}
+
+// setUILang selects the language from lang, or from LC_ALL, LC_MESSAGES
+// and LANG when it is empty. Unsupported languages fall back to English.
+func setUILang(lang string) {
+	if lang == "" {
+		for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if lang = os.Getenv(name); lang != "" {
+				break
+			}
+		}
+	}
+	// ja_JP.UTF-8 -> ja
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	lang = strings.ToLower(lang)
+	if _, ok := translations[lang]; ok {
+		uiLang = lang
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// interruptGrace is how long the results collected so far may take to be
+// written after an interrupt, before the process exits anyway.
+const interruptGrace = 5 * time.Second
+
+// runContext is the context of the requests of a command. It is cancelled
+// on SIGINT or SIGTERM.
+var runContext, cancelRun = context.WithCancel(context.Background())
+
+var interruptSignals = make(chan os.Signal, 1)
+
+// handleInterrupts cancels the in-flight requests on the first SIGINT or
+// SIGTERM, so the command can write what it collected and exit with
+// exitInterrupted. A second signal, or the grace period running out, exits
+// at once.
+func handleInterrupts() {
+	signal.Notify(interruptSignals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interruptSignals
+		fmt.Fprintln(os.Stderr, tr("interrupted, writing the results collected so far"))
+		cancelRun()
+		select {
+		case <-interruptSignals:
+		case <-time.After(interruptGrace):
+		}
+		stopProfiling()
+		os.Exit(exitInterrupted)
+	}()
+}
+
+// stopInterrupts leaves the signals to a command handling them itself.
+func stopInterrupts() {
+	signal.Stop(interruptSignals)
+}
+
+func interrupted() bool {
+	return runContext.Err() != nil
+}
+
+// pause waits d, returning false when interrupted first.
+func pause(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-runContext.Done():
+		return false
+	}
+}
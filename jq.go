@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// compileJq compiles the query of --jq.
+func compileJq(query string) (*gojq.Code, error) {
+	q, err := gojq.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("--jq: %s", err)
+	}
+	return gojq.Compile(q)
+}
+
+// showResultWithJq runs the query over the json output and prints each
+// value it yields, strings as they are (like jq -r) and the others as
+// compact json.
+func showResultWithJq(code *gojq.Code, output JsonFormat) error {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	var input interface{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	iter := code.RunWithContext(runContext, input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		switch v := v.(type) {
+		case error:
+			return fmt.Errorf("--jq: %s", v)
+		case string:
+			fmt.Println(v)
+		default:
+			line, err := gojq.Marshal(v)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(line))
+		}
+	}
+}
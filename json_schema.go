@@ -0,0 +1,23 @@
+package main
+
+import "reflect"
+
+// jsonSchemaVersion is the schema_version of the --json output. Fields may
+// be added without changing it; it is bumped when a field is removed,
+// renamed or changes type.
+const jsonSchemaVersion = 1
+
+// jsonOutputSchema is the JSON Schema of the --json output, printed by
+// --schema.
+func jsonOutputSchema() object {
+	const refs = "#/$defs/"
+	schema := inlineSchema(reflect.TypeOf(JsonFormat{}), refs)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "gommit-m search result"
+	schema["properties"].(object)["schema_version"] = object{"type": "integer", "const": jsonSchemaVersion}
+	schema["$defs"] = object{
+		schemaNames[reflect.TypeOf(commit{})]:    inlineSchema(reflect.TypeOf(commit{}), refs),
+		schemaNames[reflect.TypeOf(diffStats{})]: inlineSchema(reflect.TypeOf(diffStats{}), refs),
+	}
+	return schema
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
+)
+
+const (
+	keyringService = "gommit-m"
+	// keyringIndexFile lists the names stored in the keyring, so searches
+	// only ask the keyring, which may prompt to unlock it, for tokens
+	// that are there.
+	keyringIndexFile = "keyring.json"
+	apiKeyPrefix     = "api-key:"
+)
+
+// keyringFlags are the flags whose value auth login stores, by the name
+// stored under.
+var keyringFlags = map[string]string{
+	"github":      "github-token",
+	"gitlab":      "gitlab-token",
+	"bitbucket":   "bitbucket-token",
+	"sourcegraph": "sourcegraph-token",
+	"remote":      "remote-key",
+	"endpoint":    "auth-token",
+}
+
+func keyringNames() []string {
+	names := []string{}
+	for name := range keyringFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(names, apiKeyPrefix+"<name>")
+}
+
+func storedNames() ([]string, error) {
+	names := []string{}
+	err := loadJSON(dataPath(keyringIndexFile), &names)
+	return names, err
+}
+
+func setStoredName(name string, stored bool) error {
+	names, err := storedNames()
+	if err != nil {
+		return err
+	}
+	kept := []string{}
+	for _, n := range names {
+		if n != name {
+			kept = append(kept, n)
+		}
+	}
+	if stored {
+		kept = append(kept, name)
+	}
+	sort.Strings(kept)
+	return saveJSON(dataPath(keyringIndexFile), kept)
+}
+
+func validKeyringName(name string) bool {
+	_, ok := keyringFlags[name]
+	return ok || strings.HasPrefix(name, apiKeyPrefix) && len(name) > len(apiKeyPrefix)
+}
+
+// useKeyring sets the token flags not given on the command line or in the
+// environment to the tokens stored by auth login.
+func useKeyring(c *cli.Context) error {
+	names, err := storedNames()
+	if err != nil || len(names) == 0 {
+		return err
+	}
+	for _, name := range names {
+		flag, ok := keyringFlags[name]
+		if !ok || c.String(flag) != "" {
+			continue
+		}
+		secret, err := keyring.Get(keyringService, name)
+		if err != nil {
+			logger.Warn("failed to read the keyring", "name", name, "error", err)
+			continue
+		}
+		if err := c.Set(flag, secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyringAPIKey is the key of [api_keys.<name>] stored by auth login
+// api-key:<name>, for sections of the config without a key.
+func keyringAPIKey(name string) (string, error) {
+	return keyring.Get(keyringService, apiKeyPrefix+name)
+}
+
+// readSecret reads a line of stdin, without echoing it on a terminal.
+func readSecret(prompt string) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprint(os.Stderr, prompt)
+		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return strings.TrimSpace(string(secret)), err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		err = nil
+	}
+	return line, err
+}
+
+var authCommand = cli.Command{
+	Name:  "auth",
+	Usage: "store tokens in the OS keyring (Keychain, Secret Service or Credential Manager) instead of the config",
+	Subcommands: []cli.Command{
+		{
+			Name:      "login",
+			Usage:     "store a token, read from stdin, checking a GitHub token against the API",
+			ArgsUsage: "[" + strings.Join(keyringNames(), "|") + "]",
+			Action: func(c *cli.Context) {
+				name := firstNonEmpty(c.Args().First(), "github")
+				if !validKeyringName(name) {
+					fmt.Fprintf(os.Stderr, "unknown token %q, one of %s\n", name, strings.Join(keyringNames(), ", "))
+					os.Exit(exitUsage)
+				}
+				secret, err := readSecret(fmt.Sprintf("%s token: ", name))
+				if err == nil && secret == "" {
+					err = fmt.Errorf("no token given")
+				}
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if name == "github" {
+					user := struct {
+						Login string `json:"login"`
+					}{}
+					if err := newGithubClient(secret).get("/user", &user); err != nil {
+						fmt.Fprintln(os.Stderr, err)
+						os.Exit(1)
+					}
+					fmt.Printf("logged in to GitHub as %s\n", user.Login)
+				}
+				if err := keyring.Set(keyringService, name, secret); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if err := setStoredName(name, true); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				fmt.Printf("stored the %s token in the keyring\n", name)
+			},
+		},
+		{
+			Name:      "logout",
+			Usage:     "remove a stored token",
+			ArgsUsage: "[name]",
+			Action: func(c *cli.Context) {
+				name := firstNonEmpty(c.Args().First(), "github")
+				if err := keyring.Delete(keyringService, name); err != nil && err != keyring.ErrNotFound {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if err := setStoredName(name, false); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				fmt.Printf("removed the %s token\n", name)
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "list the stored tokens",
+			Action: func(c *cli.Context) {
+				names, err := storedNames()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				for _, name := range names {
+					if _, err := keyring.Get(keyringService, name); err != nil {
+						fmt.Printf("%s: %v\n", name, err)
+						continue
+					}
+					if flag, ok := keyringFlags[name]; ok {
+						fmt.Printf("%s: stored, used for --%s\n", name, flag)
+					} else {
+						fmt.Printf("%s: stored, used for [api_keys.%s]\n", name, strings.TrimPrefix(name, apiKeyPrefix))
+					}
+				}
+			},
+		},
+	},
+}
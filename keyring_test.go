@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestStoredNames(t *testing.T) {
+	defer func(dir string) { dataDirOverride = dir }(dataDirOverride)
+	dataDirOverride = t.TempDir()
+
+	for _, name := range []string{"github", "api-key:ci", "github"} {
+		if err := setStoredName(name, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := setStoredName("gitlab", false); err != nil {
+		t.Fatal(err)
+	}
+	names, err := storedNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "api-key:ci" || names[1] != "github" {
+		t.Errorf("stored names = %v, want [api-key:ci github]", names)
+	}
+}
+
+func TestKeyringAPIKey(t *testing.T) {
+	defer func(dir string) { dataDirOverride = dir }(dataDirOverride)
+	dataDirOverride = t.TempDir()
+	keyring.MockInit()
+	if err := keyring.Set(keyringService, apiKeyPrefix+"ci", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := newAPIKeys(map[string]apiKeyConfig{"ci": {DailyQuota: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := keys.lookup("s3cret"); !ok || name != "ci" {
+		t.Errorf("lookup of the stored key = %q, %v", name, ok)
+	}
+	if _, err := newAPIKeys(map[string]apiKeyConfig{"other": {}}); err == nil {
+		t.Error("an api key neither in the config nor the keyring was accepted")
+	}
+}
+
+func TestValidKeyringName(t *testing.T) {
+	for name, want := range map[string]bool{"github": true, "remote": true, "api-key:ci": true, "api-key:": false, "gh": false} {
+		if got := validKeyringName(name); got != want {
+			t.Errorf("validKeyringName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// latinLanguageWords are frequent short words telling apart the languages
+// written in the Latin script.
+var latinLanguageWords = map[string][]string{
+	"English":    {"the", "and", "to", "of", "for", "with", "on", "is", "add", "fix", "remove", "update", "use"},
+	"German":     {"der", "die", "das", "und", "ist", "nicht", "mit", "für", "von", "auf", "hinzugefügt", "entfernt"},
+	"French":     {"le", "la", "les", "et", "des", "du", "une", "pour", "dans", "est", "ajout", "correction"},
+	"Spanish":    {"el", "los", "las", "y", "del", "una", "para", "con", "por", "se", "agregado", "corrección"},
+	"Portuguese": {"o", "os", "e", "do", "da", "no", "na", "uma", "com", "não", "adicionado", "corrigido", "erro", "correção"},
+	"Italian":    {"il", "gli", "e", "di", "della", "una", "per", "con", "non", "aggiunto", "corretto"},
+	"Dutch":      {"de", "het", "een", "en", "van", "voor", "met", "niet", "toegevoegd", "verwijderd"},
+}
+
+// messageLanguage guesses the human language of the message from its
+// script, and for the Latin script from its frequent words.
+func messageLanguage(message string) string {
+	scripts := map[string]int{}
+	for _, r := range message {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			scripts["kana"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["han"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["Korean"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["Cyrillic"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["Arabic"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["Thai"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["Greek"]++
+		}
+	}
+	switch {
+	case scripts["kana"] > 0:
+		return "Japanese"
+	case scripts["han"] > 0:
+		return "Chinese"
+	}
+	best, most := "", 0
+	for script, n := range scripts {
+		if n > most || n == most && script < best {
+			best, most = script, n
+		}
+	}
+	if best == "Cyrillic" {
+		return "Russian/Cyrillic"
+	}
+	if best != "" {
+		return best
+	}
+
+	words := map[string]bool{}
+	for _, t := range tokenize(message) {
+		words[t] = true
+	}
+	best, most = "", 0
+	for language, frequent := range latinLanguageWords {
+		n := 0
+		for _, w := range frequent {
+			if words[w] {
+				n++
+			}
+		}
+		if n > most || n == most && n > 0 && language < best {
+			best, most = language, n
+		}
+	}
+	if best == "" {
+		// too short, or only identifiers
+		return "unknown"
+	}
+	return best
+}
+
+type languageCount struct {
+	Language string  `json:"language"`
+	Messages int     `json:"messages"`
+	Percent  float64 `json:"percent"`
+}
+
+// languageBreakdown counts the messages of each language, most first.
+func languageBreakdown(commits []*commit) []languageCount {
+	counts := map[string]int{}
+	for _, c := range commits {
+		counts[messageLanguage(c.Message)]++
+	}
+	languages := []languageCount{}
+	for _, t := range sortedTerms(counts) {
+		languages = append(languages, languageCount{Language: t.Term, Messages: t.Messages, Percent: 100 * float64(t.Messages) / float64(len(commits))})
+	}
+	return languages
+}
+
+func printLanguageBreakdown(languages []languageCount) {
+	width := 0
+	for _, l := range languages {
+		if len(l.Language) > width {
+			width = len(l.Language)
+		}
+	}
+	for _, l := range languages {
+		fmt.Printf("  %-*s %6d %5.1f%%  %s\n", width, l.Language, l.Messages, l.Percent, bar(l.Messages, languages[0].Messages, 30))
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+)
+
+const linkCheckWorkers = 8
+
+var checkCommand = cli.Command{
+	Name:      "check",
+	Usage:     "check commit urls of bookmarks or saved json results for dead links",
+	ArgsUsage: "[file...]",
+	Action: func(c *cli.Context) {
+		commits := []*commit{}
+		if len(c.Args()) == 0 {
+			bookmarks, err := loadBookmarks()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			for _, b := range bookmarks {
+				commits = append(commits, &b.commit)
+			}
+		}
+		for _, path := range c.Args() {
+			loaded, err := loadCommitsFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+				os.Exit(1)
+			}
+			commits = append(commits, loaded...)
+		}
+
+		checkLinks(commits)
+		dead := 0
+		for _, c := range commits {
+			if c.Dead {
+				dead++
+				fmt.Fprintf(color.Output, "%s %s %s\n    %s\n",
+					color.RedString("dead"),
+					theme.repo(c.Repo),
+					c.Message,
+					c.CommitURL,
+				)
+				if c.ArchiveURL != "" {
+					fmt.Printf("    archived: %s\n", c.ArchiveURL)
+				}
+			}
+		}
+		fmt.Printf("%d of %d links dead\n", dead, len(commits))
+	},
+}
+
+// loadCommitsFile reads commits from a file written by --json or from a
+// plain json array of commits.
+func loadCommitsFile(path string) ([]*commit, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	commits := []*commit{}
+	if err := json.Unmarshal(data, &commits); err == nil {
+		return commits, nil
+	}
+	result := JsonFormat{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result.Commits, nil
+}
+
+// checkLinks concurrently sends a HEAD request to each commit url and marks
+// the commits whose url is gone, looking up an archived snapshot for them.
+func checkLinks(commits []*commit) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	queue := make(chan *commit)
+	var wg sync.WaitGroup
+	for i := 0; i < linkCheckWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range queue {
+				c.Dead = isDeadLink(client, c.CommitURL)
+				if c.Dead {
+					c.ArchiveURL, _ = waybackSnapshot(client, c.CommitURL)
+				}
+			}
+		}()
+	}
+	for _, c := range commits {
+		queue <- c
+	}
+	close(queue)
+	wg.Wait()
+}
+
+func isDeadLink(client *http.Client, url string) bool {
+	if url == "" {
+		return false
+	}
+	res, err := client.Head(url)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+	return res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone
+}
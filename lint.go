@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/codegangsta/cli"
+	"github.com/yuroyoro/gommit-m/parser"
+)
+
+// rareWordThreshold is the number of corpus hits under which a word is
+// considered unusual.
+const rareWordThreshold = 3
+
+// lintCacheTTL keeps word frequencies around, as linting every commit
+// looks up the same words over and over.
+const lintCacheTTL = 24 * time.Hour
+
+// commonMisspellings maps frequent misspellings in commit messages to
+// their correction.
+var commonMisspellings = map[string]string{
+	"accomodate": "accommodate",
+	"acheive":    "achieve",
+	"adress":     "address",
+	"agian":      "again",
+	"alot":       "a lot",
+	"arguement":  "argument",
+	"begining":   "beginning",
+	"beleive":    "believe",
+	"calender":   "calendar",
+	"comit":      "commit",
+	"commited":   "committed",
+	"compatable": "compatible",
+	"definately": "definitely",
+	"dependancy": "dependency",
+	"enviroment": "environment",
+	"existant":   "existent",
+	"fucntion":   "function",
+	"funtion":    "function",
+	"lenght":     "length",
+	"occured":    "occurred",
+	"paramter":   "parameter",
+	"recieve":    "receive",
+	"refered":    "referred",
+	"seperate":   "separate",
+	"succesful":  "successful",
+	"teh":        "the",
+	"threshhold": "threshold",
+	"untill":     "until",
+	"wich":       "which",
+	"writting":   "writing",
+}
+
+var lintCommand = cli.Command{
+	Name:      "lint",
+	Usage:     "check a commit message against the commit-m corpus (usable as a commit-msg hook)",
+	ArgsUsage: "[file]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "fix-suggestions",
+			Usage: "print suggested fixes for each problem",
+		},
+		cli.BoolFlag{
+			Name:  "warn-only",
+			Usage: "always exit 0, only printing problems",
+		},
+	},
+	Action: func(c *cli.Context) {
+		var in io.Reader = os.Stdin
+		if path := c.Args().First(); path != "" && path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		subject := readSubject(in)
+		if subject == "" {
+			return
+		}
+
+		problems := lintMessage(subject)
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "gommit-m lint: %s\n", p.Description)
+			if c.Bool("fix-suggestions") && p.Suggestion != "" {
+				fmt.Fprintf(os.Stderr, "    suggestion: %s\n", p.Suggestion)
+			}
+		}
+		if len(problems) > 0 && !c.Bool("warn-only") {
+			os.Exit(1)
+		}
+	},
+}
+
+type lintProblem struct {
+	Description string `json:"description"`
+	Suggestion  string `json:"suggestion,omitempty"`
+}
+
+// readSubject returns the first line that is neither blank nor a git
+// comment.
+func readSubject(r io.Reader) string {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// significantWords returns the lower cased words of the message that are
+// worth looking up, skipping short words and numbers.
+func significantWords(message string) []string {
+	words := []string{}
+	for _, word := range strings.FieldsFunc(message, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		word = strings.ToLower(strings.Trim(word, "'"))
+		if len([]rune(word)) > 2 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// corpusFrequency returns the number of commit-m results for the keyword.
+func corpusFrequency(keyword string) (int, error) {
+	result, err := cachedCrawl(keyword, 1, lintCacheTTL)
+	if err != nil {
+		return 0, err
+	}
+	if n := parseResultCount(result.ResultCount); n > 0 {
+		return n, nil
+	}
+	return len(result.Commits), nil
+}
+
+func parseResultCount(resultCount string) int {
+	return parser.Count(resultCount)
+}
+
+func lintMessage(subject string) []*lintProblem {
+	problems := []*lintProblem{}
+	words := significantWords(subject)
+
+	freq := map[string]int{}
+	for _, word := range words {
+		if correction, ok := commonMisspellings[word]; ok && correction != "" {
+			problems = append(problems, &lintProblem{
+				Description: fmt.Sprintf("%q looks like a misspelling", word),
+				Suggestion:  correction,
+			})
+			continue
+		}
+		if _, ok := freq[word]; ok {
+			continue
+		}
+		n, err := corpusFrequency(word)
+		if err != nil {
+			continue
+		}
+		freq[word] = n
+		if n == 0 {
+			problems = append(problems, &lintProblem{
+				Description: fmt.Sprintf("%q never appears in the corpus", word),
+			})
+		} else if n < rareWordThreshold {
+			problems = append(problems, &lintProblem{
+				Description: fmt.Sprintf("%q is very rare in the corpus (%d results)", word, n),
+			})
+		}
+	}
+
+	// adjacent pairs of known words nobody has ever written together
+	for i := 0; i+1 < len(words); i++ {
+		if freq[words[i]] < rareWordThreshold || freq[words[i+1]] < rareWordThreshold {
+			continue
+		}
+		phrase := words[i] + " " + words[i+1]
+		n, err := corpusFrequency(phrase)
+		if err != nil || n > 0 {
+			continue
+		}
+		p := &lintProblem{Description: fmt.Sprintf("unusual phrasing: %q", phrase)}
+		if result, err := cachedCrawl(words[i], 1, lintCacheTTL); err == nil && len(result.Commits) > 0 {
+			p.Suggestion = result.Commits[0].Message
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+const localIndexFile = "local.json"
+
+// localRepo is a local git repository and its commit subjects.
+type localRepo struct {
+	Path    string    `json:"path"`
+	Name    string    `json:"name"`
+	Commits []*commit `json:"commits"`
+}
+
+var remoteURLPattern = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(\.git)?$`)
+
+var localCommand = cli.Command{
+	Name:  "local",
+	Usage: "index local git repositories for --include-local",
+	Subcommands: []cli.Command{
+		{
+			Name:      "index",
+			Usage:     "add the git repositories under the paths to the local index",
+			ArgsUsage: "path...",
+			Action: func(c *cli.Context) {
+				if len(c.Args()) == 0 {
+					cli.ShowCommandHelp(c, "index")
+					os.Exit(1)
+				}
+				index, err := loadLocalIndex()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				for _, root := range c.Args() {
+					for _, path := range findGitRepos(root) {
+						repo, err := indexLocalRepo(path)
+						if err != nil {
+							logger.Warn("failed to index repository", "path", path, "error", err)
+							continue
+						}
+						index[repo.Path] = repo
+						fmt.Printf("indexed %s (%d commits)\n", repo.Name, len(repo.Commits))
+					}
+				}
+				if err := saveJSON(dataPath(localIndexFile), index); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "list indexed repositories",
+			Action: func(c *cli.Context) {
+				index, err := loadLocalIndex()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				paths := []string{}
+				for path := range index {
+					paths = append(paths, path)
+				}
+				sort.Strings(paths)
+				for _, path := range paths {
+					fmt.Printf("%s\t%d commits\t%s\n", index[path].Name, len(index[path].Commits), path)
+				}
+			},
+		},
+	},
+}
+
+func loadLocalIndex() (map[string]*localRepo, error) {
+	index := map[string]*localRepo{}
+	err := loadJSON(dataPath(localIndexFile), &index)
+	return index, err
+}
+
+// findGitRepos returns the git working trees at or below root, not
+// descending into repositories it found.
+func findGitRepos(root string) []string {
+	repos := []string{}
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			repos = append(repos, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return repos
+}
+
+func indexLocalRepo(path string) (*localRepo, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := git("-C", abs, "log", "--format=%H%x09%s")
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &localRepo{Path: abs, Name: filepath.Base(abs)}
+	repoURL := ""
+	if remote, err := git("-C", abs, "remote", "get-url", "origin"); err == nil {
+		if m := remoteURLPattern.FindStringSubmatch(remote); m != nil {
+			repo.Name = m[1] + "/" + m[2]
+			repoURL = "https://github.com/" + repo.Name
+		}
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		c := &commit{
+			Repo:    repo.Name,
+			RepoURL: repoURL,
+			Sha1:    fields[0][:7],
+			Message: fields[1],
+			Source:  "local",
+		}
+		if repoURL != "" {
+			c.CommitURL = repoURL + "/commit/" + fields[0]
+		}
+		repo.Commits = append(repo.Commits, c)
+	}
+	return repo, nil
+}
+
+// searchLocal returns the indexed commits whose message contains every word
+// of the keyword, ignoring case.
+func searchLocal(keyword string) ([]*commit, error) {
+	index, err := loadLocalIndex()
+	if err != nil {
+		return nil, err
+	}
+	words := strings.Fields(strings.ToLower(keyword))
+
+	hits := []*commit{}
+	for _, repo := range index {
+		for _, c := range repo.Commits {
+			message := strings.ToLower(c.Message)
+			matched := true
+			for _, word := range words {
+				if !strings.Contains(message, word) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				hits = append(hits, c)
+			}
+		}
+	}
+	return hits, nil
+}
+
+// includeLocal adds the local hits to the first page of backend results,
+// labeling each commit with where it came from. Local hits are returned
+// even when the backend could not be reached.
+func includeLocal(result QueryResult, err error, keyword string, page int) (QueryResult, error) {
+	for _, c := range result.Commits {
+		if c.Source == "" {
+			c.Source = backend.Name()
+		}
+	}
+	if page != 1 {
+		return result, err
+	}
+	hits, lerr := searchLocal(keyword)
+	if lerr != nil {
+		return result, err
+	}
+	result.Commits = append(result.Commits, hits...)
+	if err != nil && len(hits) > 0 {
+		fmt.Fprintln(os.Stderr, err)
+		err = nil
+	}
+	return result, err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// logger is the log of gommit-m on stderr, shared by the commands, the
+// crawler and serve: warnings, and debug messages with --debug.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{ReplaceAttr: dropTime}))
+
+// setLogger installs a text or json logger of the level. The commands
+// leave the time out of the text log; servers keep it.
+func setLogger(format string, level slog.Level, timestamps bool) error {
+	opts := &slog.HandlerOptions{Level: level}
+	if !timestamps {
+		opts.ReplaceAttr = dropTime
+	}
+	switch format {
+	case "text", "":
+		logger = slog.New(slog.NewTextHandler(os.Stderr, opts))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	default:
+		return fmt.Errorf("unknown --log-format: %s", format)
+	}
+	return nil
+}
+
+func dropTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+	return a
+}
+
+// logLevel is the level of --debug.
+func logLevel(debug bool) slog.Level {
+	if debug {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -11,142 +11,675 @@ import (
 
 	"unicode/utf8"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/codegangsta/cli"
+	"github.com/itchyny/gojq"
 	"github.com/mattn/go-runewidth"
 
 	"github.com/fatih/color"
 )
 
 type commit struct {
-	Repo      string `json:"repo"`
-	RepoURL   string `json:"repo_url"`
-	Sha1      string `json:"sha1"`
-	CommitURL string `json:"commit_url"`
-	Message   string `json:"message"`
+	Repo       string     `json:"repo"`
+	RepoURL    string     `json:"repo_url"`
+	Sha1       string     `json:"sha1"`
+	CommitURL  string     `json:"commit_url"`
+	Message    string     `json:"message"`
+	Body       string     `json:"body,omitempty"`
+	Author     string     `json:"author,omitempty"`
+	Date       string     `json:"date,omitempty"`
+	Stats      *diffStats `json:"stats,omitempty"`
+	Dead       bool       `json:"dead,omitempty"`
+	ArchiveURL string     `json:"archive_url,omitempty"`
+	Stars      int        `json:"stars,omitempty"`
+	Source     string     `json:"source,omitempty"`
+	// ClusterSize is the number of near-duplicate messages the commit
+	// stands for with --cluster.
+	ClusterSize int `json:"cluster_size,omitempty"`
+}
+
+// displayURL is the url shown for the commit: its archived snapshot when
+// the original is gone.
+func (c *commit) displayURL() string {
+	if c.Dead && c.ArchiveURL != "" {
+		return c.ArchiveURL
+	}
+	return c.CommitURL
 }
 
 type QueryResult struct {
 	Commits     []*commit
 	ResultCount string
 	TotalPages  string
+	// Fallback names the backend searched because the primary one failed.
+	Fallback string `json:",omitempty"`
 }
 
 type JsonFormat struct {
-	Commits []*commit `json:"commits"`
-	Error   string    `json:"error"`
+	SchemaVersion int       `json:"schema_version"`
+	Commits       []*commit `json:"commits"`
+	Error         string    `json:"error"`
+	// ErrorCode is the exit status of the failed search, see EXIT STATUS
+	// in the README.
+	ErrorCode int `json:"error_code,omitempty"`
+	// ResultCount and TotalPages are 0 when the page does not tell.
+	ResultCount int    `json:"result_count"`
+	Page        int    `json:"page"`
+	TotalPages  int    `json:"total_pages"`
+	URL         string `json:"url"`
 }
 
+func jsonResult(result QueryResult, url string, page int, err error) JsonFormat {
+	if err != nil {
+		return JsonFormat{SchemaVersion: jsonSchemaVersion, Commits: []*commit{}, Error: err.Error(), ErrorCode: failureExitCode(err), Page: page, URL: url}
+	}
+	totalPages, _ := strconv.Atoi(result.TotalPages)
+	return JsonFormat{
+		SchemaVersion: jsonSchemaVersion,
+		Commits:       result.Commits,
+		ResultCount:   parseResultCount(result.ResultCount),
+		Page:          page,
+		TotalPages:    totalPages,
+		URL:           url,
+	}
+}
+
+var searchFlags = append([]cli.Flag{
+	cli.BoolFlag{
+		Name:  "json",
+		Usage: "output as json (same as --format=json)",
+	},
+	cli.StringFlag{
+		Name:  "jq",
+		Usage: "print the json output through a jq filter, e.g. '.commits[] | select(.repo | test(\"rails\")) | .message'",
+	},
+	cli.BoolFlag{
+		Name:  "pretty",
+		Usage: "output as json, indented and with ordered keys, for reading and stable diffs",
+	},
+	cli.BoolFlag{
+		Name:  "schema",
+		Usage: "print the JSON Schema of the json output and exit",
+	},
+	cli.BoolFlag{
+		Name:  "sexp",
+		Usage: "output as an Emacs Lisp plist (same as --format=sexp)",
+	},
+	cli.BoolFlag{
+		Name:  "transliterate",
+		Usage: "also search the romaji spelling of kana keywords, and the kana spelling of romaji keywords",
+	},
+	cli.BoolFlag{
+		Name:  "as-curl",
+		Usage: "print the equivalent curl commands instead of searching",
+	},
+	cli.BoolFlag{
+		Name:  "show-secrets",
+		Usage: "print the credentials in --as-curl commands instead of REDACTED",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "print the search urls without fetching them",
+	},
+	cli.BoolFlag{
+		Name:  "fail-empty",
+		Usage: "exit with status 4 when nothing is found",
+	},
+	cli.StringFlag{
+		Name:  "format",
+		Value: "table",
+		Usage: "output format: table, json, sexp, msgpack, cbor, proto (length-delimited SearchResponse of proto/commit_search.proto) or plugin:<name> for a formatter in the config",
+	},
+	cli.BoolFlag{
+		Name:  "lucky",
+		Usage: "open the first result in the browser instead of printing the results",
+	},
+	cli.BoolFlag{
+		Name:  "interactive, i",
+		Usage: "prompt for an action (open, copy, bookmark) after results",
+	},
+	cli.StringSliceFlag{
+		Name:  "exclude, x",
+		Value: &cli.StringSlice{},
+		Usage: "exclude commits whose message contains the word",
+	},
+	cli.BoolFlag{
+		Name:  "safe",
+		Usage: "filter out messages containing profanity (extend the list with profanity = [...] in the config)",
+	},
+	cli.BoolFlag{
+		Name:  "all",
+		Usage: "crawl every result page and show all commits, without duplicates",
+	},
+	cli.BoolFlag{
+		Name:  "dedupe-messages",
+		Usage: "with --all, also drop commits whose normalized message was already shown",
+	},
+	cli.BoolFlag{
+		Name:  "phrases",
+		Usage: "after the results, print the phrases around the keyword most often found in them",
+	},
+	cli.BoolFlag{
+		Name:  "cluster",
+		Usage: "show one message per group of messages differing only by paths, numbers or case, with the group size",
+	},
+	cli.BoolFlag{
+		Name:  "resume",
+		Usage: "with --all, continue an interrupted crawl from its checkpoint",
+	},
+	cli.BoolFlag{
+		Name:  "since-last",
+		Usage: "only show commits not returned by the previous --since-last run of the query",
+	},
+	cli.DurationFlag{
+		Name:  "cache-ttl",
+		Usage: "reuse cached results fetched within this duration (e.g. 1h, 0 disables)",
+	},
+	cli.BoolFlag{
+		Name:  "offline",
+		Usage: "only serve results from the local cache, never touching the network",
+	},
+	cli.BoolFlag{
+		Name:  "include-local",
+		Usage: "also search the local index built by 'gommit-m local index'",
+	},
+	cli.BoolFlag{
+		Name:  "full-message",
+		Usage: "fetch the full commit messages from the GitHub API",
+	},
+	cli.GenericFlag{
+		Name:  "enrich",
+		Value: &enrichValue{},
+		Usage: "add data from the GitHub API: author (default), stats, or both (--enrich=author,stats)",
+	},
+	cli.StringFlag{
+		Name:  "rank",
+		Usage: rankUsage(),
+	},
+	cli.StringFlag{
+		Name:  "sort",
+		Usage: "order results by comma separated keys of " + strings.Join(sortKeyNames(), ", ") + ", -key descending (e.g. repo,-length); text is compared as in the locale of LC_COLLATE or LANG",
+	},
+	cli.BoolFlag{
+		Name:  "check-links",
+		Usage: "mark commits whose url no longer exists",
+	},
+	cli.IntFlag{
+		Name:  "gh-browse",
+		Usage: "open the numbered result with the gh command instead of printing results",
+	},
+	cli.StringFlag{
+		Name:   "github-token",
+		Usage:  "token for GitHub API requests (defaults to the gh auth token)",
+		EnvVar: "GITHUB_TOKEN",
+	},
+	cli.StringFlag{
+		Name:  "since",
+		Usage: "only commits authored on or after the date (YYYY-MM-DD), looked up on GitHub as with --enrich=author",
+	},
+	cli.StringFlag{
+		Name:  "until",
+		Usage: "only commits authored on or before the date (YYYY-MM-DD), looked up on GitHub as with --enrich=author",
+	},
+	cli.StringSliceFlag{
+		Name:  "author",
+		Value: &cli.StringSlice{},
+		Usage: "only commits by the GitHub login or name (* is a wildcard), looked up as with --enrich=author",
+	},
+	cli.StringSliceFlag{
+		Name:  "exclude-author",
+		Value: &cli.StringSlice{},
+		Usage: "drop commits by the GitHub login or name (* is a wildcard), e.g. --exclude-author '*[bot]'",
+	},
+	cli.IntFlag{
+		Name:  "min-stars",
+		Usage: "drop commits of repositories with fewer GitHub stars than this (looked up once a day per repository)",
+	},
+	cli.IntFlag{
+		Name:  "max-changes",
+		Usage: "with --enrich=stats, drop commits changing more lines than this",
+	},
+	cli.StringFlag{
+		Name:  "output, o",
+		Usage: "also add the results to this SQLite database, created with the schema of db if needed",
+	},
+	cli.StringFlag{
+		Name:  "save-session",
+		Usage: "also keep the results under this name, for session load and diff",
+	},
+	cli.StringFlag{
+		Name:  "diff-against",
+		Usage: "print the commits added and removed since the session saved under this name, instead of the results",
+	},
+	cli.StringFlag{
+		Name:  "template-out",
+		Usage: "write the top messages as a git commit template to the file",
+	},
+	cli.IntFlag{
+		Name:  "template-count",
+		Value: 10,
+		Usage: "number of messages written by --template-out",
+	},
+}, append(append(webhookFlags, chatFlags...), figureFlags...)...)
+
 func main() {
 	app := cli.NewApp()
 	app.Name = "gommit-m"
 	app.Usage = "Command Line Client for commit-m (http://commit-m.minamijoyo.com)"
 	app.ArgsUsage = "keyword [page]"
+	app.Version = version
+	// -v is --debug
+	cli.VersionFlag = cli.BoolFlag{
+		Name:  "version",
+		Usage: "print the version",
+	}
 	app.HideHelp = true
-	app.Flags = []cli.Flag{
-		cli.BoolFlag{
-			Name:  "json",
-			Usage: "output as json",
-		},
-	}
-
-	app.Action = func(c *cli.Context) {
-		keyword := c.Args().First()
-		page := 1
-		if givenPage := c.Args().Get(1); givenPage != "" {
-			if optPage, err := strconv.Atoi(givenPage); err == nil {
-				page = optPage
-			}
-		}
-
-		if keyword == "" {
-			cli.ShowAppHelp(c)
+	app.Flags = []cli.Flag{}
+	for _, flags := range [][]cli.Flag{searchFlags, dirFlags, globalFlags, authFlags, backendFlags, fixtureFlags, profileFlags} {
+		app.Flags = append(app.Flags, flags...)
+	}
+	app.Before = func(c *cli.Context) error {
+		setDirs(c)
+		if err := configure(c); err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
-		url := buildUrl(keyword, page)
-		result, err := crawl(url)
-		if c.Bool("json") {
-			showResultAsJson(result, err)
-		} else {
-			showResult(result, url, keyword, page)
+		if err := startProfiling(c); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		handleInterrupts()
+		return nil
 	}
+	app.After = func(c *cli.Context) error {
+		stopProfiling()
+		return nil
+	}
+	app.EnableBashCompletion = true
+	app.BashComplete = completeKeywords
+	app.Commands = []cli.Command{
+		saveCommand,
+		runCommand,
+		historyCommand,
+		sessionCommand,
+		diffCommand,
+		bookmarkCommand,
+		watchCommand,
+		hookCommand,
+		authCommand,
+		configCommand,
+		suggestCommand,
+		lintCommand,
+		scoreCommand,
+		commitCommand,
+		widgetCommand,
+		patchCommand,
+		checkCommand,
+		localCommand,
+		cacheCommand,
+		dbCommand,
+		grepCommand,
+		syncCommand,
+		exportCommand,
+		openCommand,
+		copyCommand,
+		completionCommand,
+		versionCommand,
+		feedCommand,
+		serveCommand,
+		mcpCommand,
+		rpcCommand,
+		proxyCommand,
+		statsCommand,
+		analyzeCommand,
+		topCommand,
+		typosCommand,
+		fortuneCommand,
+		exploreCommand,
+		motdCommand,
+		similarCommand,
+		benchCommand,
+	}
+	app.Action = search
 
 	app.Run(os.Args)
 }
 
-func buildUrl(keyword string, page int) string {
-	return fmt.Sprintf("http://commit-m.minamijoyo.com/commits/search?keyword=%s&page=%d", url.QueryEscape(keyword), page)
+func search(c *cli.Context) {
+	if c.Bool("schema") {
+		printJSON(jsonOutputSchema())
+		return
+	}
+	keyword := c.Args().First()
+	page := parsePage(c.Args().Get(1))
+
+	if keyword == "" && !isTerminal(os.Stdin) {
+		// e.g. git diff --cached --name-only | gommit-m
+		keywords, err := readKeywords(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+		for _, keyword := range keywords {
+			searchKeyword(c, keyword, page)
+		}
+		if len(keywords) > 0 {
+			return
+		}
+	}
+	if keyword == "" {
+		cli.ShowAppHelp(c)
+		os.Exit(exitUsage)
+	}
+	searchKeyword(c, keyword, page)
 }
 
-func crawl(url string) (QueryResult, error) {
-	commits := []*commit{}
-	doc, err := goquery.NewDocument(url)
-	if err != nil {
-		return QueryResult{
-			Commits:     commits,
-			ResultCount: "",
-			TotalPages:  "",
-		}, err
-
-	}
-	doc.Find("table.table tr").Each(func(_ int, line *goquery.Selection) {
-		cellsTxt := [3]string{"", "", ""}
-		hrefIndex := 0
-		cellsHref := [2]string{"", ""}
-		line.Find("td").Each(func(i int, s *goquery.Selection) {
-			cellsTxt[i] = s.Text()
-			s.Find("a").Each(func(_ int, s *goquery.Selection) {
-				href, _ := s.Attr("href")
-				if href != "" {
-					cellsHref[hrefIndex] = href
-					hrefIndex += 1
-				}
-			})
-		})
-		commit := commit{
-			Message:   strings.TrimSpace(cellsTxt[0]),
-			Repo:      cellsTxt[1],
-			RepoURL:   cellsHref[0],
-			Sha1:      cellsTxt[2],
-			CommitURL: cellsHref[1],
-		}
-		if commit.Sha1 != "" {
-			commits = append(commits, &commit)
+func searchKeyword(c *cli.Context, keyword string, page int) {
+	format := c.String("format")
+	if c.Bool("json") || c.Bool("pretty") {
+		format = "json"
+	}
+	if c.Bool("sexp") {
+		format = "sexp"
+	}
+	if format != "table" && format != "proto" && !structuredFormat(format) && !strings.HasPrefix(format, formatterPrefix) {
+		fmt.Fprintf(os.Stderr, tr("unknown format: %s\n"), format)
+		os.Exit(1)
+	}
+	if rank := c.String("rank"); rank != "" && rankers[rank] == nil {
+		fmt.Fprintf(os.Stderr, tr("unknown rank: %s\n"), rank)
+		os.Exit(1)
+	}
+	var jq *gojq.Code
+	if query := c.String("jq"); query != "" {
+		var jerr error
+		if jq, jerr = compileJq(query); jerr != nil {
+			fmt.Fprintln(os.Stderr, jerr)
+			os.Exit(exitUsage)
 		}
-	})
-	return QueryResult{
-		Commits:     commits,
-		ResultCount: getResultCount(doc),
-		TotalPages:  getTotalPages(doc),
-	}, nil
-}
-
-func getResultCount(doc *goquery.Document) string {
-	results := ""
-	pattern := regexp.MustCompile("(\\d+) results")
-	doc.Find("div.container").Each(func(i int, s *goquery.Selection) {
-		for c := s.Nodes[0].FirstChild; c != nil; c = c.NextSibling {
-			if c.Type == 1 {
-				matches := pattern.FindStringSubmatch(c.Data)
-				if len(matches) > 0 {
-					results = matches[0]
-					break
-				}
+		format = "json"
+	}
+	// terms is what is sent to the backend; the query as typed, operators
+	// included, is what history, sessions and notifications record.
+	terms, operators, qerr := parseQueryOperators(keyword)
+	if qerr == nil && terms == "" {
+		qerr = fmt.Errorf("nothing to search for besides the operators")
+	}
+	if qerr != nil {
+		fmt.Fprintln(os.Stderr, qerr)
+		os.Exit(exitUsage)
+	}
+	since, derr := parseDate(c.String("since"))
+	if derr != nil {
+		fmt.Fprintln(os.Stderr, derr)
+		os.Exit(exitUsage)
+	}
+	until, derr := parseDate(c.String("until"))
+	if derr != nil {
+		fmt.Fprintln(os.Stderr, derr)
+		os.Exit(exitUsage)
+	}
+	var sortKeys []sortKey
+	if spec := c.String("sort"); spec != "" {
+		var serr error
+		if sortKeys, serr = parseSortKeys(spec); serr != nil {
+			fmt.Fprintln(os.Stderr, serr)
+			os.Exit(exitUsage)
+		}
+	}
+	enrich, _ := c.Generic("enrich").(*enrichValue)
+	authors, excludedAuthors := c.StringSlice("author"), c.StringSlice("exclude-author")
+	if !since.IsZero() || !until.IsZero() || len(authors) > 0 || len(excludedAuthors) > 0 || usesSortKey(sortKeys, "author", "date") {
+		enrich = enrich.with("author")
+	}
+	figure := selectedFigure(c)
+	offline := c.Bool("offline")
+	if offline && (c.Bool("full-message") || enrich.enabled() || c.Bool("check-links") || c.String("rank") == "stars" || c.IsSet("min-stars")) {
+		fmt.Fprintln(os.Stderr, tr("--full-message, --enrich, --check-links, --rank=stars and --min-stars need the network and are ignored with --offline"))
+	}
+
+	url := buildUrl(terms, page)
+	if c.Bool("dry-run") || c.Bool("as-curl") {
+		show := func(url string) { fmt.Println(url) }
+		hidden := false
+		if c.Bool("as-curl") {
+			show = func(url string) {
+				command, h := curlCommand(url, c.Bool("show-secrets"))
+				fmt.Println(command)
+				hidden = hidden || h
 			}
 		}
-	})
-	return results
+		if c.Bool("all") {
+			show(buildUrl(terms, 1))
+			show(buildUrl(terms, 2))
+			fmt.Fprintln(os.Stderr, tr("... up to the last page reported by the first page"))
+		} else {
+			show(url)
+		}
+		if hidden {
+			fmt.Fprintln(os.Stderr, tr("credentials are REDACTED, print them with --show-secrets"))
+		}
+		return
+	}
+	fetch := func(keyword string) (QueryResult, error) {
+		switch {
+		case offline:
+			return offlineResult(buildUrl(keyword, page))
+		case c.Bool("all"):
+			return crawlAll(keyword, c.Bool("dedupe-messages"), c.Bool("resume"))
+		default:
+			return cachedCrawl(keyword, page, c.Duration("cache-ttl"))
+		}
+	}
+	result, err := fetch(terms)
+	if err == nil && c.Bool("transliterate") {
+		result = mergeVariants(result, terms, fetch)
+	}
+	if c.Bool("all") {
+		page, _ = strconv.Atoi(result.TotalPages)
+	}
+	if c.Bool("include-local") {
+		result, err = includeLocal(result, err, terms, page)
+	}
+	result.Commits = excludeCommits(result.Commits, c.StringSlice("exclude"))
+	result.Commits = operators.filter(result.Commits)
+	if c.Bool("safe") {
+		result.Commits = safeCommits(result.Commits)
+	}
+	if err == nil && !offline && (c.Bool("full-message") || enrich.enabled()) {
+		if gerr := newGithubClient(githubToken(c)).enrich(result.Commits, c.Bool("full-message"), enrich); gerr != nil {
+			logger.Warn("enrichment failed", "error", gerr)
+		}
+	}
+	if err == nil && !offline && c.Bool("check-links") {
+		checkLinks(result.Commits)
+	}
+	if err == nil && !offline && (c.String("rank") == "stars" || c.IsSet("min-stars") || usesSortKey(sortKeys, "stars")) {
+		if gerr := newGithubClient(githubToken(c)).fetchStars(result.Commits); gerr != nil {
+			logger.Warn("failed to look up stars", "error", gerr)
+		}
+		if c.IsSet("min-stars") {
+			result.Commits = filterMinStars(result.Commits, c.Int("min-stars"))
+		}
+	}
+	if ranker, ok := rankers[c.String("rank")]; ok {
+		ranker.Rank(result.Commits, terms)
+	}
+	if len(sortKeys) > 0 {
+		sortCommits(result.Commits, sortKeys, collationLocale())
+	}
+	result.Commits = filterDates(result.Commits, since, until)
+	result.Commits = filterAuthors(result.Commits, authors, excludedAuthors)
+	if c.IsSet("max-changes") {
+		result.Commits = filterMaxChanges(result.Commits, c.Int("max-changes"))
+	}
+	if c.Bool("cluster") {
+		result.Commits = clusterCommits(result.Commits)
+	}
+	if err == nil && c.Bool("since-last") {
+		query := fmt.Sprintf("%s\x00%d", keyword, page)
+		if c.Bool("all") {
+			query = keyword + "\x00all"
+		}
+		fresh, serr := newSinceLast(query, result.Commits)
+		if serr != nil {
+			logger.Warn("failed to compare with the last search", "error", serr)
+		} else {
+			result.Commits = fresh
+		}
+	}
+	var baseline *session
+	if name := c.String("diff-against"); name != "" && err == nil {
+		var derr error
+		if baseline, derr = loadNamedSession(name); derr != nil {
+			fmt.Fprintln(os.Stderr, derr)
+			os.Exit(1)
+		}
+	}
+	if err == nil {
+		if herr := recordHistory(c, keyword, page, len(result.Commits)); herr != nil {
+			logger.Warn("failed to record history", "error", herr)
+		}
+		if lerr := saveSession(keyword, page, result); lerr != nil {
+			logger.Warn("failed to save session", "error", lerr)
+		}
+		if name := c.String("save-session"); name != "" {
+			if serr := saveNamedSession(name, flagArgs(c, searchFlags), keyword, page, result); serr != nil {
+				fmt.Fprintln(os.Stderr, serr)
+				os.Exit(1)
+			}
+		}
+		if path := c.String("output"); path != "" {
+			if oerr := writeResultsDB(path, terms, result.Commits); oerr != nil {
+				fmt.Fprintln(os.Stderr, oerr)
+				os.Exit(1)
+			}
+		}
+		if path := c.String("template-out"); path != "" {
+			if terr := writeCommitTemplate(path, terms, result.Commits, c.Int("template-count")); terr != nil {
+				logger.Warn("failed to write commit template", "error", terr)
+			}
+		}
+	}
+	if hook := c.String("post-webhook"); hook != "" {
+		if werr := postWebhook(hook, c.String("webhook-secret"), keyword, page, result.Commits, err); werr != nil {
+			logger.Warn("webhook failed", "error", werr)
+		}
+	}
+	for _, nerr := range notifyChats(c, keyword, result.Commits) {
+		logger.Warn("chat notification failed", "error", nerr)
+	}
+	if baseline != nil {
+		showSessionDiff(diffSessions(baseline, newSession(keyword, page, result)), format == "json")
+		return
+	}
+	if c.Bool("lucky") && err == nil {
+		if len(result.Commits) == 0 {
+			fmt.Println(tr("No Results Found."))
+			os.Exit(exitNoResults)
+		}
+		if berr := openBrowser(result.Commits[0].displayURL()); berr != nil {
+			fmt.Fprintln(os.Stderr, berr)
+			os.Exit(1)
+		}
+		return
+	}
+	if n := c.Int("gh-browse"); n > 0 && err == nil {
+		if n > len(result.Commits) {
+			fmt.Fprintf(os.Stderr, tr("no such result: %d\n"), n)
+			os.Exit(1)
+		}
+		if gerr := ghBrowse(result.Commits[n-1]); gerr != nil {
+			fmt.Fprintln(os.Stderr, gerr)
+			os.Exit(1)
+		}
+		return
+	}
+	if err != nil {
+		// json and sexp output also carry the error, and exit with its
+		// status once written
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if err != nil && !structuredFormat(format) {
+		if !c.Bool("all") || len(result.Commits) == 0 {
+			os.Exit(failureExitCode(err))
+		}
+		// the pages crawled before the failure are still shown
+		fmt.Fprintf(os.Stderr, tr("showing the %d commits of the first %s pages, continue with --resume\n"), len(result.Commits), result.TotalPages)
+	}
+	if name := strings.TrimPrefix(format, formatterPrefix); name != format {
+		if ferr := runFormatter(name, result.Commits); ferr != nil {
+			fmt.Fprintln(os.Stderr, ferr)
+			os.Exit(1)
+		}
+	} else if jq != nil {
+		if jerr := showResultWithJq(jq, jsonResult(result, url, page, err)); jerr != nil {
+			fmt.Fprintln(os.Stderr, jerr)
+			os.Exit(1)
+		}
+	} else if format == "proto" {
+		if _, perr := os.Stdout.Write(appendDelimited(nil, protoResult(result, url, page))); perr != nil {
+			fmt.Fprintln(os.Stderr, perr)
+		}
+	} else if enc, ok := newBinaryEncoder(format, os.Stdout); ok {
+		if eerr := enc.Encode(jsonResult(result, url, page, err)); eerr != nil {
+			fmt.Fprintln(os.Stderr, eerr)
+		}
+	} else if format == "json" {
+		showResultAsJson(result, url, page, err, c.Bool("pretty"))
+	} else if format == "sexp" {
+		showResultAsSexp(result, url, page, err)
+	} else if figure != "" && len(result.Commits) > 0 {
+		printFigure(figure, result.Commits[0])
+	} else {
+		showResult(result, url, terms, page)
+		if c.Bool("phrases") {
+			showPhrases(result.Commits, terms)
+		}
+		if c.Bool("interactive") && isTerminal(os.Stdout) {
+			promptActions(terms, page, result, c.Duration("cache-ttl"))
+		}
+	}
+	if err != nil {
+		os.Exit(failureExitCode(err))
+	}
+	if len(result.Commits) == 0 && c.Bool("fail-empty") {
+		os.Exit(exitNoResults)
+	}
 }
 
-func getTotalPages(doc *goquery.Document) string {
-	pages := doc.Find("ul.pagination li.next_page").Prev().Text()
-	if pages == "" {
-		pages = "1"
+func parsePage(givenPage string) int {
+	page := 1
+	if givenPage != "" {
+		if optPage, err := strconv.Atoi(givenPage); err == nil {
+			page = optPage
+		}
 	}
+	return page
+}
 
-	return pages
+func buildUrl(keyword string, page int) string {
+	return backend.URL(keyword, page)
+}
 
+func excludeCommits(commits []*commit, words []string) []*commit {
+	if len(words) == 0 {
+		return commits
+	}
+	filtered := []*commit{}
+	for _, c := range commits {
+		message := strings.ToLower(c.Message)
+		excluded := false
+		for _, word := range words {
+			if strings.Contains(message, strings.ToLower(word)) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
 }
 
 func maxRepoWidth(commits []*commit) int {
@@ -174,7 +707,7 @@ func maxMessageWidth(commits []*commit) int {
 func maxURLWidth(commits []*commit) int {
 	width := 0
 	for _, c := range commits {
-		count := utf8.RuneCountInString(c.CommitURL)
+		count := utf8.RuneCountInString(c.displayURL())
 		if count > width {
 			width = count
 		}
@@ -185,16 +718,20 @@ func maxURLWidth(commits []*commit) int {
 func showResult(result QueryResult, url, keyword string, page int) {
 	commits := result.Commits
 	if len(commits) == 0 {
-		fmt.Println("No Results Found.")
+		fmt.Println(tr("No Results Found."))
 		fmt.Printf("  url: %s\n\n", url)
 		return
 	}
-	fmt.Printf("Search Result : %s : %d/%s pages\n",
+	fmt.Printf(tr("Search Result : %s : %d/%s pages\n"),
 		result.ResultCount,
 		page,
 		result.TotalPages,
 	)
-	fmt.Printf("  url: %s\n\n", url)
+	fmt.Printf("  url: %s\n", url)
+	if result.Fallback != "" {
+		fmt.Printf(tr("  (%s failed, showing results from %s)\n"), backend.Name(), result.Fallback)
+	}
+	fmt.Println()
 
 	repoWidth := maxRepoWidth(commits)
 	repoFmt := fmt.Sprintf("%%-%ds", repoWidth)
@@ -204,33 +741,159 @@ func showResult(result QueryResult, url, keyword string, page int) {
 
 	msgWidth := maxMessageWidth(commits)
 
-	fmt.Fprintf(color.Output, " %s | %s | %s | message \n",
-		color.BlueString(repoFmt, "Repository"),
-		color.CyanString("%-7s", "sha1"),
+	numWidth := len(strconv.Itoa(len(commits)))
+	numFmt := fmt.Sprintf("%%%ds", numWidth)
+
+	columns := extraColumns(commits)
+	extraWidth := 0
+	extraHeader := ""
+	for _, col := range columns {
+		extraWidth += col.width + 3
+		extraHeader += fmt.Sprintf(" %-*s |", col.width, col.name)
+	}
+
+	fmt.Fprintf(color.Output, " %s | %s | %s |%s %s | %s \n",
+		fmt.Sprintf(numFmt, "#"),
+		theme.repo("%s", runewidth.FillRight(tr("Repository"), repoWidth)),
+		theme.sha1("%-7s", "sha1"),
+		extraHeader,
 		fmt.Sprintf(urlFmt, "url"),
+		tr("message"),
 	)
-	fmt.Println(strings.Repeat("-", repoWidth+msgWidth+urlWidth+18))
+	fmt.Println(strings.Repeat("-", numWidth+repoWidth+msgWidth+urlWidth+extraWidth+21))
 
-	for _, c := range commits {
-		fmt.Fprintf(color.Output, " %s | %7s | %s | %s\n",
-			color.BlueString(repoFmt, c.Repo),
-			color.CyanString(c.Sha1),
-			fmt.Sprintf(urlFmt, c.CommitURL),
+	for i, c := range commits {
+		extra := ""
+		for _, col := range columns {
+			extra += fmt.Sprintf(" %-*s |", col.width, col.value(c))
+		}
+		fmt.Fprintf(color.Output, " %s | %s | %7s |%s %s | %s\n",
+			fmt.Sprintf(numFmt, strconv.Itoa(i+1)),
+			theme.repo(repoFmt, c.Repo),
+			theme.sha1(c.Sha1),
+			extra,
+			fmt.Sprintf(urlFmt, c.displayURL()),
 			highlightWords(c.Message, keyword),
 		)
 	}
+
+	showBodies(commits)
+}
+
+type column struct {
+	name  string
+	width int
+	value func(*commit) string
+}
+
+// extraColumns returns the columns for enrichment data present on any of
+// the commits, sized to fit their values.
+func extraColumns(commits []*commit) []*column {
+	candidates := []*column{
+		{name: "source", value: func(c *commit) string { return c.Source }},
+		{name: "author", value: func(c *commit) string { return c.Author }},
+		{name: "date", value: func(c *commit) string {
+			if len(c.Date) >= 10 {
+				return c.Date[:10]
+			}
+			return c.Date
+		}},
+		{name: "stats", value: func(c *commit) string { return c.Stats.String() }},
+		{name: "stars", value: func(c *commit) string {
+			if c.Stars == 0 {
+				return ""
+			}
+			return strconv.Itoa(c.Stars)
+		}},
+		{name: "count", value: func(c *commit) string {
+			if c.ClusterSize < 2 {
+				return ""
+			}
+			return "x" + strconv.Itoa(c.ClusterSize)
+		}},
+		{name: "link", value: func(c *commit) string {
+			if c.Dead && c.ArchiveURL != "" {
+				return "archived"
+			}
+			if c.Dead {
+				return "dead"
+			}
+			return ""
+		}},
+	}
+
+	columns := []*column{}
+	for _, col := range candidates {
+		col.width = runewidth.StringWidth(col.name)
+		present := false
+		for _, c := range commits {
+			v := col.value(c)
+			if v != "" {
+				present = true
+			}
+			if w := runewidth.StringWidth(v); w > col.width {
+				col.width = w
+			}
+		}
+		if present {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// showBodies prints the full message bodies, if fetched, as footnotes keyed
+// by result number.
+func showBodies(commits []*commit) {
+	for i, c := range commits {
+		if c.Body == "" {
+			continue
+		}
+		fmt.Printf("\n [%d] %s\n", i+1, c.Message)
+		for _, line := range strings.Split(c.Body, "\n") {
+			fmt.Printf("     %s\n", line)
+		}
+	}
 }
 
-func showResultAsJson(result QueryResult, err error) {
+func protoResult(result QueryResult, url string, page int) *pbSearchResponse {
+	totalPages, _ := strconv.Atoi(result.TotalPages)
+	return &pbSearchResponse{
+		commits:     result.Commits,
+		resultCount: int32(parseResultCount(result.ResultCount)),
+		totalPages:  int32(totalPages),
+		page:        int32(page),
+		url:         url,
+	}
+}
+
+func showResultAsJson(result QueryResult, url string, page int, err error, pretty bool) {
+	var output interface{} = jsonResult(result, url, page, err)
 	enc := json.NewEncoder(os.Stdout)
-	if err != nil {
-		enc.Encode(JsonFormat{Commits: []*commit{}, Error: err.Error()})
-		return
+	if pretty {
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		output = sortedKeys(output)
 	}
-	err = enc.Encode(JsonFormat{Commits: result.Commits, Error: ""})
+	if jerr := enc.Encode(output); jerr != nil {
+		fmt.Fprintln(os.Stderr, jerr)
+	}
+}
+
+// sortedKeys returns v decoded into maps, which encoding/json writes with
+// their keys in order.
+func sortedKeys(v interface{}) interface{} {
+	data, err := json.Marshal(v)
 	if err != nil {
-		fmt.Print(err)
+		return v
 	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var sorted interface{}
+	if err := dec.Decode(&sorted); err != nil {
+		return v
+	}
+	return sorted
 }
 
 func highlightWords(message, keyword string) string {
@@ -238,9 +901,12 @@ func highlightWords(message, keyword string) string {
 	for _, word := range strings.Fields(keyword) {
 		words = append(words, regexp.QuoteMeta(word))
 	}
+	if len(words) == 0 {
+		return message
+	}
 
 	pattern := regexp.MustCompile(strings.Join(words, "|"))
 	return pattern.ReplaceAllStringFunc(message, func(s string) string {
-		return color.YellowString(s)
+		return theme.highlight(s)
 	})
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+const mcpProtocolVersion = "2025-06-18"
+
+var mcpCommand = cli.Command{
+	Name:  "mcp",
+	Usage: "serve the search_commit_messages tool to AI assistants over the Model Context Protocol on stdio",
+	Description: `Register it with an assistant as a stdio server, e.g.
+
+   {"mcpServers": {"gommit-m": {"command": "gommit-m", "args": ["mcp"]}}}`,
+	Flags: []cli.Flag{
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: time.Hour,
+			Usage: "reuse cached results fetched within this duration",
+		},
+	},
+	Action: func(c *cli.Context) {
+		stopInterrupts()
+		s := &mcpServer{ttl: c.Duration("cache-ttl"), out: json.NewEncoder(os.Stdout)}
+		if err := s.serve(os.Stdin); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// JSON-RPC 2.0 messages, one per line.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	// rpcServerError is a failure of the method itself.
+	rpcServerError = -32000
+)
+
+var searchCommitMessagesTool = map[string]interface{}{
+	"name":        "search_commit_messages",
+	"description": "Search real-world commit messages from GitHub projects containing a keyword. Use it to find how others word commit messages for a kind of change.",
+	"inputSchema": map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"keyword": map[string]interface{}{"type": "string", "description": "word or phrase to search for, e.g. \"refactor\""},
+			"page":    map[string]interface{}{"type": "integer", "description": "page of results, from 1", "minimum": 1},
+			"limit":   map[string]interface{}{"type": "integer", "description": "maximum number of commits to return", "minimum": 1},
+		},
+		"required": []string{"keyword"},
+	},
+}
+
+type mcpServer struct {
+	ttl time.Duration
+	out *json.Encoder
+}
+
+func (s *mcpServer) serve(in io.Reader) error {
+	return serveJSONRPC(in, s.out, "mcp", s.handle)
+}
+
+// serveJSONRPC answers the JSON-RPC requests read from in, one per line,
+// with what handle returns.
+func serveJSONRPC(in io.Reader, out *json.Encoder, name string, handle func(req rpcRequest) (interface{}, *rpcError)) error {
+	reply := func(id json.RawMessage, result interface{}, rerr *rpcError) error {
+		if result == nil && rerr == nil {
+			result = struct{}{}
+		}
+		return out.Encode(rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rerr})
+	}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		req := rpcRequest{}
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			if err := reply(json.RawMessage("null"), nil, &rpcError{rpcParseError, err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		debugf("%s %s", name, req.Method)
+		result, rerr := handle(req)
+		// notifications have no id and get no response
+		if req.ID == nil {
+			continue
+		}
+		if err := reply(req.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *mcpServer) handle(req rpcRequest) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		params := struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}{}
+		json.Unmarshal(req.Params, &params)
+		return map[string]interface{}{
+			"protocolVersion": firstNonEmpty(params.ProtocolVersion, mcpProtocolVersion),
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "gommit-m", "version": version},
+		}, nil
+	case "ping", "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": []interface{}{searchCommitMessagesTool}}, nil
+	case "tools/call":
+		return s.callTool(req.Params)
+	}
+	return nil, &rpcError{rpcMethodNotFound, "method not found: " + req.Method}
+}
+
+func (s *mcpServer) callTool(raw json.RawMessage) (interface{}, *rpcError) {
+	params := struct {
+		Name      string `json:"name"`
+		Arguments struct {
+			Keyword string `json:"keyword"`
+			Page    int    `json:"page"`
+			Limit   int    `json:"limit"`
+		} `json:"arguments"`
+	}{}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &rpcError{rpcInvalidParams, err.Error()}
+	}
+	if params.Name != "search_commit_messages" {
+		return nil, &rpcError{rpcInvalidParams, "unknown tool: " + params.Name}
+	}
+	args := params.Arguments
+	if args.Keyword == "" {
+		return nil, &rpcError{rpcInvalidParams, "keyword is required"}
+	}
+	if args.Page < 1 {
+		args.Page = 1
+	}
+
+	// failures of the search are reported to the model, not as protocol errors
+	result, err := cachedCrawl(args.Keyword, args.Page, s.ttl)
+	if err != nil {
+		return toolResult(err.Error(), nil, true), nil
+	}
+	if args.Limit > 0 && len(result.Commits) > args.Limit {
+		result.Commits = result.Commits[:args.Limit]
+	}
+	lines := []string{fmt.Sprintf("%s results for %q (page %d of %s):", result.ResultCount, args.Keyword, args.Page, result.TotalPages)}
+	for _, c := range result.Commits {
+		lines = append(lines, fmt.Sprintf("- %s (%s %s)", c.Message, c.Repo, c.displayURL()))
+	}
+	return toolResult(strings.Join(lines, "\n"), result, false), nil
+}
+
+func toolResult(text string, structured interface{}, isError bool) map[string]interface{} {
+	res := map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+	if structured != nil {
+		res["structuredContent"] = structured
+	}
+	return res
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics exposed by serve at /metrics. The cache hit ratio is
+// rate(gommitm_cache_lookups_total{result="hit"}) over all lookups.
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gommitm_http_requests_total",
+		Help: "HTTP requests served, by handler, method and status code.",
+	}, []string{"handler", "method", "code"})
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gommitm_upstream_request_duration_seconds",
+		Help:    "Duration of searches of the backend, by backend and outcome.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"backend", "outcome"})
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gommitm_cache_lookups_total",
+		Help: "Lookups of the result cache, by result (hit or miss).",
+	}, []string{"result"})
+	parseFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gommitm_parse_failures_total",
+		Help: "Backend responses that could not be parsed, by backend.",
+	}, []string{"backend"})
+	apiKeyRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gommitm_api_key_requests_total",
+		Help: "Requests counted against the quota of each API key, by key name.",
+	}, []string{"key"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequests, upstreamDuration, cacheLookups, parseFailures, apiKeyRequests)
+}
+
+// instrument counts the requests served by h under the handler name.
+func instrument(name string, h http.Handler) http.Handler {
+	return promhttp.InstrumentHandlerCounter(httpRequests.MustCurryWith(prometheus.Labels{"handler": name}), h)
+}
+
+// observeSearch records a search of the backend that started at start.
+func observeSearch(start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+		if failureExitCode(err) == exitParse {
+			parseFailures.WithLabelValues(backend.Name()).Inc()
+		}
+	}
+	upstreamDuration.WithLabelValues(backend.Name(), outcome).Observe(time.Since(start).Seconds())
+}
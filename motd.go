@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+const motdFile = "motd.json"
+
+// motdPick is the message of the day of a keyword.
+type motdPick struct {
+	Date   string  `json:"date"`
+	Commit *commit `json:"commit"`
+}
+
+var motdCommand = cli.Command{
+	Name:      "motd",
+	Usage:     "print the commit message of the day, picked once a day per keyword",
+	ArgsUsage: "[keyword]",
+	Flags:     figureFlags,
+	Action: func(c *cli.Context) {
+		figure := selectedFigure(c)
+		keyword := strings.Join(c.Args(), " ")
+		picks := map[string]*motdPick{}
+		if err := loadJSON(dataPath(motdFile), &picks); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		today := time.Now().Format("2006-01-02")
+		pick := picks[keyword]
+		if pick == nil || pick.Date != today {
+			rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+			search := keyword
+			if search == "" {
+				search = fortuneKeywords[rnd.Intn(len(fortuneKeywords))]
+			}
+			found, err := randomCommit(rnd, search, 24*time.Hour)
+			switch {
+			case err == nil && found != nil:
+				pick = &motdPick{Date: today, Commit: found}
+				picks[keyword] = pick
+				if err := saveJSON(dataPath(motdFile), picks); err != nil {
+					logger.Warn("failed to save the message of the day", "error", err)
+				}
+			case pick == nil:
+				// nothing to fall back on
+				if err == nil {
+					fmt.Println(tr("No Results Found."))
+					os.Exit(1)
+				}
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(failureExitCode(err))
+			}
+			// otherwise the previous pick is shown again while offline
+		}
+
+		printFortune(pick.Commit, figure)
+	},
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+const sessionsDir = "sessions"
+
+func namedSessionPath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid session name: %q", name)
+	}
+	return dataPath(sessionsDir, name+".json"), nil
+}
+
+// saveNamedSession keeps the result under name, with the search flags
+// other than --save-session itself.
+func saveNamedSession(name string, flags []string, keyword string, page int, result QueryResult) error {
+	path, err := namedSessionPath(name)
+	if err != nil {
+		return err
+	}
+	s := newSession(keyword, page, result)
+	s.Name = name
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "--save-session=") {
+			s.Flags = append(s.Flags, flag)
+		}
+	}
+	return saveJSON(path, s)
+}
+
+func loadNamedSession(name string) (*session, error) {
+	path, err := namedSessionPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("no such session: %s", name)
+	}
+	s := &session{}
+	if err := loadJSON(path, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func namedSessions() ([]string, error) {
+	files, err := ioutil.ReadDir(dataPath(sessionsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(f.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func completeNamedSessions(c *cli.Context) {
+	names, _ := namedSessions()
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
+
+var sessionCommand = cli.Command{
+	Name:  "session",
+	Usage: "revisit results saved with --save-session",
+	Subcommands: []cli.Command{
+		{
+			Name:  "list",
+			Usage: "list the saved sessions",
+			Action: func(c *cli.Context) {
+				names, err := namedSessions()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				for _, name := range names {
+					s, err := loadNamedSession(name)
+					if err != nil {
+						logger.Warn("failed to read session", "name", name, "error", err)
+						continue
+					}
+					fmt.Printf("%s\t%s\t%s\t%d\t%s\n", name, s.Saved.Format("2006-01-02 15:04"), s.Keyword, s.Page, strings.Join(s.Flags, " "))
+				}
+			},
+		},
+		{
+			Name:         "load",
+			Usage:        "show a saved session and make it the last search, for open, copy and patch",
+			ArgsUsage:    "name",
+			BashComplete: completeNamedSessions,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "print the session as json",
+				},
+			},
+			Action: func(c *cli.Context) {
+				name := c.Args().First()
+				if name == "" {
+					cli.ShowCommandHelp(c, "load")
+					os.Exit(exitUsage)
+				}
+				s, err := loadNamedSession(name)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				if err := saveJSON(dataPath(sessionFile), s); err != nil {
+					logger.Warn("failed to save session", "error", err)
+				}
+				if c.Bool("json") {
+					printJSON(s)
+					return
+				}
+				fmt.Printf("%s: %s", name, s.Saved.Format("2006-01-02 15:04"))
+				if len(s.Flags) > 0 {
+					fmt.Printf(" (%s)", strings.Join(s.Flags, " "))
+				}
+				fmt.Print("\n\n")
+				showResult(QueryResult{Commits: s.Commits, ResultCount: s.ResultCount, TotalPages: s.TotalPages}, s.URL, s.Keyword, s.Page)
+			},
+		},
+		{
+			Name:         "delete",
+			Usage:        "delete a saved session",
+			ArgsUsage:    "name",
+			BashComplete: completeNamedSessions,
+			Action: func(c *cli.Context) {
+				path, err := namedSessionPath(c.Args().First())
+				if err == nil {
+					err = os.Remove(path)
+				}
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			},
+		},
+	},
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// desktopNotify shows a native notification using the tools each platform
+// ships with: osascript on macOS, notify-send on Linux and a PowerShell
+// balloon tip on Windows.
+func desktopNotify(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(body), strconv.Quote(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms;`+
+			`$n = New-Object System.Windows.Forms.NotifyIcon;`+
+			`$n.Icon = [System.Drawing.SystemIcons]::Information;`+
+			`$n.Visible = $true;`+
+			`$n.ShowBalloonTip(10000, '%s', '%s', 'Info');`+
+			`Start-Sleep -Seconds 10; $n.Dispose()`,
+			powershellEscape(title), powershellEscape(body))
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", script)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=gommit-m", title, body)
+	}
+	return cmd.Run()
+}
+
+func powershellEscape(s string) string {
+	return strings.Replace(s, "'", "''", -1)
+}
+
+func notifyNewCommits(keyword string, commits []*commit) error {
+	if len(commits) == 0 {
+		return nil
+	}
+	title := fmt.Sprintf("gommit-m: %d new commits for %q", len(commits), keyword)
+	if len(commits) == 1 {
+		title = fmt.Sprintf("gommit-m: new commit for %q", keyword)
+	}
+	first := commits[0]
+	return desktopNotify(title, fmt.Sprintf("%s\n%s", first.Message, first.Repo))
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+type object map[string]interface{}
+
+// schemaNames are the component names of the types in the responses.
+var schemaNames = map[reflect.Type]string{
+	reflect.TypeOf(commit{}):        "Commit",
+	reflect.TypeOf(diffStats{}):     "DiffStats",
+	reflect.TypeOf(JsonFormat{}):    "SearchResult",
+	reflect.TypeOf(batchRequest{}):  "BatchRequest",
+	reflect.TypeOf(batchQuery{}):    "BatchQuery",
+	reflect.TypeOf(batchResponse{}): "BatchResponse",
+	reflect.TypeOf(batchResult{}):   "BatchResult",
+}
+
+const componentRefs = "#/components/schemas/"
+
+// schemaOf returns the JSON schema of values of t as encoding/json
+// writes them, referring to the named components.
+func schemaOf(t reflect.Type) object {
+	return typeSchema(t, componentRefs)
+}
+
+// typeSchema is schemaOf referring to the named types under refs.
+func typeSchema(t reflect.Type, refs string) object {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if name, ok := schemaNames[t]; ok {
+		return object{"$ref": refs + name}
+	}
+	return inlineSchema(t, refs)
+}
+
+func inlineSchema(t reflect.Type, refs string) object {
+	switch t.Kind() {
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return object{"type": "integer"}
+	case reflect.Slice:
+		return object{"type": "array", "items": typeSchema(t.Elem(), refs)}
+	case reflect.Struct:
+		properties := object{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, opts := f.Name, ""
+			if tag := f.Tag.Get("json"); tag != "" {
+				parts := strings.SplitN(tag, ",", 2)
+				if parts[0] == "-" {
+					continue
+				}
+				if parts[0] != "" {
+					name = parts[0]
+				}
+				if len(parts) > 1 {
+					opts = parts[1]
+				}
+			}
+			properties[name] = typeSchema(f.Type, refs)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return object{"type": "object", "properties": properties, "required": required}
+	}
+	return object{}
+}
+
+func jsonContent(schema object) object {
+	return object{"application/json": object{"schema": schema}}
+}
+
+func errorResponse(description string) object {
+	return object{"description": description, "content": jsonContent(object{"$ref": "#/components/schemas/Error"})}
+}
+
+// openAPIDocument describes the endpoints of serve as OpenAPI 3.
+func openAPIDocument(serverURL string) object {
+	schemas := object{
+		"Error": object{
+			"type":       "object",
+			"properties": object{"error": object{"type": "string"}},
+			"required":   []string{"error"},
+		},
+	}
+	for t, name := range schemaNames {
+		schemas[name] = inlineSchema(t, componentRefs)
+	}
+	keyword := object{"name": "keyword", "in": "query", "required": true, "description": "word or phrase to search for", "schema": object{"type": "string"}}
+	limited := errorResponse("rate limit of the client or daily quota of the api key exceeded")
+	unauthorized := errorResponse("a valid api key is required (when the server has api keys configured)")
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "gommit-m",
+			"description": "Search commit messages of GitHub projects.",
+			"version":     version,
+		},
+		"servers": []object{{"url": serverURL}},
+		"paths": object{
+			"/search": object{"get": object{
+				"operationId": "search",
+				"summary":     "Search one page of results",
+				"parameters": []object{keyword, {
+					"name": "page", "in": "query", "description": "page of results, from 1",
+					"schema": object{"type": "integer", "minimum": 1, "default": 1},
+				}},
+				"responses": object{
+					"200": object{"description": "results of the page", "content": jsonContent(schemaOf(reflect.TypeOf(JsonFormat{})))},
+					"400": errorResponse("missing keyword"),
+					"401": unauthorized,
+					"429": limited,
+					"500": errorResponse("the backend response could not be parsed"),
+					"502": errorResponse("the backend could not be searched"),
+				},
+			}},
+			"/search/batch": object{"post": object{
+				"operationId": "searchBatch",
+				"summary":     fmt.Sprintf("Search up to %d keywords at once", maxBatch),
+				"description": "Each query gets a result or an error; a failed query does not fail the response.",
+				"requestBody": object{"required": true, "content": jsonContent(schemaOf(reflect.TypeOf(batchRequest{})))},
+				"responses": object{
+					"200": object{"description": "results in the order of the queries, keywords last", "content": jsonContent(schemaOf(reflect.TypeOf(batchResponse{})))},
+					"400": errorResponse("invalid body, no queries or too many"),
+					"401": unauthorized,
+					"429": limited,
+				},
+			}},
+			"/graphql": object{"post": object{
+				"operationId": "graphql",
+				"summary":     "GraphQL queries of search results, with the GitHub enrichment fields resolved on demand",
+				"requestBody": object{"required": true, "content": jsonContent(object{
+					"type": "object",
+					"properties": object{
+						"query":         object{"type": "string"},
+						"variables":     object{"type": "object"},
+						"operationName": object{"type": "string"},
+					},
+					"required": []string{"query"},
+				})},
+				"responses": object{
+					"200": object{"description": "GraphQL response with data and errors", "content": jsonContent(object{"type": "object"})},
+					"400": errorResponse("invalid body or no query"),
+					"401": unauthorized,
+					"429": limited,
+				},
+			}},
+			"/stream": object{"get": object{
+				"operationId": "stream",
+				"summary":     "Stream the results of several pages as Server-Sent Events",
+				"description": "Sends a commit event with a Commit for each commit, a page event after each page, then done, or error when a page fails.",
+				"parameters": []object{keyword, {
+					"name": "pages", "in": "query", "description": `"all", a number of pages from the first, or a range "2-10"`,
+					"schema": object{"type": "string", "default": "all"},
+				}},
+				"responses": object{
+					"200": object{"description": "event stream", "content": object{"text/event-stream": object{"schema": object{"type": "string"}}}},
+					"400": errorResponse("missing keyword or invalid pages"),
+					"401": unauthorized,
+					"429": limited,
+				},
+			}},
+			"/feed/{name}": object{"get": object{
+				"operationId": "feed",
+				"summary":     "Atom feed of the results of a saved search",
+				"parameters": []object{{
+					"name": "name", "in": "path", "required": true, "description": "name of the saved search",
+					"schema": object{"type": "string"},
+				}},
+				"responses": object{
+					"200": object{"description": "Atom feed", "content": object{"application/atom+xml": object{"schema": object{"type": "string"}}}},
+					"401": unauthorized,
+					"404": errorResponse("no saved search of the name"),
+					"429": limited,
+					"502": errorResponse("the backend could not be searched"),
+				},
+			}},
+			"/usage": object{"get": object{
+				"operationId": "usage",
+				"summary":     "Usage of the api key of the request",
+				"responses": object{
+					"200": object{"description": "requests made with the key", "content": jsonContent(object{
+						"type": "object",
+						"properties": object{
+							"name":        object{"type": "string"},
+							"daily_quota": object{"type": "integer", "description": "0 is unlimited"},
+							"today":       object{"type": "integer"},
+							"total":       object{"type": "integer"},
+							"last_used":   object{"type": "string", "format": "date-time"},
+						},
+					})},
+					"401": unauthorized,
+					"404": errorResponse("no api keys configured"),
+				},
+			}},
+			"/healthz": object{"get": object{
+				"operationId": "healthz",
+				"summary":     "Liveness of the server",
+				"responses":   object{"200": object{"description": "the server is running"}},
+			}},
+			"/readyz": object{"get": object{
+				"operationId": "readyz",
+				"summary":     "Readiness of the server: not shutting down and the backend reachable",
+				"responses": object{
+					"200": object{"description": "ready to serve searches"},
+					"503": errorResponse("shutting down or the backend unreachable"),
+				},
+			}},
+		},
+		"security": []object{{}, {"apiKey": []string{}}, {"bearer": []string{}}},
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"apiKey": object{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": object{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func (s *server) openAPI(w http.ResponseWriter, r *http.Request) {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	writeJSON(w, http.StatusOK, openAPIDocument(scheme+"://"+r.Host))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"github.com/yuroyoro/gommit-m/parser"
+)
+
+// strictParsing makes a search page not laid out as expected an error,
+// instead of a warning, with --strict.
+var strictParsing bool
+
+// parseSearchPage parses a commit-m search page read from r.
+func parseSearchPage(url string, r io.Reader) (QueryResult, error) {
+	parsed, err := parser.Parse(r)
+	result := QueryResult{Commits: make([]*commit, len(parsed.Commits)), ResultCount: parsed.ResultCount, TotalPages: parsed.TotalPages}
+	for i, c := range parsed.Commits {
+		result.Commits[i] = &commit{Message: c.Message, Repo: c.Repo, RepoURL: c.RepoURL, Sha1: c.Sha1, CommitURL: c.CommitURL}
+	}
+	if err == parser.ErrNotSearchPage {
+		return result, &parseError{url: url}
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, checkAnomalies(url, parsed.Anomalies)
+}
+
+// checkAnomalies warns about the anomalies of the page, or with --strict
+// makes them an error.
+func checkAnomalies(url string, anomalies []string) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+	if strictParsing {
+		return &parseError{url: url, reason: strings.Join(anomalies, ", ")}
+	}
+	for _, a := range anomalies {
+		logger.Warn(tr("unexpected page structure"), "url", url, "anomaly", a)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package parser extracts the commits of commit-m search result pages.
+//
+// The markup of commit-m is not an API: when the result table is not
+// where it used to be, the commits are looked for in any table and then
+// in any link to a commit, and what looked unexpected is reported in the
+// Anomalies of the result.
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrNotSearchPage is returned by Parse for a page with neither commits
+// nor a result count.
+var ErrNotSearchPage = errors.New("no search results found")
+
+// Commit is a commit listed on a search page.
+type Commit struct {
+	Message   string
+	Repo      string
+	RepoURL   string
+	Sha1      string
+	CommitURL string
+}
+
+// QueryResult is what a search page lists: the commits, the result count
+// ("42 results") and the number of pages.
+type QueryResult struct {
+	Commits     []*Commit
+	ResultCount string
+	TotalPages  string
+	// Anomalies describe what did not look as expected.
+	Anomalies []string
+}
+
+// Parse parses a search page. It only fails when the page cannot be read
+// or does not look like a search page at all.
+func Parse(r io.Reader) (QueryResult, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return QueryResult{Commits: []*Commit{}}, err
+	}
+	result := ParseDocument(doc)
+	if len(result.Commits) == 0 && result.ResultCount == "" {
+		return result, ErrNotSearchPage
+	}
+	return result, nil
+}
+
+// zeroWidth are the invisible characters dropped from cells. They break
+// the alignment of the table and the matching of the messages.
+var zeroWidth = strings.NewReplacer("\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "")
+
+// CleanText normalizes the text of a cell: entities left encoded (the
+// pages sometimes escape twice) are decoded, zero-width characters
+// dropped and any run of whitespace, newlines and non-breaking spaces
+// included, collapsed to a single space.
+func CleanText(s string) string {
+	s = zeroWidth.Replace(html.UnescapeString(s))
+	return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+}
+
+// Count is the number of a result count, 0 when there is none.
+func Count(resultCount string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(resultCount, " results"))
+	return n
+}
+
+// rowStrategy is a way of finding the commits of a search page. The first
+// strategy finding any is used; the others are fallbacks for when the
+// markup of commit-m changes.
+type rowStrategy struct {
+	name  string
+	parse func(doc *goquery.Document) (commits []*Commit, skipped int)
+}
+
+var rowStrategies = []rowStrategy{
+	{"table.table rows", func(doc *goquery.Document) ([]*Commit, int) {
+		return parseTableRows(doc.Find("table.table tr"))
+	}},
+	{"table rows", func(doc *goquery.Document) ([]*Commit, int) {
+		return parseTableRows(doc.Find("table tr"))
+	}},
+	{"commit links", parseCommitLinks},
+}
+
+// ParseDocument extracts the results of a parsed search page, trying each
+// row strategy in turn.
+func ParseDocument(doc *goquery.Document) QueryResult {
+	result := QueryResult{Commits: []*Commit{}}
+	for i, strategy := range rowStrategies {
+		commits, skipped := strategy.parse(doc)
+		if skipped > 0 {
+			result.Anomalies = append(result.Anomalies, fmt.Sprintf("%d rows without a sha1 with %s", skipped, strategy.name))
+		}
+		if len(commits) > 0 {
+			if i > 0 {
+				result.Anomalies = append(result.Anomalies, fmt.Sprintf("no commits in %s, found %d with %s", rowStrategies[0].name, len(commits), strategy.name))
+			}
+			result.Commits = commits
+			break
+		}
+	}
+
+	result.ResultCount = resultCount(doc)
+	pages, paginated := totalPages(doc)
+	result.TotalPages = pages
+
+	count := Count(result.ResultCount)
+	switch {
+	case result.ResultCount == "" && len(result.Commits) > 0:
+		result.Anomalies = append(result.Anomalies, "no result count")
+	case count > 0 && len(result.Commits) == 0:
+		result.Anomalies = append(result.Anomalies, fmt.Sprintf("%d results but no commits parsed", count))
+	case count > len(result.Commits) && !paginated:
+		result.Anomalies = append(result.Anomalies, fmt.Sprintf("%d results, %d commits parsed and no pagination", count, len(result.Commits)))
+	case count > 0 && count < len(result.Commits):
+		result.Anomalies = append(result.Anomalies, fmt.Sprintf("%d results but %d commits parsed", count, len(result.Commits)))
+	}
+	return result
+}
+
+// parseTableRows extracts a commit from each row of message, repository
+// and sha1 cells. skipped counts the rows with cells but without a sha1.
+func parseTableRows(rows *goquery.Selection) (commits []*Commit, skipped int) {
+	commits = []*Commit{}
+	rows.Each(func(_ int, line *goquery.Selection) {
+		cellsTxt := [3]string{"", "", ""}
+		hrefIndex := 0
+		cellsHref := [2]string{"", ""}
+		cells := line.Find("td")
+		cells.Each(func(i int, s *goquery.Selection) {
+			if i >= len(cellsTxt) {
+				return
+			}
+			cellsTxt[i] = CleanText(s.Text())
+			s.Find("a").Each(func(_ int, s *goquery.Selection) {
+				href, _ := s.Attr("href")
+				if href != "" && hrefIndex < len(cellsHref) {
+					cellsHref[hrefIndex] = strings.TrimSpace(href)
+					hrefIndex += 1
+				}
+			})
+		})
+		commit := Commit{
+			Message:   cellsTxt[0],
+			Repo:      cellsTxt[1],
+			RepoURL:   cellsHref[0],
+			Sha1:      cellsTxt[2],
+			CommitURL: cellsHref[1],
+		}
+		if commit.Sha1 != "" {
+			commits = append(commits, &commit)
+		} else if cells.Length() >= len(cellsTxt) {
+			skipped++
+		}
+	})
+	return commits, skipped
+}
+
+// parseCommitLinks extracts a commit from each link to a commit, reading
+// the repository and sha1 from its url and the message from the first
+// cell of the row, or item, holding it.
+func parseCommitLinks(doc *goquery.Document) ([]*Commit, int) {
+	commits := []*Commit{}
+	skipped := 0
+	doc.Find(`a[href*="/commit/"]`).Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		u, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			skipped++
+			return
+		}
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) < 4 || parts[len(parts)-2] != "commit" {
+			skipped++
+			return
+		}
+		message := CleanText(a.Closest("tr, li").Children().First().Text())
+		if message == "" {
+			message = CleanText(a.AttrOr("title", ""))
+		}
+		repoURL := *u
+		repoURL.Path = "/" + strings.Join(parts[:len(parts)-2], "/")
+		commits = append(commits, &Commit{
+			Message:   message,
+			Repo:      strings.Join(parts[len(parts)-4:len(parts)-2], "/"),
+			RepoURL:   repoURL.String(),
+			Sha1:      parts[len(parts)-1],
+			CommitURL: u.String(),
+		})
+	})
+	return commits, skipped
+}
+
+var resultCountPattern = regexp.MustCompile(`(\d+) results`)
+
+// resultCount finds the "42 results" text directly in a container.
+func resultCount(doc *goquery.Document) string {
+	results := ""
+	doc.Find("div.container").Each(func(_ int, s *goquery.Selection) {
+		for c := s.Nodes[0].FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == 1 {
+				if m := resultCountPattern.FindString(c.Data); m != "" {
+					results = m
+					break
+				}
+			}
+		}
+	})
+	return results
+}
+
+// totalPages finds the number of the last page in the pagination, and
+// whether a pagination was found at all.
+func totalPages(doc *goquery.Document) (string, bool) {
+	if pages := CleanText(doc.Find("ul.pagination li.next_page").Prev().Text()); pages != "" {
+		return pages, true
+	}
+	pagination := doc.Find(".pagination, nav[aria-label*=agination]")
+	last := 0
+	pagination.Find("a, li, span").Each(func(_ int, s *goquery.Selection) {
+		if n, err := strconv.Atoi(CleanText(s.Text())); err == nil && n > last {
+			last = n
+		}
+	})
+	if last > 0 {
+		return strconv.Itoa(last), true
+	}
+	return "1", pagination.Length() > 0
+}
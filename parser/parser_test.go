@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestCleanText(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"Fix typo", "Fix typo"},
+		{"  Fix\n  typo\t\t", "Fix typo"},
+		{"Tom &amp; Jerry", "Tom & Jerry"},
+		{"a &lt;b&gt; c", "a <b> c"},
+		{"no\u00a0break\u00a0spaces", "no break spaces"},
+		{"zero\u200bwidth\ufeff", "zerowidth"},
+		{"first line\r\nsecond line", "first line second line"},
+		{"日本語 の\u3000メッセージ", "日本語 の メッセージ"},
+		{"", ""},
+	} {
+		if got := CleanText(tt.in); got != tt.want {
+			t.Errorf("CleanText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+const searchPage = `<html><body>
+<div class="container">
+<table class="table">
+<tr><th>message</th><th>repository</th><th>sha1</th></tr>
+<tr>
+  <td>Fix &amp;amp; escape
+      in  templates&nbsp;</td>
+  <td><a href="https://github.com/octo/cat">octo/cat</a></td>
+  <td><a href=" https://github.com/octo/cat/commit/0123456 ">0123456</a></td>
+</tr>
+<tr>
+  <td>zero&#8203;width</td>
+  <td><a href="https://github.com/octo/dog">octo&#8203;/dog</a></td>
+  <td><a href="https://github.com/octo/dog/commit/89abcde">89abcde</a></td>
+</tr>
+<tr><td>a row without a sha1</td><td></td><td></td></tr>
+</table>
+</div>
+</body></html>`
+
+func TestParseTableRows(t *testing.T) {
+	result, err := Parse(strings.NewReader(searchPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commits := result.Commits
+	want := []Commit{
+		{
+			Message:   "Fix & escape in templates",
+			Repo:      "octo/cat",
+			RepoURL:   "https://github.com/octo/cat",
+			Sha1:      "0123456",
+			CommitURL: "https://github.com/octo/cat/commit/0123456",
+		},
+		{
+			Message:   "zerowidth",
+			Repo:      "octo/dog",
+			RepoURL:   "https://github.com/octo/dog",
+			Sha1:      "89abcde",
+			CommitURL: "https://github.com/octo/dog/commit/89abcde",
+		},
+	}
+	if len(commits) != len(want) {
+		t.Fatalf("parsed %d commits, want %d", len(commits), len(want))
+	}
+	for i, c := range commits {
+		if *c != want[i] {
+			t.Errorf("commit %d = %+v, want %+v", i, *c, want[i])
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		page      string
+		commits   int
+		pages     string
+		anomalies int
+	}{
+		{
+			name: "expected markup",
+			page: `<div class="container">2 results<table class="table">
+				<tr><td>one</td><td><a href="https://github.com/a/b">a/b</a></td><td><a href="https://github.com/a/b/commit/1111111">1111111</a></td></tr>
+				<tr><td>two</td><td><a href="https://github.com/a/b">a/b</a></td><td><a href="https://github.com/a/b/commit/2222222">2222222</a></td></tr>
+				</table></div>`,
+			commits: 2, pages: "1",
+		},
+		{
+			name: "table without its class",
+			page: `<div class="container">1 results<table>
+				<tr><td>one</td><td><a href="https://github.com/a/b">a/b</a></td><td><a href="https://github.com/a/b/commit/1111111">1111111</a></td></tr>
+				</table></div>`,
+			commits: 1, pages: "1", anomalies: 1,
+		},
+		{
+			name: "list of commit links",
+			page: `<div class="container">40 results<ul>
+				<li><span>one</span> <a href="https://github.com/a/b/commit/1111111">1111111</a></li>
+				<li><span>two</span> <a href="https://github.com/c/d/commit/2222222">2222222</a></li>
+				</ul><nav class="pagination"><a href="?page=1">1</a><a href="?page=2">2</a><a rel="next">Next</a></nav></div>`,
+			commits: 2, pages: "2", anomalies: 1,
+		},
+		{
+			name: "results without pagination",
+			page: `<div class="container">40 results<table class="table">
+				<tr><td>one</td><td><a href="https://github.com/a/b">a/b</a></td><td><a href="https://github.com/a/b/commit/1111111">1111111</a></td></tr>
+				</table></div>`,
+			commits: 1, pages: "1", anomalies: 1,
+		},
+	} {
+		result, err := Parse(strings.NewReader(tt.page))
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if len(result.Commits) != tt.commits || result.TotalPages != tt.pages || len(result.Anomalies) != tt.anomalies {
+			t.Errorf("%s: %d commits, %s pages, anomalies %q; want %d commits, %s pages, %d anomalies",
+				tt.name, len(result.Commits), result.TotalPages, result.Anomalies, tt.commits, tt.pages, tt.anomalies)
+		}
+	}
+}
+
+func TestParseCommitLinks(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(
+		`<ul><li><span>Fix &amp;amp; thing</span> <a href="https://github.com/octo/cat/commit/0123456">0123456</a></li></ul>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commits, _ := parseCommitLinks(doc)
+	want := Commit{
+		Message:   "Fix & thing",
+		Repo:      "octo/cat",
+		RepoURL:   "https://github.com/octo/cat",
+		Sha1:      "0123456",
+		CommitURL: "https://github.com/octo/cat/commit/0123456",
+	}
+	if len(commits) != 1 || *commits[0] != want {
+		t.Fatalf("parsed %+v, want %+v", commits, want)
+	}
+}
+
+func TestParseNotSearchPage(t *testing.T) {
+	for _, page := range []string{"", "<html><body><p>maintenance</p></body></html>", "<table><tr><td>"} {
+		if _, err := Parse(strings.NewReader(page)); err != ErrNotSearchPage {
+			t.Errorf("Parse(%q) = %v, want ErrNotSearchPage", page, err)
+		}
+	}
+}
+
+// FuzzParse checks that no page makes Parse panic or return commits
+// without a sha1. Run it with go test -fuzz=FuzzParse ./parser.
+func FuzzParse(f *testing.F) {
+	f.Add(searchPage)
+	f.Add(`<ul><li><span>one</span> <a href="https://github.com/a/b/commit/1111111">1111111</a></li></ul>`)
+	f.Add(`<a href="%zz/commit/">x</a><a href="/commit/">y</a><a href="//commit/a/b">z</a>`)
+	f.Add(`<div class="container">9 results<ul class="pagination"><li class="next_page"></li></ul></div>`)
+	f.Add(`<table class="table"><tr><td><td><td><tr><td>a<td>b<td>c<td>d</table>`)
+	f.Fuzz(func(t *testing.T, page string) {
+		result, _ := Parse(strings.NewReader(page))
+		if result.Commits == nil {
+			t.Fatal("nil commits")
+		}
+		for _, c := range result.Commits {
+			if c.Sha1 == "" {
+				t.Fatalf("commit without a sha1: %+v", c)
+			}
+		}
+	})
+}
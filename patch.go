@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/codegangsta/cli"
+)
+
+var patchCommand = cli.Command{
+	Name:      "patch",
+	Usage:     "download the .patch of the numbered commit of the last search",
+	ArgsUsage: "number",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "write the patch to the file instead of stdout",
+		},
+	},
+	Action: func(c *cli.Context) {
+		n, err := strconv.Atoi(c.Args().First())
+		if err != nil {
+			cli.ShowCommandHelp(c, "patch")
+			os.Exit(1)
+		}
+		commit, err := lastCommit(n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		var out io.Writer = os.Stdout
+		if path := c.String("output"); path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := downloadPatch(commit, out); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func downloadPatch(c *commit, w io.Writer) error {
+	if c.CommitURL == "" {
+		return fmt.Errorf("commit %s has no url", c.Sha1)
+	}
+	req, err := http.NewRequest("GET", c.CommitURL+".patch", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	res, err := http.DefaultClient.Do(req.WithContext(runContext))
+	if err != nil {
+		return err
+	}
+	defer closeBody(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s.patch: %s", c.CommitURL, res.Status)
+	}
+	_, err = io.Copy(w, res.Body)
+	return err
+}
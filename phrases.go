@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxPhrases is the number of phrases printed by --phrases.
+const maxPhrases = 5
+
+// keywordPhrases counts the messages using each phrase of one or two
+// more words than the keyword, around it. Phrases found in a single
+// message are left out.
+func keywordPhrases(commits []*commit, keyword string) []termCount {
+	kw := tokenize(keyword)
+	if len(kw) == 0 {
+		return nil
+	}
+	counts := map[string]int{}
+	for _, c := range commits {
+		tokens := tokenize(firstLine(c.Message))
+		seen := map[string]bool{}
+		for i := 0; i+len(kw) <= len(tokens); i++ {
+			if strings.Join(tokens[i:i+len(kw)], " ") != strings.Join(kw, " ") {
+				continue
+			}
+			for extra := 1; extra <= 2; extra++ {
+				for before := 0; before <= extra; before++ {
+					from, to := i-before, i+len(kw)+extra-before
+					if from < 0 || to > len(tokens) {
+						continue
+					}
+					if phrase := strings.Join(tokens[from:to], " "); !seen[phrase] {
+						seen[phrase] = true
+						counts[phrase]++
+					}
+				}
+			}
+		}
+	}
+	phrases := []termCount{}
+	for _, t := range sortedTerms(counts) {
+		if t.Messages > 1 {
+			phrases = append(phrases, t)
+		}
+	}
+	return phrases
+}
+
+func showPhrases(commits []*commit, keyword string) {
+	phrases := keywordPhrases(commits, keyword)
+	if len(phrases) == 0 {
+		return
+	}
+	if len(phrases) > maxPhrases {
+		phrases = phrases[:maxPhrases]
+	}
+	fmt.Println()
+	fmt.Println(tr("People also write:"))
+	for _, p := range phrases {
+		fmt.Printf("  %s (%d)\n", p.Term, p.Messages)
+	}
+}
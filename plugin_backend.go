@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const pluginPrefix = "gommit-m-backend-"
+
+// pluginBackend runs an external executable, gommit-m-backend-<name> on
+// the PATH, for each search. It writes one JSON request to the plugin's
+// stdin and reads one JSON response from its stdout:
+//
+//	{"query": "fix typo", "page": 1}
+//	{"commits": [{"repo": "...", "sha1": "...", "commit_url": "...", "message": "..."}],
+//	 "result_count": 42, "total_pages": 3, "error": ""}
+//
+// Commits use the same fields as --json output. A non-empty error, or a
+// non-zero exit status, fails the search.
+type pluginBackend struct {
+	name string
+	path string
+}
+
+type pluginRequest struct {
+	Query string `json:"query"`
+	Page  int    `json:"page"`
+}
+
+type pluginResponse struct {
+	Commits     []*commit `json:"commits"`
+	ResultCount int       `json:"result_count"`
+	TotalPages  int       `json:"total_pages"`
+	Error       string    `json:"error"`
+}
+
+func findPlugin(name string) (*pluginBackend, error) {
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown backend: %s (no %s%s on the PATH)", name, pluginPrefix, name)
+	}
+	return &pluginBackend{name: name, path: path}, nil
+}
+
+func (p *pluginBackend) Name() string {
+	return p.name
+}
+
+// URL is only a cache key; plugins have no page to link to.
+func (p *pluginBackend) URL(query string, page int) string {
+	return fmt.Sprintf("plugin://%s/search?q=%s&page=%d", p.name, url.QueryEscape(query), page)
+}
+
+func (p *pluginBackend) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	req, err := json.Marshal(&pluginRequest{Query: query, Page: page})
+	if err != nil {
+		return QueryResult{Commits: []*commit{}}, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Stdin = bytes.NewReader(append(req, '\n'))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	debugf("run %s", p.path)
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%s: %s", err, msg)
+		}
+		return QueryResult{Commits: []*commit{}}, fmt.Errorf("%s: %s", p.name, err)
+	}
+
+	res := &pluginResponse{}
+	if err := json.Unmarshal(stdout.Bytes(), res); err != nil {
+		return QueryResult{Commits: []*commit{}}, &parseError{url: p.URL(query, page)}
+	}
+	if res.Error != "" {
+		return QueryResult{Commits: []*commit{}}, fmt.Errorf("%s: %s", p.name, res.Error)
+	}
+	if res.Commits == nil {
+		res.Commits = []*commit{}
+	}
+	for _, c := range res.Commits {
+		if c.Source == "" {
+			c.Source = p.name
+		}
+	}
+	if res.TotalPages == 0 {
+		res.TotalPages = 1
+	}
+	return QueryResult{
+		Commits:     res.Commits,
+		ResultCount: fmt.Sprintf("%d results", res.ResultCount),
+		TotalPages:  strconv.Itoa(res.TotalPages),
+	}, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// prefetch fetches a result page in the background.
+type prefetch struct {
+	page   int
+	done   chan struct{}
+	result QueryResult
+	err    error
+}
+
+func startPrefetch(keyword string, page int, ttl time.Duration) *prefetch {
+	p := &prefetch{page: page, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		p.result, p.err = cachedCrawl(keyword, page, ttl)
+	}()
+	return p
+}
+
+func (p *prefetch) wait() (QueryResult, error) {
+	<-p.done
+	return p.result, p.err
+}
+
+// hasNextPage reports whether the result has pages after page.
+func hasNextPage(result QueryResult, page int) bool {
+	total, err := strconv.Atoi(result.TotalPages)
+	return err == nil && page < total
+}
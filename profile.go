@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	rpprof "runtime/pprof"
+
+	"github.com/codegangsta/cli"
+)
+
+// profileFlags are hidden flags writing profiles for go tool pprof.
+var profileFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "cpuprofile",
+		Usage:  "write a CPU profile to this file",
+		Hidden: true,
+	},
+	cli.StringFlag{
+		Name:   "memprofile",
+		Usage:  "write a heap profile to this file when the command finishes",
+		Hidden: true,
+	},
+}
+
+var pprofFlag = cli.StringFlag{
+	Name:   "pprof-listen",
+	Usage:  "serve net/http/pprof on this address, e.g. localhost:6060",
+	Hidden: true,
+}
+
+var (
+	cpuProfile     *os.File
+	memProfilePath string
+)
+
+// startProfiling starts the CPU profile of --cpuprofile. The profiles are
+// written by stopProfiling, when the command returns or is interrupted.
+func startProfiling(c *cli.Context) error {
+	memProfilePath = c.String("memprofile")
+	path := c.String("cpuprofile")
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := rpprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	cpuProfile = f
+	return nil
+}
+
+func stopProfiling() {
+	if cpuProfile != nil {
+		rpprof.StopCPUProfile()
+		cpuProfile.Close()
+		cpuProfile = nil
+	}
+	if memProfilePath != "" {
+		path := memProfilePath
+		memProfilePath = ""
+		f, err := os.Create(path)
+		if err != nil {
+			logger.Error("failed to write heap profile", "error", err)
+			return
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := rpprof.WriteHeapProfile(f); err != nil {
+			logger.Error("failed to write heap profile", "error", err)
+		}
+	}
+}
+
+// servePprof serves the pprof handlers on addr, apart from the handlers of
+// serve so they are never exposed on its public address.
+func servePprof(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	logger.Info("pprof listening", "addr", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logger.Error("pprof", "error", err)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"golang.org/x/sync/singleflight"
+)
+
+const cacheProxyDir = "proxy"
+
+var proxyCommand = cli.Command{
+	Name:  "proxy",
+	Usage: "serve commit-m through a caching proxy; point --endpoint of other gommit-m at it",
+	Description: `Pages and JSON fetched from the upstream are cached on disk and served
+   again for --cache-ttl. Identical requests in flight are answered by one
+   upstream request, and no more than --max-upstream requests are sent to
+   the upstream at a time, --delay apart.`,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "listen",
+			Value: ":8081",
+			Usage: "address to listen on",
+		},
+		cli.StringFlag{
+			Name:  "upstream",
+			Usage: "commit-m instance proxied (default --endpoint)",
+		},
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: time.Hour,
+			Usage: "serve cached responses fetched within this duration",
+		},
+		cli.IntFlag{
+			Name:  "max-upstream",
+			Value: 1,
+			Usage: "requests sent to the upstream at a time",
+		},
+		cli.DurationFlag{
+			Name:  "delay",
+			Value: allPagesDelay,
+			Usage: "minimum time between requests to the upstream",
+		},
+	}, serverLogFlags...),
+	Action: func(c *cli.Context) {
+		if err := setServerLog(c); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		max := c.Int("max-upstream")
+		if max < 1 {
+			max = 1
+		}
+		p := &cachingProxy{
+			upstream: firstNonEmpty(c.String("upstream"), endpoint),
+			ttl:      c.Duration("cache-ttl"),
+			delay:    c.Duration("delay"),
+			slots:    make(chan struct{}, max),
+		}
+		logger.Info("proxying", "upstream", p.upstream, "addr", c.String("listen"))
+		if err := http.ListenAndServe(c.String("listen"), logRequests(p)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+type proxyEntry struct {
+	URL         string    `json:"url"`
+	Fetched     time.Time `json:"fetched"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+}
+
+type cachingProxy struct {
+	upstream string
+	ttl      time.Duration
+	delay    time.Duration
+
+	group singleflight.Group
+	slots chan struct{}
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+func proxyCacheFile(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return cachePath(cacheProxyDir, hex.EncodeToString(sum[:])+".json.gz")
+}
+
+func (p *cachingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	url := p.upstream + r.URL.RequestURI()
+
+	cache := "HIT"
+	entry := &proxyEntry{}
+	if err := loadJSON(proxyCacheFile(url), entry); err != nil || entry.URL != url || time.Since(entry.Fetched) > p.ttl {
+		cache = "MISS"
+		v, err, shared := p.group.Do(url, func() (interface{}, error) {
+			return p.fetch(url)
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if shared {
+			cache = "COALESCED"
+		}
+		entry = v.(*proxyEntry)
+	}
+	noteRequest(r, "", cache)
+	if cache == "HIT" {
+		cacheLookups.WithLabelValues("hit").Inc()
+	} else {
+		cacheLookups.WithLabelValues("miss").Inc()
+	}
+
+	if entry.ContentType != "" {
+		w.Header().Set("Content-Type", entry.ContentType)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry.Body)))
+	w.Header().Set("X-Gommitm-Cache", cache)
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(entry.Fetched)/time.Second)))
+	w.WriteHeader(entry.Status)
+	if r.Method == "GET" {
+		w.Write(entry.Body)
+	}
+}
+
+// fetch requests the url from the upstream once a slot is free and the
+// delay since the previous request has passed, caching successful
+// responses.
+func (p *cachingProxy) fetch(url string) (*proxyEntry, error) {
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	p.mu.Lock()
+	if wait := p.delay - time.Since(p.last); wait > 0 {
+		time.Sleep(wait)
+	}
+	p.last = time.Now()
+	p.mu.Unlock()
+
+	start := time.Now()
+	res, err := http.Get(url)
+	if err != nil {
+		observeSearch(start, err)
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	observeSearch(start, err)
+	if err != nil {
+		return nil, err
+	}
+	entry := &proxyEntry{
+		URL:         url,
+		Fetched:     time.Now(),
+		Status:      res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+		Body:        body,
+	}
+	if res.StatusCode == http.StatusOK {
+		if err := saveJSON(proxyCacheFile(url), entry); err != nil {
+			logger.Error("failed to cache", "url", url, "error", err)
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// queryOperators are the filters written in the keyword, as in
+// "repo:rails/* type:fix len:<50 fix typo":
+//
+//	repo:PATTERN  only repositories matching the pattern (* is a wildcard)
+//	type:TYPE     only messages of the conventional commit type, or whose
+//	              first word is TYPE
+//	len:<N len:>N len:N  only subject lines shorter, longer or as long
+type queryOperators struct {
+	repo    *regexp.Regexp
+	kind    string
+	compare byte
+	length  int
+}
+
+var lengthOperator = regexp.MustCompile(`^([<>]?)(\d+)$`)
+
+// parseQueryOperators takes the operators out of the keyword, returning
+// the words left to search for.
+func parseQueryOperators(keyword string) (string, *queryOperators, error) {
+	ops := &queryOperators{}
+	words := []string{}
+	for _, word := range strings.Fields(keyword) {
+		i := strings.Index(word, ":")
+		if i <= 0 || i == len(word)-1 {
+			words = append(words, word)
+			continue
+		}
+		name, value := word[:i], word[i+1:]
+		switch name {
+		case "repo":
+			ops.repo = globPattern(value)
+		case "type":
+			ops.kind = strings.ToLower(value)
+		case "len":
+			m := lengthOperator.FindStringSubmatch(value)
+			if m == nil {
+				return "", nil, fmt.Errorf("invalid operator: %s, expected len:<N, len:>N or len:N", word)
+			}
+			ops.length, _ = strconv.Atoi(m[2])
+			if m[1] != "" {
+				ops.compare = m[1][0]
+			}
+		default:
+			// e.g. "fix: typo" or a url
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " "), ops, nil
+}
+
+func (ops *queryOperators) match(c *commit) bool {
+	if ops.repo != nil && !ops.repo.MatchString(c.Repo) {
+		return false
+	}
+	subject := firstLine(c.Message)
+	if ops.kind != "" {
+		kind := ""
+		if m := conventionalSubject.FindStringSubmatch(subject); m != nil {
+			kind = m[1]
+		} else if fields := strings.Fields(subject); len(fields) > 0 {
+			kind = fields[0]
+		}
+		if strings.ToLower(kind) != ops.kind {
+			return false
+		}
+	}
+	if ops.length > 0 {
+		n := utf8.RuneCountInString(subject)
+		switch ops.compare {
+		case '<':
+			return n < ops.length
+		case '>':
+			return n > ops.length
+		default:
+			return n == ops.length
+		}
+	}
+	return true
+}
+
+func (ops *queryOperators) filter(commits []*commit) []*commit {
+	if ops.repo == nil && ops.kind == "" && ops.length == 0 {
+		return commits
+	}
+	filtered := []*commit{}
+	for _, c := range commits {
+		if ops.match(c) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestParseQueryOperators(t *testing.T) {
+	keyword, ops, err := parseQueryOperators("repo:rails/* type:fix len:<50 fix: typo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyword != "fix: typo" {
+		t.Errorf("keyword = %q, want %q", keyword, "fix: typo")
+	}
+	for _, tt := range []struct {
+		repo, message string
+		want          bool
+	}{
+		{"rails/rails", "fix: typo in guides", true},
+		{"rails/rails", "Fix typo in guides", true},
+		{"rails/rails", "fix(docs): typo", true},
+		{"Rails/Rails", "fix typo", true},
+		{"rails/rails", "docs: fix typo", false},
+		{"octo/rails", "fix typo", false},
+		{"rails/rails", "fix typo in the guides, the api docs and the changelog of the release", false},
+	} {
+		if got := ops.match(&commit{Repo: tt.repo, Message: tt.message}); got != tt.want {
+			t.Errorf("match(%s, %q) = %v, want %v", tt.repo, tt.message, got, tt.want)
+		}
+	}
+
+	if _, _, err := parseQueryOperators("len:short typo"); err == nil {
+		t.Error("len:short was accepted")
+	}
+	if keyword, _, _ := parseQueryOperators("https://example.com x:"); keyword != "https://example.com x:" {
+		t.Errorf("words that are no operators became %q", keyword)
+	}
+}
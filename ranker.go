@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ranker orders the results for --rank. Another ordering can be added by
+// implementing it and adding it to rankers.
+type Ranker interface {
+	// Rank reorders the commits found for the keyword in place.
+	Rank(commits []*commit, keyword string)
+}
+
+// RankerFunc adapts an ordering function to Ranker.
+type RankerFunc func(commits []*commit, keyword string)
+
+func (f RankerFunc) Rank(commits []*commit, keyword string) {
+	f(commits, keyword)
+}
+
+// rankers are the orderings of --rank by name. stars needs the star counts
+// looked up before ranking.
+var rankers = map[string]Ranker{
+	"upstream-order": RankerFunc(func([]*commit, string) {}),
+	"relevance":      RankerFunc(rankByRelevance),
+	"stars":          RankerFunc(func(commits []*commit, _ string) { rankByStars(commits) }),
+	"similarity":     RankerFunc(rankBySimilarity),
+	"length":         RankerFunc(func(commits []*commit, _ string) { rankByLength(commits) }),
+}
+
+func rankerNames() []string {
+	names := make([]string, 0, len(rankers))
+	for name := range rankers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rankBySimilarity orders the commits by the similarity of their messages
+// to the keyword, as similar does with a draft.
+func rankBySimilarity(commits []*commit, keyword string) {
+	scores := make(map[*commit]float64, len(commits))
+	for _, c := range commits {
+		scores[c] = similarity(keyword, firstLine(c.Message))
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return scores[commits[i]] > scores[commits[j]]
+	})
+}
+
+// rankByLength orders the commits from the shortest subject line.
+func rankByLength(commits []*commit) {
+	sort.SliceStable(commits, func(i, j int) bool {
+		return utf8.RuneCountInString(firstLine(commits[i].Message)) < utf8.RuneCountInString(firstLine(commits[j].Message))
+	})
+}
+
+// rankUsage lists the rankers for the usage of --rank.
+func rankUsage() string {
+	return "reorder results: " + strings.Join(rankerNames(), ", ") +
+		" (stars is the repository star count on GitHub, relevance weighs keyword frequency, position and message brevity)"
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestRankers(t *testing.T) {
+	messages := func(commits []*commit) string {
+		text := ""
+		for _, c := range commits {
+			text += "|" + c.Message
+		}
+		return text
+	}
+	for _, tt := range []struct {
+		rank string
+		want string
+	}{
+		{"upstream-order", "|update the readme|fix typo in readme|fix typo"},
+		{"length", "|fix typo|update the readme|fix typo in readme"},
+		{"similarity", "|fix typo|fix typo in readme|update the readme"},
+	} {
+		commits := []*commit{{Message: "update the readme"}, {Message: "fix typo in readme"}, {Message: "fix typo"}}
+		rankers[tt.rank].Rank(commits, "fix typo")
+		if got := messages(commits); got != tt.want {
+			t.Errorf("--rank %s: got %s, want %s", tt.rank, got, tt.want)
+		}
+	}
+}
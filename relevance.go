@@ -0,0 +1,39 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// relevance scores how much the message is about the keyword: how often
+// the keyword appears, how early (a message starting with it scores
+// higher) and how short the message is.
+func relevance(message, keyword string) float64 {
+	message, keyword = strings.ToLower(firstLine(message)), strings.ToLower(keyword)
+	first := strings.Index(message, keyword)
+	if keyword == "" || first < 0 {
+		return 0
+	}
+	length := utf8.RuneCountInString(message)
+	score := float64(strings.Count(message, keyword))
+	score += 1 - float64(utf8.RuneCountInString(message[:first]))/float64(length)
+	if first == 0 {
+		score++
+	}
+	// 1 for an empty message, 0.5 at the 50 characters of a good subject line
+	score += 50 / float64(50+length)
+	return score
+}
+
+// rankByRelevance orders the commits by relevance to the keyword, keeping
+// the upstream order between equally relevant ones.
+func rankByRelevance(commits []*commit, keyword string) {
+	scores := make(map[*commit]float64, len(commits))
+	for _, c := range commits {
+		scores[c] = relevance(c.Message, keyword)
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return scores[commits[i]] > scores[commits[j]]
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestRelevance(t *testing.T) {
+	if r := relevance("Update dependencies", "typo"); r != 0 {
+		t.Errorf("message without the keyword: %v, want 0", r)
+	}
+	if r := relevance("Fix typo", ""); r != 0 {
+		t.Errorf("empty keyword: %v, want 0", r)
+	}
+	if a, b := relevance("Typo in docs", "typo"), relevance("Fix a typo", "typo"); a <= b {
+		t.Errorf("leading keyword scored %v, not above %v", a, b)
+	}
+	if a, b := relevance("Typo fix", "typo"), relevance("Typo fix in the error message of the config loader", "typo"); a <= b {
+		t.Errorf("short message scored %v, not above %v", a, b)
+	}
+	if r := relevance("fix\ntypo in the body", "typo"); r != 0 {
+		t.Errorf("keyword only in the body: %v, want 0", r)
+	}
+}
+
+func TestRankByRelevance(t *testing.T) {
+	commits := []*commit{
+		{Sha1: "1111111", Message: "Update README"},
+		{Sha1: "2222222", Message: "fix typo"},
+		{Sha1: "3333333", Message: "Typo fix"},
+		{Sha1: "4444444", Message: "Fix Typo"},
+		{Sha1: "5555555", Message: "Bump version"},
+	}
+	rankByRelevance(commits, "typo")
+
+	// ties keep the upstream order: 2 and 4 score the same, as do 1 and 5
+	want := []string{"3333333", "2222222", "4444444", "1111111", "5555555"}
+	for i, c := range commits {
+		if c.Sha1 != want[i] {
+			t.Errorf("rank %d is %s %q, want %s", i+1, c.Sha1, c.Message, want[i])
+		}
+	}
+}
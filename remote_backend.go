@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// remoteServer searches through the /search API of a gommit-m serve
+// instance given with --remote, sharing its cache.
+type remoteServer struct {
+	url string
+	key string
+}
+
+func newRemoteServer(base, key string) remoteServer {
+	return remoteServer{url: strings.TrimRight(base, "/"), key: key}
+}
+
+func (r remoteServer) Name() string { return "remote" }
+
+func (r remoteServer) URL(query string, page int) string {
+	return fmt.Sprintf("%s/search?keyword=%s&page=%d", r.url, url.QueryEscape(query), page)
+}
+
+func (r remoteServer) Search(ctx context.Context, query string, page int) (QueryResult, error) {
+	req, err := http.NewRequest("GET", r.URL(query, page), nil)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if r.key != "" {
+		req.Header.Set("Authorization", "Bearer "+r.key)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	defer closeBody(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		body := struct {
+			Error string `json:"error"`
+		}{}
+		json.NewDecoder(res.Body).Decode(&body)
+		if res.StatusCode == http.StatusInternalServerError {
+			// the server could not parse the page of its backend
+			return QueryResult{}, &parseError{url: r.URL(query, page)}
+		}
+		return QueryResult{}, fmt.Errorf("%s: %s: %s", r.url, res.Status, firstNonEmpty(body.Error, "no details"))
+	}
+	output := JsonFormat{}
+	if err := json.NewDecoder(res.Body).Decode(&output); err != nil {
+		return QueryResult{}, &parseError{url: r.URL(query, page)}
+	}
+	result := QueryResult{Commits: output.Commits, TotalPages: "1"}
+	if result.Commits == nil {
+		result.Commits = []*commit{}
+	}
+	if output.ResultCount > 0 {
+		result.ResultCount = fmt.Sprintf("%d results", output.ResultCount)
+	}
+	if output.TotalPages > 0 {
+		result.TotalPages = strconv.Itoa(output.TotalPages)
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryWait is the longest wait before a retry. A server asking for a
+// longer one is considered down, and the error is returned right away.
+const maxRetryWait = time.Minute
+
+// retryTransport retries the GET and HEAD requests answered with 429 or a
+// 5xx status, after the delay of their Retry-After header or, without one,
+// after 1s, 2s, 4s... up to retries times.
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryAfter parses the Retry-After header, in seconds or as a date.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return t.base.RoundTrip(req)
+	}
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if err != nil || !retryable(res.StatusCode) || attempt >= t.retries {
+			return res, err
+		}
+		wait, ok := retryAfter(res.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			wait = backoff
+			backoff *= 2
+		}
+		if wait > maxRetryWait {
+			debugf("%s %s: %s, not retrying as asked to wait %s", req.Method, req.URL, res.Status, wait)
+			return res, nil
+		}
+		closeBody(res.Body)
+		debugf("%s %s: %s, retrying in %s (%d/%d)", req.Method, req.URL, res.Status, wait, attempt+1, t.retries)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%s %s: %s while waiting to retry", req.Method, req.URL, req.Context().Err())
+		}
+	}
+}
+
+// setRetries installs the retries of --max-retries.
+func setRetries(retries int) {
+	if retries > 0 {
+		http.DefaultTransport = &retryTransport{base: http.DefaultTransport, retries: retries}
+	}
+}
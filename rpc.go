@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var rpcCommand = cli.Command{
+	Name:  "rpc",
+	Usage: "answer search, open and copy requests of editor plugins as JSON-RPC 2.0 on stdio, one message per line",
+	Description: `Methods:
+
+   search {"keyword", "page", "limit"}  commits of one page of results
+   open   {"index"}                     open a commit of the last search in the browser
+   copy   {"index"} or {"message"}      copy a message of the last search, or a message, to the clipboard
+   ping
+
+   e.g. from Vim: job_start(['gommit-m', 'rpc'], {'mode': 'nl', 'out_cb': ...})`,
+	Flags: []cli.Flag{
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: time.Hour,
+			Usage: "reuse cached results fetched within this duration",
+		},
+	},
+	Action: func(c *cli.Context) {
+		stopInterrupts()
+		s := &editorRPC{ttl: c.Duration("cache-ttl")}
+		if err := serveJSONRPC(os.Stdin, json.NewEncoder(os.Stdout), "rpc", s.handle); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// editorRPC serves the rpc command. open and copy refer to the commits of
+// the last search by their 1-based index.
+type editorRPC struct {
+	ttl  time.Duration
+	last []*commit
+}
+
+type rpcSearchResult struct {
+	Keyword     string    `json:"keyword"`
+	Page        int       `json:"page"`
+	ResultCount int       `json:"result_count"`
+	TotalPages  string    `json:"total_pages"`
+	Commits     []*commit `json:"commits"`
+}
+
+func (s *editorRPC) handle(req rpcRequest) (interface{}, *rpcError) {
+	params := struct {
+		Keyword string `json:"keyword"`
+		Page    int    `json:"page"`
+		Limit   int    `json:"limit"`
+		Index   int    `json:"index"`
+		Message string `json:"message"`
+	}{}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+	}
+
+	switch req.Method {
+	case "ping":
+		return nil, nil
+	case "search":
+		if params.Keyword == "" {
+			return nil, &rpcError{rpcInvalidParams, "keyword is required"}
+		}
+		if params.Page < 1 {
+			params.Page = 1
+		}
+		result, err := cachedCrawl(params.Keyword, params.Page, s.ttl)
+		if err != nil {
+			return nil, &rpcError{rpcServerError, err.Error()}
+		}
+		if params.Limit > 0 && len(result.Commits) > params.Limit {
+			result.Commits = result.Commits[:params.Limit]
+		}
+		s.last = result.Commits
+		return rpcSearchResult{
+			Keyword:     params.Keyword,
+			Page:        params.Page,
+			ResultCount: parseResultCount(result.ResultCount),
+			TotalPages:  result.TotalPages,
+			Commits:     result.Commits,
+		}, nil
+	case "open", "copy":
+		var found *commit
+		if params.Index != 0 {
+			if params.Index < 1 || params.Index > len(s.last) {
+				return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("no such result: %d", params.Index)}
+			}
+			found = s.last[params.Index-1]
+		}
+		var err error
+		switch {
+		case req.Method == "open" && found != nil:
+			err = openBrowser(found.displayURL())
+		case req.Method == "copy" && found != nil:
+			err = copyToClipboard(found.Message)
+		case req.Method == "copy" && params.Message != "":
+			err = copyToClipboard(params.Message)
+		default:
+			return nil, &rpcError{rpcInvalidParams, "index, or message for copy, is required"}
+		}
+		if err != nil {
+			return nil, &rpcError{rpcServerError, err.Error()}
+		}
+		return nil, nil
+	}
+	return nil, &rpcError{rpcMethodNotFound, "method not found: " + req.Method}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+)
+
+// profanity are the words whose messages --safe filters out, on top of
+// the profanity list of the config. A trailing * matches any word starting
+// with the rest.
+var profanity = []string{
+	"arse", "asshole*", "bastard*", "bitch*", "bollocks", "bullshit*", "crap", "crappy",
+	"cunt*", "damn*", "dick", "dickhead*", "fuck*", "goddamn*", "motherfuck*", "piss*",
+	"shit*", "shitty", "twat*", "wank*", "wtf",
+}
+
+// isProfane reports whether a word of the message is in the list.
+func isProfane(message string, words []string) bool {
+	for _, token := range tokenize(message) {
+		for _, word := range words {
+			word = strings.ToLower(word)
+			if prefix := strings.TrimSuffix(word, "*"); prefix != word {
+				if strings.HasPrefix(token, prefix) {
+					return true
+				}
+			} else if token == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// safeCommits drops the commits whose message contains profanity.
+func safeCommits(commits []*commit) []*commit {
+	safe := []*commit{}
+	for _, c := range commits {
+		if !isProfane(c.Message, profanity) {
+			safe = append(safe, c)
+		}
+	}
+	return safe
+}
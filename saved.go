@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+const savedSearchesFile = "searches.json"
+
+// savedSearch is a named query replayed by `gommit-m run`. Args holds the
+// flags given at save time in command line form, followed by the keyword.
+type savedSearch struct {
+	Name string   `json:"name"`
+	Args []string `json:"args"`
+}
+
+var saveCommand = cli.Command{
+	Name:      "save",
+	Usage:     "save a search under a name",
+	ArgsUsage: "name keyword [page]",
+	Flags:     searchFlags,
+	Action: func(c *cli.Context) {
+		name := c.Args().First()
+		keyword := c.Args().Get(1)
+		if name == "" || keyword == "" {
+			cli.ShowCommandHelp(c, "save")
+			os.Exit(1)
+		}
+
+		args := append(flagArgs(c, searchFlags), keyword)
+		if page := c.Args().Get(2); page != "" {
+			args = append(args, page)
+		}
+
+		searches, err := loadSavedSearches()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		searches[name] = &savedSearch{Name: name, Args: args}
+		if err := saveJSON(configPath(savedSearchesFile), searches); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("saved %s: %s\n", name, strings.Join(args, " "))
+	},
+}
+
+var runCommand = cli.Command{
+	Name:         "run",
+	Usage:        "run a saved search (lists saved searches without a name)",
+	ArgsUsage:    "[name]",
+	BashComplete: completeSavedSearches,
+	Action: func(c *cli.Context) {
+		searches, err := loadSavedSearches()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		name := c.Args().First()
+		if name == "" {
+			names := []string{}
+			for name := range searches {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("%s\t%s\n", name, strings.Join(searches[name].Args, " "))
+			}
+			return
+		}
+
+		s, ok := searches[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "no saved search: %s\n", name)
+			os.Exit(1)
+		}
+		c.App.Run(append([]string{os.Args[0]}, s.Args...))
+	},
+}
+
+func loadSavedSearches() (map[string]*savedSearch, error) {
+	searches := map[string]*savedSearch{}
+	err := loadJSON(configPath(savedSearchesFile), &searches)
+	return searches, err
+}
+
+// secretFlags are never written to saved searches or history.
+var secretFlags = map[string]bool{
+	"github-token":      true,
+	"gitlab-token":      true,
+	"bitbucket-token":   true,
+	"sourcegraph-token": true,
+	"auth-password":     true,
+	"auth-token":        true,
+	"header":            true,
+	"webhook-secret":    true,
+	"notify":            true,
+	"remote-key":        true,
+}
+
+// flagArgs turns the flags explicitly set on the context back into command
+// line arguments, so they can be replayed later.
+func flagArgs(c *cli.Context, flags []cli.Flag) []string {
+	args := []string{}
+	for _, flag := range flags {
+		var name string
+		var values []string
+		switch f := flag.(type) {
+		case cli.BoolFlag:
+			name = flagName(f.Name)
+			values = []string{"true"}
+		case cli.StringFlag:
+			name = flagName(f.Name)
+			values = []string{c.String(name)}
+		case cli.IntFlag:
+			name = flagName(f.Name)
+			values = []string{strconv.Itoa(c.Int(name))}
+		case cli.DurationFlag:
+			name = flagName(f.Name)
+			values = []string{c.Duration(name).String()}
+		case cli.GenericFlag:
+			name = flagName(f.Name)
+			if v, ok := c.Generic(name).(fmt.Stringer); ok {
+				values = []string{v.String()}
+			}
+		case cli.StringSliceFlag:
+			name = flagName(f.Name)
+			values = c.StringSlice(name)
+		default:
+			continue
+		}
+		if !c.IsSet(name) || secretFlags[name] {
+			continue
+		}
+		for _, v := range values {
+			args = append(args, fmt.Sprintf("--%s=%s", name, v))
+		}
+	}
+	return args
+}
+
+// declaredName returns the name a flag was declared with, "name, n".
+func declaredName(flag cli.Flag) (string, bool) {
+	switch f := flag.(type) {
+	case cli.BoolFlag:
+		return f.Name, true
+	case cli.StringFlag:
+		return f.Name, true
+	case cli.IntFlag:
+		return f.Name, true
+	case cli.DurationFlag:
+		return f.Name, true
+	case cli.GenericFlag:
+		return f.Name, true
+	case cli.StringSliceFlag:
+		return f.Name, true
+	}
+	return "", false
+}
+
+// flagName returns the long name of a flag declared as "name, n".
+func flagName(name string) string {
+	return strings.TrimSpace(strings.Split(name, ",")[0])
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+func TestSavedSearchReplaysDurations(t *testing.T) {
+	defer func(dir string) { configDirOverride = dir }(configDirOverride)
+	configDirOverride = t.TempDir()
+
+	var saved []string
+	var ttl time.Duration
+	app := cli.NewApp()
+	app.Writer = ioutil.Discard
+	app.HideHelp = true
+	app.HideVersion = true
+	app.Flags = searchFlags
+	app.Action = func(c *cli.Context) {
+		saved = flagArgs(c, searchFlags)
+		ttl = c.Duration("cache-ttl")
+	}
+
+	if err := app.Run([]string{"gommit-m", "--cache-ttl", "90m", "typo"}); err != nil {
+		t.Fatal(err)
+	}
+	searches := map[string]*savedSearch{"typos": {Name: "typos", Args: append(saved, "typo")}}
+	if err := saveJSON(configPath(savedSearchesFile), searches); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadSavedSearches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ttl = 0
+	if err := app.Run(append([]string{"gommit-m"}, loaded["typos"].Args...)); err != nil {
+		t.Fatal(err)
+	}
+	if ttl != 90*time.Minute {
+		t.Errorf("replayed %v: cache-ttl = %s, want 1h30m0s", saved, ttl)
+	}
+}
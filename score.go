@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+// commonWordResults is the number of corpus hits at which a word scores
+// full marks.
+const commonWordResults = 1000
+
+type wordScore struct {
+	Word        string `json:"word"`
+	Results     int    `json:"results"`
+	Misspelling string `json:"misspelling_of,omitempty"`
+	Score       int    `json:"score"`
+}
+
+type messageScore struct {
+	Message  string         `json:"message"`
+	Score    int            `json:"score"`
+	Words    []*wordScore   `json:"words"`
+	Problems []*lintProblem `json:"problems"`
+}
+
+var scoreCommand = cli.Command{
+	Name:      "score",
+	Usage:     "rate a draft commit message from 0 to 100 by how common its words are in the corpus",
+	ArgsUsage: "\"draft message\"",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the score as json",
+		},
+	},
+	Action: func(c *cli.Context) {
+		draft := strings.Join(c.Args(), " ")
+		if strings.TrimSpace(draft) == "" {
+			cli.ShowCommandHelp(c, "score")
+			os.Exit(exitUsage)
+		}
+		s := scoreMessage(draft)
+		if c.Bool("json") {
+			printJSON(s)
+			return
+		}
+		printScore(s)
+	},
+}
+
+// wordScoreOf scores a word from 0, for misspelled and unseen words, to
+// 100 for words with commonWordResults hits or more.
+func wordScoreOf(results int, misspelled bool) int {
+	switch {
+	case misspelled || results == 0:
+		return 0
+	case results < rareWordThreshold:
+		return 40
+	}
+	return 70 + int(30*math.Min(1, math.Log10(float64(results))/math.Log10(commonWordResults)))
+}
+
+// scoreMessage scores the draft as the mean score of its significant
+// words, with the problems lint reports as suggestions.
+func scoreMessage(draft string) *messageScore {
+	s := &messageScore{Message: draft, Words: []*wordScore{}, Problems: lintMessage(draft)}
+	seen := map[string]bool{}
+	total := 0
+	for _, word := range significantWords(draft) {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		w := &wordScore{Word: word, Misspelling: commonMisspellings[word]}
+		if w.Misspelling == "" {
+			n, err := corpusFrequency(word)
+			if err != nil {
+				logger.Warn("failed to look up word", "word", word, "error", err)
+				continue
+			}
+			w.Results = n
+		}
+		w.Score = wordScoreOf(w.Results, w.Misspelling != "")
+		total += w.Score
+		s.Words = append(s.Words, w)
+	}
+	if len(s.Words) > 0 {
+		s.Score = total / len(s.Words)
+	}
+	return s
+}
+
+func printScore(s *messageScore) {
+	fmt.Printf("score: %d/100\n\n", s.Score)
+	for _, w := range s.Words {
+		switch {
+		case w.Misspelling != "":
+			fmt.Printf("  %3d  %-20s misspelling of %q\n", w.Score, w.Word, w.Misspelling)
+		case w.Results == 0:
+			fmt.Printf("  %3d  %-20s never seen\n", w.Score, w.Word)
+		default:
+			fmt.Printf("  %3d  %-20s %d results\n", w.Score, w.Word, w.Results)
+		}
+	}
+	if len(s.Problems) == 0 {
+		return
+	}
+	fmt.Println("\nsuggestions:")
+	for _, p := range s.Problems {
+		fmt.Printf("  %s\n", p.Description)
+		if p.Suggestion != "" {
+			fmt.Printf("    suggestion: %s\n", p.Suggestion)
+		}
+	}
+}
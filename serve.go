@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/graphql-go/graphql"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+var serveCommand = cli.Command{
+	Name:  "serve",
+	Usage: "serve searches over HTTP with a web UI at /: GET /search?keyword=&page=, POST /search/batch, /stream?keyword=&pages= (Server-Sent Events), /graphql, /feed/<saved-search>, /usage, /metrics, /openapi.json, /healthz and /readyz",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "listen",
+			Value: ":8080",
+			Usage: "address to listen on",
+		},
+		cli.StringFlag{
+			Name:  "grpc-listen",
+			Usage: "also serve the CommitSearch gRPC service (proto/commit_search.proto) on this address",
+		},
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: time.Hour,
+			Usage: "reuse cached results fetched within this duration",
+		},
+		cli.IntFlag{
+			Name:  "memory-cache",
+			Value: 1000,
+			Usage: "number of results kept in memory (0 reads the cache on disk every time)",
+		},
+		cli.IntFlag{
+			Name:  "rate-limit",
+			Value: 60,
+			Usage: "requests per minute allowed for each client address (0 for no limit)",
+		},
+		cli.IntFlag{
+			Name:  "burst",
+			Value: 10,
+			Usage: "requests a client may make at once before --rate-limit applies",
+		},
+		cli.DurationFlag{
+			Name:  "shutdown-delay",
+			Usage: "on SIGTERM or interrupt, fail /readyz for this long before closing the listeners",
+		},
+		cli.DurationFlag{
+			Name:  "shutdown-timeout",
+			Value: 30 * time.Second,
+			Usage: "time allowed for requests in progress to finish on shutdown",
+		},
+		pprofFlag,
+	}, append(serverLogFlags, warmFlags...)...),
+	Action: func(c *cli.Context) {
+		stopInterrupts()
+		if err := setServerLog(c); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if addr := c.String("pprof-listen"); addr != "" {
+			go servePprof(addr)
+		}
+		cache := newResultCache(c.Duration("cache-ttl"), c.Int("memory-cache"))
+		limiter := newClientLimiter(c.Int("rate-limit"), c.Int("burst"))
+		keys, err := newAPIKeys(apiKeySettings)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		stop := make(chan struct{})
+		go keys.saveEvery(time.Minute, stop)
+
+		warm, err := warmKeywords(c)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if len(warm) > 0 {
+			pages, err := parsePageRange(c.String("warm-pages"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			go cache.warm(warm, pages, c.Duration("warm-interval"), stop)
+		}
+
+		h := &health{}
+		srv := &http.Server{Addr: c.String("listen"), Handler: newServer(cache, limiter, keys, h, newGithubClient(githubToken(c)))}
+		var grpcServer *grpc.Server
+		if addr := c.String("grpc-listen"); addr != "" {
+			l, err := net.Listen("tcp", addr)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			grpcServer = newGRPCServer(cache, limiter, keys)
+			logger.Info("grpc listening", "addr", addr)
+			go func() {
+				if err := grpcServer.Serve(l); err != nil {
+					logger.Error("grpc", "error", err)
+				}
+			}()
+		}
+
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			sig := <-signals
+			logger.Info("shutting down", "signal", sig.String())
+			h.drain()
+			close(stop)
+			time.Sleep(c.Duration("shutdown-delay"))
+
+			ctx, cancel := context.WithTimeout(context.Background(), c.Duration("shutdown-timeout"))
+			defer cancel()
+			if grpcServer != nil {
+				go func() {
+					<-ctx.Done()
+					grpcServer.Stop()
+				}()
+				grpcServer.GracefulStop()
+			}
+			if err := srv.Shutdown(ctx); err != nil {
+				logger.Error("shutdown", "error", err)
+			}
+		}()
+		logger.Info("listening", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		<-stopped
+		keys.save()
+	},
+}
+
+// webFiles is the web UI served at /.
+//
+//go:embed web
+var webFiles embed.FS
+
+type server struct {
+	cache  *resultCache
+	github *githubClient
+	schema graphql.Schema
+}
+
+func newServer(cache *resultCache, limiter *clientLimiter, keys *apiKeys, h *health, github *githubClient) http.Handler {
+	s := &server{cache: cache, github: github}
+	schema, err := newGraphQLSchema(s)
+	if err != nil {
+		panic(err)
+	}
+	s.schema = schema
+	guard := func(name string, handler http.HandlerFunc) http.Handler {
+		return instrument(name, limiter.limit(keys.require(handler)))
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/search", guard("search", s.search))
+	mux.Handle("/search/batch", guard("batch", s.batch))
+	mux.Handle("/stream", guard("stream", s.stream))
+	mux.Handle("/graphql", guard("graphql", s.graphql))
+	mux.Handle("/feed/", guard("feed", s.feed))
+	mux.Handle("/usage", instrument("usage", limiter.limit(http.HandlerFunc(keys.usageHandler))))
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/openapi.json", s.openAPI)
+	mux.HandleFunc("/healthz", h.healthz)
+	mux.HandleFunc("/readyz", h.readyz)
+	ui, _ := fs.Sub(webFiles, "web")
+	mux.Handle("/", http.FileServer(http.FS(ui)))
+	return logRequests(mux)
+}
+
+// writeJSON writes v as the response with the status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+		return
+	}
+	keyword := r.URL.Query().Get("keyword")
+	if keyword == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("keyword is required"))
+		return
+	}
+	page := parsePage(r.URL.Query().Get("page"))
+	result, cache, err := s.cache.fetch(keyword, page)
+	noteRequest(r, keyword, cache)
+	if err != nil {
+		status := http.StatusBadGateway
+		if failureExitCode(err) == exitParse {
+			status = http.StatusInternalServerError
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, jsonResult(result, buildUrl(keyword, page), page, nil))
+}
+
+func (s *server) feed(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/feed/")
+	searches, err := loadSavedSearches()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	saved, ok := searches[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no saved search: %s", name))
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	// nothing is written before the results are fetched
+	if err := writeFeed(w, saved, s.cache.ttl); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxBatch is the largest number of queries in one batch request.
+const maxBatch = 20
+
+type batchQuery struct {
+	Keyword string `json:"keyword"`
+	Page    int    `json:"page,omitempty"`
+}
+
+// batchRequest is the body of POST /search/batch: queries, or just
+// keywords to search their first page.
+type batchRequest struct {
+	Keywords []string     `json:"keywords,omitempty"`
+	Queries  []batchQuery `json:"queries,omitempty"`
+}
+
+type batchResult struct {
+	Keyword string      `json:"keyword"`
+	Page    int         `json:"page"`
+	Result  *JsonFormat `json:"result,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+// batch serves POST /search/batch. The queries are searched one after
+// another and a failed query is reported in its result, not as the status
+// of the response.
+func (s *server) batch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+		return
+	}
+	req := batchRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %s", err))
+		return
+	}
+	queries := req.Queries
+	for _, keyword := range req.Keywords {
+		queries = append(queries, batchQuery{Keyword: keyword})
+	}
+	if len(queries) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("keywords or queries are required"))
+		return
+	}
+	if len(queries) > maxBatch {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("at most %d queries are allowed in a batch", maxBatch))
+		return
+	}
+
+	res := batchResponse{Results: []batchResult{}}
+	keywords := []string{}
+	for _, q := range queries {
+		if q.Page < 1 {
+			q.Page = 1
+		}
+		br := batchResult{Keyword: q.Keyword, Page: q.Page}
+		if strings.TrimSpace(q.Keyword) == "" {
+			br.Error = "keyword is required"
+		} else if result, err := s.cache.crawl(q.Keyword, q.Page); err != nil {
+			br.Error = err.Error()
+		} else {
+			output := jsonResult(result, buildUrl(q.Keyword, q.Page), q.Page, nil)
+			br.Result = &output
+		}
+		res.Results = append(res.Results, br)
+		keywords = append(keywords, q.Keyword)
+	}
+	noteRequest(r, strings.Join(keywords, ","), "")
+	writeJSON(w, http.StatusOK, res)
+}
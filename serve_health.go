@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// readinessTTL is how long the result of probing the backend is reused,
+// so frequent probes of /readyz do not turn into requests upstream.
+const readinessTTL = 10 * time.Second
+
+// health answers /healthz and /readyz. The server is ready while it is
+// not shutting down and the backend answers HTTP requests.
+type health struct {
+	mu       sync.Mutex
+	draining bool
+	checked  time.Time
+	err      error
+}
+
+func (h *health) drain() {
+	h.mu.Lock()
+	h.draining = true
+	h.mu.Unlock()
+}
+
+func (h *health) ready() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.draining {
+		return fmt.Errorf("shutting down")
+	}
+	if time.Since(h.checked) > readinessTTL {
+		h.err = probeBackend()
+		h.checked = time.Now()
+	}
+	return h.err
+}
+
+// probeBackend requests the root of the host searched by the backend. Any
+// response short of a server error counts as reachable; backends not
+// searched over HTTP, such as plugins, always are.
+func probeBackend() error {
+	u, err := url.Parse(backend.URL("gommit-m", 1))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequest("HEAD", u.Scheme+"://"+u.Host+"/", nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("%s unreachable: %s", u.Host, err)
+	}
+	res.Body.Close()
+	if res.StatusCode >= 500 {
+		return fmt.Errorf("%s: %s", u.Host, res.Status)
+	}
+	return nil
+}
+
+func (h *health) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *health) readyz(w http.ResponseWriter, r *http.Request) {
+	if err := h.ready(); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
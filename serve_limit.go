@@ -0,0 +1,190 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// resultCache keeps the most recently used results in memory in front of
+// the cache on disk, so that a shared server answers repeated queries
+// without reading files or searching the backend again.
+type resultCache struct {
+	ttl time.Duration
+	max int
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+type resultCacheItem struct {
+	key     string
+	fetched time.Time
+	result  QueryResult
+}
+
+func newResultCache(ttl time.Duration, max int) *resultCache {
+	return &resultCache{ttl: ttl, max: max, lru: list.New(), items: map[string]*list.Element{}}
+}
+
+func resultCacheKey(keyword string, page int) string {
+	return fmt.Sprintf("%s\x00%d", keyword, page)
+}
+
+func (rc *resultCache) crawl(keyword string, page int) (QueryResult, error) {
+	result, _, err := rc.fetch(keyword, page)
+	return result, err
+}
+
+// fetch is crawl also telling where the result came from: "memory",
+// "disk" or "miss" when the backend was searched.
+func (rc *resultCache) fetch(keyword string, page int) (QueryResult, string, error) {
+	if rc.ttl <= 0 {
+		result, err := cachedCrawl(keyword, page, 0)
+		return result, "miss", err
+	}
+	key := resultCacheKey(keyword, page)
+
+	if rc.max > 0 {
+		rc.mu.Lock()
+		if e, ok := rc.items[key]; ok {
+			item := e.Value.(*resultCacheItem)
+			if time.Since(item.fetched) <= rc.ttl {
+				rc.lru.MoveToFront(e)
+				rc.mu.Unlock()
+				cacheLookups.WithLabelValues("hit").Inc()
+				return item.result, "memory", nil
+			}
+			rc.lru.Remove(e)
+			delete(rc.items, key)
+		}
+		rc.mu.Unlock()
+	}
+
+	source := "disk"
+	result, ok := cachedResult(buildUrl(keyword, page), rc.ttl)
+	if !ok {
+		source = "miss"
+		var err error
+		if result, err = cachedCrawl(keyword, page, 0); err != nil {
+			return result, source, err
+		}
+	}
+	rc.remember(key, result)
+	return result, source, nil
+}
+
+func (rc *resultCache) remember(key string, result QueryResult) {
+	if rc.max <= 0 {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if e, ok := rc.items[key]; ok {
+		rc.lru.Remove(e)
+	}
+	rc.items[key] = rc.lru.PushFront(&resultCacheItem{key: key, fetched: time.Now(), result: result})
+	for rc.lru.Len() > rc.max {
+		oldest := rc.lru.Back()
+		rc.lru.Remove(oldest)
+		delete(rc.items, oldest.Value.(*resultCacheItem).key)
+	}
+}
+
+// clientLimiter allows each client, identified by its IP address, rate
+// requests per second with bursts of up to burst requests.
+type clientLimiter struct {
+	rate  rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	clients map[string]*clientBucket
+}
+
+type clientBucket struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// limiterIdle is how long a client is remembered after its last request.
+const limiterIdle = 10 * time.Minute
+
+func newClientLimiter(perMinute, burst int) *clientLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &clientLimiter{rate: rate.Limit(float64(perMinute) / 60), burst: burst, clients: map[string]*clientBucket{}}
+}
+
+// reserve takes a request from the client's allowance and returns how long
+// it has to wait before asking again when there is none left.
+func (l *clientLimiter) reserve(client string) (time.Duration, bool) {
+	if l == nil {
+		return 0, true
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.clients[client]
+	if !ok {
+		for c, old := range l.clients {
+			if now.Sub(old.seen) > limiterIdle {
+				delete(l.clients, c)
+			}
+		}
+		b = &clientBucket{limiter: rate.NewLimiter(l.rate, l.burst)}
+		l.clients[client] = b
+	}
+	b.seen = now
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+func clientAddr(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// limit rejects requests of clients over their rate with 429 Too Many
+// Requests.
+func (l *clientLimiter) limit(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wait, ok := l.reserve(clientAddr(r.RemoteAddr)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait/time.Second)+1))
+			writeError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded, retry in %s", wait.Round(time.Second)))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (l *clientLimiter) grpcAllow(ctx context.Context) error {
+	client := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client = clientAddr(p.Addr.String())
+	}
+	if wait, ok := l.reserve(client); !ok {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry in %s", wait.Round(time.Second))
+	}
+	return nil
+}
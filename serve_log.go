@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var serverLogFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "log-format",
+		Usage: "format of the log: text, or json for one JSON object per line (default --log-format of gommit-m)",
+	},
+	cli.StringFlag{
+		Name:  "log-level",
+		Usage: "least severe messages logged: debug, info, warn or error (default info, debug with --debug)",
+	},
+}
+
+// setServerLog sets the log of serve and proxy, with the time of each
+// message.
+func setServerLog(c *cli.Context) error {
+	level := logLevel(c.GlobalBool("debug"))
+	if c.IsSet("log-level") {
+		if err := level.UnmarshalText([]byte(c.String("log-level"))); err != nil {
+			return fmt.Errorf("invalid --log-level: %s", c.String("log-level"))
+		}
+	}
+	return setLogger(firstNonEmpty(c.String("log-format"), c.GlobalString("log-format")), level, true)
+}
+
+// requestLog collects what the handlers know about a request for its log
+// line.
+type requestLog struct {
+	keyword string
+	cache   string
+}
+
+type requestLogKey struct{}
+
+// noteRequest records the keyword searched by the request and where the
+// result came from.
+func noteRequest(r *http.Request, keyword, cache string) {
+	if l, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+		l.keyword, l.cache = keyword, cache
+	}
+}
+
+// statusRecorder remembers the status and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush lets the event stream of /stream through.
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// logRequests logs every request after it is served: server errors at
+// error level, health checks at debug level and the rest at info.
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rl := &requestLog{}
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestLogKey{}, rl)))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case rec.status >= 500:
+			level = slog.LevelError
+		case r.URL.Path == "/healthz" || r.URL.Path == "/readyz":
+			level = slog.LevelDebug
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client", clientAddr(r.RemoteAddr)),
+		}
+		if rl.keyword != "" {
+			attrs = append(attrs, slog.String("keyword", rl.keyword))
+		}
+		if rl.cache != "" {
+			attrs = append(attrs, slog.String("cache", strings.ToLower(rl.cache)))
+		}
+		logger.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// stream serves GET /stream?keyword=&pages= as Server-Sent Events: a
+// "commit" event for each commit as its page is fetched, a "page" event
+// after each page, then "done", or "error" when a page fails. pages takes
+// the --pages syntax and defaults to every page.
+func (s *server) stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+		return
+	}
+	keyword := r.URL.Query().Get("keyword")
+	if keyword == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("keyword is required"))
+		return
+	}
+	noteRequest(r, keyword, "")
+	pages, err := parsePageRange(r.URL.Query().Get("pages"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, v interface{}) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	err = crawlPagesWith(s.cache.crawl, keyword, pages, allPagesDelay, func(page int, result QueryResult) bool {
+		for _, c := range result.Commits {
+			if !send("commit", c) {
+				return false
+			}
+		}
+		return send("page", map[string]interface{}{
+			"page":         page,
+			"result_count": result.ResultCount,
+			"total_pages":  result.TotalPages,
+		}) && r.Context().Err() == nil
+	})
+	if err != nil {
+		send("error", map[string]string{"error": err.Error()})
+		return
+	}
+	send("done", struct{}{})
+}
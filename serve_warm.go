@@ -0,0 +1,94 @@
+package main
+
+import (
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var warmFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "warm",
+		Value: &cli.StringSlice{},
+		Usage: "keyword searched again every --warm-interval so it is always cached (can be repeated)",
+	},
+	cli.StringFlag{
+		Name:  "warm-file",
+		Usage: "file with keywords to keep warm, one per line (# starts a comment)",
+	},
+	cli.StringFlag{
+		Name:  "warm-pages",
+		Value: "1",
+		Usage: "pages kept warm per keyword: all, N or FIRST-LAST",
+	},
+	cli.DurationFlag{
+		Name:  "warm-interval",
+		Value: 30 * time.Minute,
+		Usage: "how often the warm keywords are searched again; keep it below --cache-ttl",
+	},
+}
+
+// warmKeywords returns the keywords of --warm and --warm-file.
+func warmKeywords(c *cli.Context) ([]string, error) {
+	keywords := c.StringSlice("warm")
+	if path := c.String("warm-file"); path != "" {
+		fromFile, err := readKeywordsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, fromFile...)
+	}
+	return keywords, nil
+}
+
+// refresh searches the backend for the page, bypassing the caches, and
+// caches the result.
+func (rc *resultCache) refresh(keyword string, page int) (QueryResult, error) {
+	result, err := cachedCrawl(keyword, page, 0)
+	if err == nil {
+		rc.remember(resultCacheKey(keyword, page), result)
+	}
+	return result, err
+}
+
+// warm refreshes the pages of the keywords now and then every interval
+// until stop is closed.
+func (rc *resultCache) warm(keywords []string, pages pageRange, interval time.Duration, stop <-chan struct{}) {
+	for {
+		start := time.Now()
+		refreshed := 0
+		breaker := &circuitBreaker{}
+		for i, keyword := range keywords {
+			if i > 0 {
+				select {
+				case <-time.After(allPagesDelay):
+				case <-stop:
+					return
+				}
+			}
+			err := crawlPagesWith(rc.refresh, keyword, pages, allPagesDelay, func(page int, result QueryResult) bool {
+				refreshed++
+				select {
+				case <-stop:
+					return false
+				default:
+					return true
+				}
+			})
+			if err != nil {
+				logger.Warn("warming failed", "keyword", keyword, "error", err)
+			}
+			if breaker.record(err) {
+				logger.Warn("warming stopped", "error", breaker.err(i+1, len(keywords)))
+				break
+			}
+		}
+		logger.Info("cache warmed", "keywords", len(keywords), "pages", refreshed, "duration", time.Since(start).String())
+
+		select {
+		case <-time.After(interval):
+		case <-stop:
+			return
+		}
+	}
+}
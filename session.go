@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+const sessionFile = "session.json"
+
+// session is the most recent search result as shown, kept so that
+// follow-up commands (open, copy, bookmark add, patch) can refer to its
+// commits by number without fetching again.
+type session struct {
+	Keyword     string    `json:"keyword"`
+	Page        int       `json:"page"`
+	URL         string    `json:"url"`
+	ResultCount string    `json:"result_count"`
+	TotalPages  string    `json:"total_pages"`
+	Commits     []*commit `json:"commits"`
+	Saved       time.Time `json:"saved"`
+	// Name and Flags are set for sessions saved with --save-session, Flags
+	// holding the search flags the result was filtered with.
+	Name  string   `json:"name,omitempty"`
+	Flags []string `json:"flags,omitempty"`
+}
+
+func newSession(keyword string, page int, result QueryResult) *session {
+	return &session{
+		Keyword:     keyword,
+		Page:        page,
+		URL:         buildUrl(keyword, page),
+		ResultCount: result.ResultCount,
+		TotalPages:  result.TotalPages,
+		Commits:     result.Commits,
+		Saved:       time.Now(),
+	}
+}
+
+func saveSession(keyword string, page int, result QueryResult) error {
+	return saveJSON(dataPath(sessionFile), newSession(keyword, page, result))
+}
+
+func loadSession() (*session, error) {
+	s := &session{}
+	if err := loadJSON(dataPath(sessionFile), s); err != nil {
+		return nil, err
+	}
+	if len(s.Commits) == 0 {
+		return nil, fmt.Errorf("no previous search results")
+	}
+	return s, nil
+}
+
+// lastCommit returns the n-th (1-based) commit of the last search.
+func lastCommit(n int) (*commit, error) {
+	s, err := loadSession()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(s.Commits) {
+		return nil, fmt.Errorf("no such result: %d (last search %q has %d results)", n, s.Keyword, len(s.Commits))
+	}
+	return s.Commits[n-1], nil
+}
+
+// lastCommitCommand builds a command running fn on the numbered commit of
+// the last search.
+func lastCommitCommand(name, usage string, fn func(*commit) error) cli.Command {
+	return cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: "number",
+		Action: func(c *cli.Context) {
+			n, err := strconv.Atoi(c.Args().First())
+			if err != nil {
+				cli.ShowCommandHelp(c, name)
+				os.Exit(1)
+			}
+			commit, err := lastCommit(n)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if err := fn(commit); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+var openCommand = lastCommitCommand("open", "open the numbered commit of the last search in the browser", func(c *commit) error {
+	return openBrowser(c.displayURL())
+})
+
+var copyCommand = lastCommitCommand("copy", "copy the message of the numbered commit of the last search", func(c *commit) error {
+	if err := copyToClipboard(c.Message); err != nil {
+		return err
+	}
+	fmt.Println("copied:", c.Message)
+	return nil
+})
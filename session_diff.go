@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+)
+
+// sessionDiff is the change in the results of a query between two runs.
+type sessionDiff struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	FromSaved time.Time `json:"from_saved"`
+	ToSaved   time.Time `json:"to_saved"`
+	Added     []*commit `json:"added"`
+	Removed   []*commit `json:"removed"`
+	Unchanged int       `json:"unchanged"`
+}
+
+func sessionLabel(s *session) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return "current"
+}
+
+func diffSessions(from, to *session) sessionDiff {
+	d := sessionDiff{
+		From: sessionLabel(from), To: sessionLabel(to),
+		FromSaved: from.Saved, ToSaved: to.Saved,
+		Added: []*commit{}, Removed: []*commit{},
+	}
+	before := map[string]bool{}
+	for _, c := range from.Commits {
+		before[commitKey(c)] = true
+	}
+	after := map[string]bool{}
+	for _, c := range to.Commits {
+		after[commitKey(c)] = true
+		if before[commitKey(c)] {
+			d.Unchanged++
+		} else {
+			d.Added = append(d.Added, c)
+		}
+	}
+	for _, c := range from.Commits {
+		if !after[commitKey(c)] {
+			d.Removed = append(d.Removed, c)
+		}
+	}
+	return d
+}
+
+func showSessionDiff(d sessionDiff, asJSON bool) {
+	if asJSON {
+		printJSON(d)
+		return
+	}
+	fmt.Printf("%s (%s) -> %s (%s): +%d -%d, %d unchanged\n\n",
+		d.From, d.FromSaved.Format("2006-01-02 15:04"), d.To, d.ToSaved.Format("2006-01-02 15:04"),
+		len(d.Added), len(d.Removed), d.Unchanged)
+	for _, change := range []struct {
+		sign    string
+		paint   colorFunc
+		commits []*commit
+	}{{"+", color.GreenString, d.Added}, {"-", color.RedString, d.Removed}} {
+		for _, c := range change.commits {
+			fmt.Fprintf(color.Output, "%s %s %s %s\n", change.paint(change.sign), theme.repo("%s", c.Repo), theme.sha1("%s", c.Sha1), c.Message)
+		}
+	}
+}
+
+var diffCommand = cli.Command{
+	Name:         "diff",
+	Usage:        "report the commits added and removed between two sessions saved with --save-session",
+	ArgsUsage:    "old new",
+	BashComplete: completeNamedSessions,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the changes as json",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 2 {
+			cli.ShowCommandHelp(c, "diff")
+			os.Exit(exitUsage)
+		}
+		sessions := []*session{}
+		for _, name := range c.Args() {
+			s, err := loadNamedSession(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			sessions = append(sessions, s)
+		}
+		if sessions[0].Keyword != sessions[1].Keyword {
+			logger.Warn("the sessions are of different keywords", "old", sessions[0].Keyword, "new", sessions[1].Keyword)
+		}
+		showSessionDiff(diffSessions(sessions[0], sessions[1]), c.Bool("json"))
+	},
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var sexpEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+// writeSexp writes v, as it is encoded in json, as an Emacs Lisp form read
+// by (read): objects become plists with keyword keys (commit_url becomes
+// :commit-url), arrays lists, null and false nil, and true t.
+func writeSexp(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	out := bufio.NewWriter(w)
+	if err := writeSexpValue(out, dec); err != nil {
+		return err
+	}
+	out.WriteString("\n")
+	return out.Flush()
+}
+
+func writeSexpValue(w *bufio.Writer, dec *json.Decoder) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		w.WriteString("(")
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				w.WriteString(" ")
+			}
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, ":%s ", strings.Replace(key.(string), "_", "-", -1))
+			}
+			if err := writeSexpValue(w, dec); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		w.WriteString(")")
+	case string:
+		w.WriteString(`"` + sexpEscaper.Replace(t) + `"`)
+	case json.Number:
+		w.WriteString(t.String())
+	case bool:
+		if t {
+			w.WriteString("t")
+		} else {
+			w.WriteString("nil")
+		}
+	case nil:
+		w.WriteString("nil")
+	}
+	return nil
+}
+
+func showResultAsSexp(result QueryResult, url string, page int, err error) {
+	if werr := writeSexp(os.Stdout, jsonResult(result, url, page, err)); werr != nil {
+		fmt.Fprintln(os.Stderr, werr)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteSexp(t *testing.T) {
+	var b bytes.Buffer
+	err := writeSexp(&b, JsonFormat{Commits: []*commit{
+		{Repo: "octo/cat", Sha1: "0123456", CommitURL: "https://github.com/octo/cat/commit/0123456", Message: `Say "hi" \o/`, Body: "line 1\nline 2", Stars: 3, Dead: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `(:schema-version 0 :commits ((:repo "octo/cat" :repo-url "" :sha1 "0123456" :commit-url "https://github.com/octo/cat/commit/0123456" :message "Say \"hi\" \\o/" :body "line 1\nline 2" :dead t :stars 3)) :error "" :result-count 0 :page 0 :total-pages 0 :url "")` + "\n"
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	writeSexp(&b, map[string]interface{}{"list": []int{}, "none": nil, "no": false})
+	if want := "(:list () :no nil :none nil)\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
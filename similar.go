@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+)
+
+type similarCommit struct {
+	*commit
+	Similarity float64 `json:"similarity"`
+}
+
+var similarCommand = cli.Command{
+	Name:      "similar",
+	Usage:     "search the words of a draft message and rank the results by similarity to it",
+	ArgsUsage: "\"draft message\"",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "keywords, k",
+			Value: 3,
+			Usage: "number of words of the draft searched for",
+		},
+		cli.IntFlag{
+			Name:  "limit, n",
+			Value: 10,
+			Usage: "number of messages printed",
+		},
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: 24 * time.Hour,
+			Usage: "reuse cached pages fetched within this duration",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the messages as json",
+		},
+	},
+	Action: func(c *cli.Context) {
+		draft := strings.Join(c.Args(), " ")
+		words := draftKeywords(draft)
+		if len(words) == 0 {
+			cli.ShowCommandHelp(c, "similar")
+			os.Exit(1)
+		}
+		if len(words) > c.Int("keywords") {
+			words = words[:c.Int("keywords")]
+		}
+		if !c.Bool("json") {
+			fmt.Fprintf(os.Stderr, "keywords: %s\n\n", strings.Join(words, ", "))
+		}
+
+		similar, err := similarMessages(draft, words, c.Duration("cache-ttl"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			if len(similar) == 0 {
+				os.Exit(failureExitCode(err))
+			}
+		}
+		if len(similar) > c.Int("limit") {
+			similar = similar[:c.Int("limit")]
+		}
+		if c.Bool("json") {
+			printJSON(similar)
+			return
+		}
+		if len(similar) == 0 {
+			fmt.Println(tr("No Results Found."))
+			return
+		}
+		for i, s := range similar {
+			fmt.Fprintf(color.Output, "%3d. %3.0f%%  %s  %s\n",
+				i+1, 100*s.Similarity, highlightWords(s.Message, strings.Join(words, " ")), theme.repo("(%s)", s.Repo))
+		}
+	},
+}
+
+// stem strips the common English inflections, so "fixing", "fixed" and
+// "fixes" all become "fix".
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 && !strings.HasSuffix(word, "ss") {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// stemmedWords returns the distinct stems of the message, without stop
+// words, in order.
+func stemmedWords(message string) []string {
+	seen := map[string]bool{}
+	words := []string{}
+	for _, t := range tokenize(message) {
+		if messageStopWords[t] || len([]rune(t)) < 3 {
+			continue
+		}
+		if s := stem(t); !seen[s] {
+			seen[s] = true
+			words = append(words, s)
+		}
+	}
+	return words
+}
+
+// draftKeywords returns the words of the draft worth searching for,
+// longest first as they tend to be the most specific.
+func draftKeywords(draft string) []string {
+	words := stemmedWords(draft)
+	sort.SliceStable(words, func(i, j int) bool {
+		return len([]rune(words[i])) > len([]rune(words[j]))
+	})
+	return words
+}
+
+// trigrams returns the character trigrams of the message.
+func trigrams(message string) map[string]bool {
+	runes := []rune(" " + strings.Join(tokenize(message), " ") + " ")
+	grams := map[string]bool{}
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = true
+	}
+	return grams
+}
+
+// dice is the Dice coefficient of the two sets.
+func dice(a, b map[string]bool) float64 {
+	if len(a)+len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+func wordSet(words []string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// similarity scores from 0 to 1 how close the message is to the draft, by
+// the words they share and, to catch different spellings, by their
+// character trigrams.
+func similarity(draft, message string) float64 {
+	words := dice(wordSet(stemmedWords(draft)), wordSet(stemmedWords(message)))
+	return 0.6*words + 0.4*dice(trigrams(draft), trigrams(message))
+}
+
+// similarMessages searches each word and ranks the distinct messages found
+// by similarity to the draft.
+func similarMessages(draft string, words []string, ttl time.Duration) ([]*similarCommit, error) {
+	byMessage := map[string]*similarCommit{}
+	var lastErr error
+	for _, word := range words {
+		result, err := cachedCrawl(word, 1, ttl)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, c := range result.Commits {
+			if _, ok := byMessage[c.Message]; !ok {
+				byMessage[c.Message] = &similarCommit{commit: c, Similarity: similarity(draft, c.Message)}
+			}
+		}
+	}
+
+	similar := []*similarCommit{}
+	for _, s := range byMessage {
+		similar = append(similar, s)
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Similarity != similar[j].Similarity {
+			return similar[i].Similarity > similar[j].Similarity
+		}
+		return similar[i].Message < similar[j].Message
+	})
+	return similar, lastErr
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	for word, want := range map[string]string{
+		"fixing":  "fix",
+		"fixed":   "fix",
+		"fixes":   "fix",
+		"typos":   "typo",
+		"class":   "class",
+		"bus":     "bus",
+		"address": "address",
+	} {
+		if got := stem(word); got != want {
+			t.Errorf("stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestDraftKeywords(t *testing.T) {
+	got := draftKeywords("Fix the typo in the configuration loader, fixing typos")
+	want := []string{"configuration", "loader", "typo", "fix"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("draftKeywords = %q, want %q", got, want)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	const draft = "Fix typo in README"
+	if s := similarity(draft, draft); s != 1 {
+		t.Errorf("identical messages: %v, want 1", s)
+	}
+	if s := similarity(draft, "fixed typos in readme"); s < 0.5 || s >= 1 {
+		t.Errorf("inflected message: %v, want in [0.5, 1)", s)
+	}
+	if s := similarity(draft, "bump version"); s != 0 {
+		t.Errorf("disjoint messages: %v, want 0", s)
+	}
+	if s := similarity("", ""); s != 0 {
+		t.Errorf("empty messages: %v, want 0", s)
+	}
+}
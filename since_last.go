@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+)
+
+const lastSeenDir = "seen"
+
+// lastSeen is the set of commits a query returned the previous time it ran
+// with --since-last.
+type lastSeen struct {
+	Query   string          `json:"query"`
+	Commits map[string]bool `json:"commits"`
+	Updated time.Time       `json:"updated"`
+}
+
+func lastSeenFile(query string) string {
+	sum := sha1.Sum([]byte(query))
+	return dataPath(lastSeenDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// newSinceLast returns the commits that were not in the previous result of
+// the query, and remembers the current result for the next run.
+func newSinceLast(query string, commits []*commit) ([]*commit, error) {
+	previous := &lastSeen{}
+	if err := loadJSON(lastSeenFile(query), previous); err != nil {
+		return nil, err
+	}
+
+	current := &lastSeen{Query: query, Commits: map[string]bool{}, Updated: time.Now()}
+	fresh := []*commit{}
+	for _, c := range commits {
+		key := commitKey(c)
+		current.Commits[key] = true
+		if !previous.Commits[key] {
+			fresh = append(fresh, c)
+		}
+	}
+	return fresh, saveJSON(lastSeenFile(query), current)
+}
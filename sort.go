@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// sortKey is a key of --sort, descending when written -key.
+type sortKey struct {
+	name       string
+	descending bool
+}
+
+// sortComparisons compare two commits by a key of --sort. Text is compared
+// with the collator, so accented and Japanese text orders as in the locale.
+var sortComparisons = map[string]func(a, b *commit, col *collate.Collator) int{
+	"repo": func(a, b *commit, col *collate.Collator) int {
+		return col.CompareString(a.Repo, b.Repo)
+	},
+	"message": func(a, b *commit, col *collate.Collator) int {
+		return col.CompareString(firstLine(a.Message), firstLine(b.Message))
+	},
+	"length": func(a, b *commit, _ *collate.Collator) int {
+		return utf8.RuneCountInString(firstLine(a.Message)) - utf8.RuneCountInString(firstLine(b.Message))
+	},
+	"author": func(a, b *commit, col *collate.Collator) int {
+		return col.CompareString(a.Author, b.Author)
+	},
+	// dates from GitHub are all in UTC, so they compare as strings
+	"date": func(a, b *commit, _ *collate.Collator) int {
+		return strings.Compare(a.Date, b.Date)
+	},
+	"stars": func(a, b *commit, _ *collate.Collator) int {
+		return a.Stars - b.Stars
+	},
+}
+
+func sortKeyNames() []string {
+	names := []string{}
+	for name := range sortComparisons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseSortKeys parses the comma separated keys of --sort, e.g.
+// "repo,-length".
+func parseSortKeys(spec string) ([]sortKey, error) {
+	keys := []sortKey{}
+	for _, name := range strings.Split(spec, ",") {
+		key := sortKey{name: strings.TrimSpace(name)}
+		if strings.HasPrefix(key.name, "-") {
+			key.name, key.descending = key.name[1:], true
+		}
+		if _, ok := sortComparisons[key.name]; !ok {
+			return nil, fmt.Errorf("unknown sort key %q, one of %s", key.name, strings.Join(sortKeyNames(), ", "))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func usesSortKey(keys []sortKey, names ...string) bool {
+	for _, key := range keys {
+		if contains(names, key.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// collationLocale is the locale of LC_ALL, LC_COLLATE or LANG, e.g. ja for
+// ja_JP.UTF-8, or the root collation for C, POSIX and unknown locales.
+func collationLocale() language.Tag {
+	for _, name := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		if i := strings.IndexAny(locale, ".@"); i >= 0 {
+			locale = locale[:i]
+		}
+		tag, err := language.Parse(strings.Replace(locale, "_", "-", -1))
+		if err != nil {
+			return language.Und
+		}
+		return tag
+	}
+	return language.Und
+}
+
+// sortCommits orders the commits by the keys, the later keys breaking the
+// ties of the earlier, keeping the order of commits equal on every key.
+func sortCommits(commits []*commit, keys []sortKey, locale language.Tag) {
+	col := collate.New(locale)
+	sort.SliceStable(commits, func(i, j int) bool {
+		for _, key := range keys {
+			d := sortComparisons[key.name](commits[i], commits[j], col)
+			if key.descending {
+				d = -d
+			}
+			if d != 0 {
+				return d < 0
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestSortCommits(t *testing.T) {
+	commits := func() []*commit {
+		return []*commit{
+			{Repo: "zeta/app", Message: "fix typo"},
+			{Repo: "Émile/app", Message: "fix the typo"},
+			{Repo: "emile/app", Message: "typo"},
+			{Repo: "alpha/app", Message: "fix typo in docs"},
+			{Repo: "zeta/app", Message: "typo"},
+		}
+	}
+	order := func(commits []*commit) string {
+		parts := []string{}
+		for _, c := range commits {
+			parts = append(parts, c.Repo+" "+c.Message)
+		}
+		return strings.Join(parts, "|")
+	}
+	for _, tt := range []struct {
+		spec string
+		want string
+	}{
+		// accented letters sort with their base letter, not after z
+		{"repo", "alpha/app fix typo in docs|emile/app typo|Émile/app fix the typo|zeta/app fix typo|zeta/app typo"},
+		{"repo,-length", "alpha/app fix typo in docs|emile/app typo|Émile/app fix the typo|zeta/app fix typo|zeta/app typo"},
+		{"-repo,length", "zeta/app typo|zeta/app fix typo|Émile/app fix the typo|emile/app typo|alpha/app fix typo in docs"},
+		{"length,message", "emile/app typo|zeta/app typo|zeta/app fix typo|Émile/app fix the typo|alpha/app fix typo in docs"},
+	} {
+		keys, err := parseSortKeys(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs := commits()
+		sortCommits(cs, keys, language.English)
+		if got := order(cs); got != tt.want {
+			t.Errorf("--sort %s:\n got %s\nwant %s", tt.spec, got, tt.want)
+		}
+	}
+	if _, err := parseSortKeys("repo,size"); err == nil {
+		t.Error("unknown sort key accepted")
+	}
+}
+
+func TestSortJapanese(t *testing.T) {
+	// katakana sorts with hiragana by sound, where bytes put it after
+	cs := []*commit{{Message: "いどう"}, {Message: "アプリ"}, {Message: "うごく"}}
+	keys, _ := parseSortKeys("message")
+	sortCommits(cs, keys, language.Japanese)
+	got := cs[0].Message + "|" + cs[1].Message + "|" + cs[2].Message
+	if want := "アプリ|いどう|うごく"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	sourcegraphDefaultEndpoint = "https://sourcegraph.com"
+	sourcegraphSearchPerPage   = 20
+)
+
+const sourcegraphQuery = `query($query: String!) {
+  search(query: $query, version: V3) {
+    results {
+      matchCount
+      limitHit
+      results {
+        ... on CommitSearchResult {
+          commit {
+            abbreviatedOID
+            url
+            message
+            author { person { name } date }
+            repository { name url }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// sourcegraphSearch searches commit messages, or diffs, with the
+// Sourcegraph GraphQL API. filters are added to the query as they are, e.g.
+// "author:alice repo:^github.com/org/".
+type sourcegraphSearch struct {
+	endpoint string
+	token    string
+	filters  string
+	diff     bool
+}
+
+type sourcegraphResponse struct {
+	Data struct {
+		Search struct {
+			Results struct {
+				MatchCount int  `json:"matchCount"`
+				LimitHit   bool `json:"limitHit"`
+				Results    []struct {
+					Commit *struct {
+						AbbreviatedOID string `json:"abbreviatedOID"`
+						URL            string `json:"url"`
+						Message        string `json:"message"`
+						Author         struct {
+							Person struct {
+								Name string `json:"name"`
+							} `json:"person"`
+							Date string `json:"date"`
+						} `json:"author"`
+						Repository struct {
+							Name string `json:"name"`
+							URL  string `json:"url"`
+						} `json:"repository"`
+					} `json:"commit"`
+				} `json:"results"`
+			} `json:"results"`
+		} `json:"search"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func newSourcegraphSearch(endpoint, token, filters string, diff bool) *sourcegraphSearch {
+	return &sourcegraphSearch{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    token,
+		filters:  filters,
+		diff:     diff,
+	}
+}
+
+func (s *sourcegraphSearch) Name() string {
+	return "sourcegraph"
+}
+
+// query is the Sourcegraph search query for keyword. Sourcegraph has no
+// offset, so count asks for everything up to the end of the page.
+func (s *sourcegraphSearch) query(keyword string, page int) string {
+	quoted := strconv.Quote(keyword)
+	q := "type:commit message:" + quoted
+	if s.diff {
+		q = "type:diff " + quoted
+	}
+	if s.filters != "" {
+		q += " " + s.filters
+	}
+	return fmt.Sprintf("%s count:%d", q, page*sourcegraphSearchPerPage)
+}
+
+func (s *sourcegraphSearch) URL(keyword string, page int) string {
+	return s.endpoint + "/search?q=" + url.QueryEscape(s.query(keyword, page))
+}
+
+func (s *sourcegraphSearch) Search(ctx context.Context, keyword string, page int) (QueryResult, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     sourcegraphQuery,
+		"variables": map[string]string{"query": s.query(keyword, page)},
+	})
+	if err != nil {
+		return QueryResult{Commits: []*commit{}}, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		header.Set("Authorization", "token "+s.token)
+	}
+	res := &sourcegraphResponse{}
+	if _, err := doJSON(ctx, "POST", s.endpoint+"/.api/graphql", header, bytes.NewReader(body), res); err != nil {
+		return QueryResult{Commits: []*commit{}}, fmt.Errorf("sourcegraph: %s", err)
+	}
+	if len(res.Errors) > 0 {
+		return QueryResult{Commits: []*commit{}}, fmt.Errorf("sourcegraph: %s", res.Errors[0].Message)
+	}
+
+	results := res.Data.Search.Results
+	commits := []*commit{}
+	for i, r := range results.Results {
+		if r.Commit == nil || i < (page-1)*sourcegraphSearchPerPage {
+			continue
+		}
+		commits = append(commits, &commit{
+			Repo:      r.Commit.Repository.Name,
+			RepoURL:   s.endpoint + r.Commit.Repository.URL,
+			Sha1:      r.Commit.AbbreviatedOID,
+			CommitURL: s.endpoint + r.Commit.URL,
+			Message:   firstLine(r.Commit.Message),
+			Body:      messageBody(r.Commit.Message),
+			Author:    r.Commit.Author.Person.Name,
+			Date:      r.Commit.Author.Date,
+			Source:    "sourcegraph",
+		})
+	}
+
+	pages := page
+	if results.LimitHit {
+		pages++
+	}
+	return QueryResult{
+		Commits:     commits,
+		ResultCount: fmt.Sprintf("%d results", results.MatchCount),
+		TotalPages:  strconv.Itoa(pages),
+	}, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const (
+	starsCacheFile = "stars.json"
+	starsCacheTTL  = 24 * time.Hour
+)
+
+var repoURLPattern = regexp.MustCompile(`github\.com/([^/]+)/([^/#?]+)`)
+
+type starsEntry struct {
+	Stars   int       `json:"stars"`
+	Fetched time.Time `json:"fetched"`
+}
+
+type githubRepo struct {
+	StargazersCount int `json:"stargazers_count"`
+}
+
+// repoPath returns "owner/repo" for the commit's GitHub repository.
+func repoPath(c *commit) (string, bool) {
+	for _, u := range []string{c.RepoURL, c.CommitURL} {
+		if m := repoURLPattern.FindStringSubmatch(u); m != nil {
+			return m[1] + "/" + m[2], true
+		}
+	}
+	return "", false
+}
+
+// fetchStars sets the star count of each commit's repository, looking each
+// repository up once and caching the counts on disk for a day.
+func (g *githubClient) fetchStars(commits []*commit) error {
+	cache := map[string]*starsEntry{}
+	if err := loadJSON(cachePath(starsCacheFile), &cache); err != nil {
+		return err
+	}
+
+	var lookupErr error
+	for _, c := range commits {
+		repo, ok := repoPath(c)
+		if !ok {
+			continue
+		}
+		entry, ok := cache[repo]
+		if !ok || time.Since(entry.Fetched) > starsCacheTTL {
+			r := &githubRepo{}
+			if err := g.get(fmt.Sprintf("/repos/%s", repo), r); err != nil {
+				lookupErr = err
+				continue
+			}
+			entry = &starsEntry{Stars: r.StargazersCount, Fetched: time.Now()}
+			cache[repo] = entry
+		}
+		c.Stars = entry.Stars
+	}
+
+	if err := saveJSON(cachePath(starsCacheFile), cache); err != nil {
+		return err
+	}
+	return lookupErr
+}
+
+// filterMinStars drops the commits of repositories with fewer than min
+// stars, and of repositories not on GitHub.
+func filterMinStars(commits []*commit, min int) []*commit {
+	filtered := []*commit{}
+	for _, c := range commits {
+		if c.Stars >= min {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+func rankByStars(commits []*commit) {
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Stars > commits[j].Stars
+	})
+}
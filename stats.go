@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+)
+
+// analysisFlags are the flags of the commands analysing every result of a
+// keyword.
+var analysisFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "pages",
+		Value: "all",
+		Usage: "result pages analysed: all, N or FIRST-LAST",
+	},
+	cli.DurationFlag{
+		Name:  "cache-ttl",
+		Value: 24 * time.Hour,
+		Usage: "reuse cached pages fetched within this duration",
+	},
+	cli.BoolFlag{
+		Name:  "json",
+		Usage: "print the report as json",
+	},
+}
+
+// analysedCommits fetches the pages of --pages for the keyword, from the
+// cache when possible, reporting progress on stderr.
+func analysedCommits(c *cli.Context, keyword string) ([]*commit, error) {
+	pages, err := parsePageRange(c.String("pages"))
+	if err != nil {
+		return nil, err
+	}
+	ttl := c.Duration("cache-ttl")
+	commits := []*commit{}
+	err = crawlPagesWith(func(keyword string, page int) (QueryResult, error) {
+		return cachedCrawl(keyword, page, ttl)
+	}, keyword, pages, allPagesDelay, func(page int, result QueryResult) bool {
+		commits = append(commits, result.Commits...)
+		fmt.Fprintf(os.Stderr, "\rpage %d/%s, %d commits", page, result.TotalPages, len(commits))
+		return true
+	})
+	fmt.Fprintln(os.Stderr)
+	return commits, err
+}
+
+// analysisKeyword returns the keyword argument of an analysis command,
+// showing its help when there is none.
+func analysisKeyword(c *cli.Context, command string) string {
+	keyword := strings.Join(c.Args(), " ")
+	if keyword == "" {
+		cli.ShowCommandHelp(c, command)
+		os.Exit(1)
+	}
+	return keyword
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// bar is a horizontal bar of width cells for value out of max.
+func bar(value, max, width int) string {
+	if max == 0 {
+		return ""
+	}
+	n := value * width / max
+	if n == 0 && value > 0 {
+		n = 1
+	}
+	return strings.Repeat("█", n)
+}
+
+type repoCount struct {
+	Repo    string  `json:"repo"`
+	Commits int     `json:"commits"`
+	Percent float64 `json:"percent"`
+}
+
+var statsCommand = cli.Command{
+	Name:      "stats",
+	Usage:     "report the repositories with the most commits matching a keyword, or the languages of the messages",
+	ArgsUsage: "keyword",
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "top, n",
+			Value: 20,
+			Usage: "number of repositories reported",
+		},
+		cli.BoolFlag{
+			Name:  "languages",
+			Usage: "report the human languages the messages are written in instead",
+		},
+	}, analysisFlags...),
+	Action: func(c *cli.Context) {
+		keyword := analysisKeyword(c, "stats")
+		commits, err := analysedCommits(c, keyword)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			if len(commits) == 0 {
+				os.Exit(failureExitCode(err))
+			}
+		}
+
+		if c.Bool("languages") {
+			languages := languageBreakdown(commits)
+			if c.Bool("json") {
+				printJSON(map[string]interface{}{"keyword": keyword, "commits": len(commits), "languages": languages})
+				return
+			}
+			if len(languages) == 0 {
+				fmt.Println(tr("No Results Found."))
+				return
+			}
+			printLanguageBreakdown(languages)
+			fmt.Printf("\n%d commits\n", len(commits))
+			return
+		}
+
+		repos := topRepositories(commits)
+		if n := c.Int("top"); n > 0 && len(repos) > n {
+			repos = repos[:n]
+		}
+		if c.Bool("json") {
+			printJSON(map[string]interface{}{"keyword": keyword, "commits": len(commits), "repositories": repos})
+			return
+		}
+		if len(repos) == 0 {
+			fmt.Println(tr("No Results Found."))
+			return
+		}
+		width := 0
+		for _, r := range repos {
+			if w := runewidth.StringWidth(r.Repo); w > width {
+				width = w
+			}
+		}
+		for i, r := range repos {
+			fmt.Fprintf(color.Output, "%3d. %s %6d %5.1f%%  %s\n",
+				i+1, theme.repo("%s", runewidth.FillRight(r.Repo, width)), r.Commits, r.Percent, bar(r.Commits, repos[0].Commits, 30))
+		}
+		fmt.Printf("\n%d commits in %d repositories\n", len(commits), len(topRepositories(commits)))
+	},
+}
+
+// topRepositories counts the commits of each repository, most first.
+func topRepositories(commits []*commit) []repoCount {
+	counts := map[string]int{}
+	for _, c := range commits {
+		counts[c.Repo]++
+	}
+	repos := []repoCount{}
+	for repo, n := range counts {
+		repos = append(repos, repoCount{Repo: repo, Commits: n, Percent: 100 * float64(n) / float64(len(commits))})
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].Commits != repos[j].Commits {
+			return repos[i].Commits > repos[j].Commits
+		}
+		return repos[i].Repo < repos[j].Repo
+	})
+	return repos
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuroyoro/gommit-m/parser"
+	"golang.org/x/net/html"
+)
+
+var resultCountText = regexp.MustCompile(`(\d+) results`)
+
+// pageSummary is what streamCommitRows finds on a page besides the commits.
+type pageSummary struct {
+	ResultCount string
+	TotalPages  string
+	Commits     int
+}
+
+func hasClass(z *html.Tokenizer, class string) bool {
+	for {
+		key, val, more := z.TagAttr()
+		if string(key) == "class" {
+			for _, c := range strings.Fields(string(val)) {
+				if c == class {
+					return true
+				}
+			}
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+func tagAttr(z *html.Tokenizer, name string) string {
+	for {
+		key, val, more := z.TagAttr()
+		if string(key) == name {
+			return string(val)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// streamCommitRows reads the search page token by token, handing each
+// commit of the result table to fn as soon as its row ends, so no document
+// tree nor list of commits is kept in memory. It understands the markup
+// the table rows of parser.Parse expect, without its fallbacks.
+func streamCommitRows(r io.Reader, fn func(c *commit) error) (pageSummary, error) {
+	summary := pageSummary{TotalPages: "1"}
+	z := html.NewTokenizer(r)
+	tableDepth := 0 // nesting of table.table
+	inPagination := false
+	cell := -1 // index of the td of the current row, -1 outside of cells
+	var cells [3]strings.Builder
+	var hrefs []string
+	lastItem := &strings.Builder{} // text of the last li of the pagination
+	item := &strings.Builder{}
+	inItem := false
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return summary, nil
+			}
+			return summary, z.Err()
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "table":
+				if tableDepth > 0 || hasAttr && hasClass(z, "table") {
+					tableDepth++
+				}
+			case "tr":
+				if tableDepth > 0 {
+					cell = -1
+					hrefs = hrefs[:0]
+					for i := range cells {
+						cells[i].Reset()
+					}
+				}
+			case "td":
+				if tableDepth > 0 {
+					cell++
+				}
+			case "a":
+				if tableDepth > 0 && cell >= 0 && cell < len(cells) && hasAttr {
+					if href := strings.TrimSpace(tagAttr(z, "href")); href != "" {
+						hrefs = append(hrefs, href)
+					}
+				}
+			case "ul":
+				if hasAttr && hasClass(z, "pagination") {
+					inPagination = true
+				}
+			case "li":
+				if inPagination {
+					if hasAttr && hasClass(z, "next_page") {
+						if pages := parser.CleanText(lastItem.String()); pages != "" {
+							summary.TotalPages = pages
+						}
+					}
+					item.Reset()
+					inItem = true
+				}
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "table":
+				if tableDepth > 0 {
+					tableDepth--
+				}
+			case "tr":
+				if tableDepth > 0 && cell >= 0 {
+					c := &commit{
+						Message: parser.CleanText(cells[0].String()),
+						Repo:    parser.CleanText(cells[1].String()),
+						Sha1:    parser.CleanText(cells[2].String()),
+					}
+					if len(hrefs) > 0 {
+						c.RepoURL = hrefs[0]
+					}
+					if len(hrefs) > 1 {
+						c.CommitURL = hrefs[1]
+					}
+					cell = -1
+					if c.Sha1 != "" {
+						summary.Commits++
+						if err := fn(c); err != nil {
+							return summary, err
+						}
+					}
+				}
+			case "ul":
+				inPagination = false
+			case "li":
+				if inItem {
+					lastItem, item = item, lastItem
+					inItem = false
+				}
+			}
+
+		case html.TextToken:
+			text := z.Text()
+			switch {
+			case tableDepth > 0 && cell >= 0 && cell < len(cells):
+				cells[cell].Write(text)
+			case inItem:
+				item.Write(text)
+			case tableDepth == 0 && summary.ResultCount == "":
+				if m := resultCountText.FindString(string(text)); m != "" {
+					summary.ResultCount = m
+				}
+			}
+		}
+	}
+}
+
+// streamPages fetches the pages of the range like crawlPages, handing each
+// commit to fn as it is parsed and calling pageDone after each page. The
+// commit-m search pages are streamed without being cached or stored in the
+// database; the other backends, and pages the streaming parser does not
+// understand, go through cachedCrawl.
+func streamPages(keyword string, pages pageRange, delay time.Duration, fn func(page int, c *commit) error, pageDone func(page int) bool) error {
+	last := pages.last
+	for page := pages.first; last == 0 || page <= last; page++ {
+		if page > pages.first && delay > 0 && !pause(delay) {
+			return fmt.Errorf("page %d: %s", page, runContext.Err())
+		}
+		summary, err := streamPage(keyword, page, func(c *commit) error { return fn(page, c) })
+		if err != nil {
+			return fmt.Errorf("page %d: %s", page, err)
+		}
+		if total, err := strconv.Atoi(summary.TotalPages); err == nil && (last == 0 || total < last) {
+			last = total
+		}
+		if !pageDone(page) || summary.Commits == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+func streamPage(keyword string, page int, fn func(c *commit) error) (pageSummary, error) {
+	ctx := runContext
+	if _, ok := backend.(commitM); ok && commitMAPI(ctx) == "" {
+		url := buildUrl(keyword, page)
+		res, err := fetchPage(ctx, url)
+		if err != nil {
+			return pageSummary{}, err
+		}
+		summary, err := streamCommitRows(res.Body, fn)
+		closeBody(res.Body)
+		if err != nil || summary.Commits > 0 || summary.ResultCount != "" {
+			return summary, err
+		}
+		debugf("%s: nothing found by the streaming parser, parsing the page again", url)
+	}
+
+	result, err := cachedCrawl(keyword, page, 0)
+	if err != nil {
+		return pageSummary{}, err
+	}
+	for _, c := range result.Commits {
+		if err := fn(c); err != nil {
+			return pageSummary{}, err
+		}
+	}
+	return pageSummary{ResultCount: result.ResultCount, TotalPages: result.TotalPages, Commits: len(result.Commits)}, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const searchPage = `<html><body>
+<div class="container">
+<table class="table">
+<tr><th>message</th><th>repository</th><th>sha1</th></tr>
+<tr>
+  <td>Fix &amp;amp; escape
+      in  templates&nbsp;</td>
+  <td><a href="https://github.com/octo/cat">octo/cat</a></td>
+  <td><a href=" https://github.com/octo/cat/commit/0123456 ">0123456</a></td>
+</tr>
+<tr>
+  <td>zero&#8203;width</td>
+  <td><a href="https://github.com/octo/dog">octo&#8203;/dog</a></td>
+  <td><a href="https://github.com/octo/dog/commit/89abcde">89abcde</a></td>
+</tr>
+<tr><td>a row without a sha1</td><td></td><td></td></tr>
+</table>
+</div>
+</body></html>`
+
+func TestStreamCommitRows(t *testing.T) {
+	for _, page := range []string{searchPage, `<div class="container">
+		43 results
+		<table class="table"><tr><th>message</th></tr>
+		<tr><td>one</td><td><a href="https://github.com/a/b">a/b</a></td><td><a href="https://github.com/a/b/commit/1111111">1111111</a></td></tr>
+		</table>
+		<ul class="pagination"><li class="active"><a>1</a></li><li><a>2</a></li><li><a>3</a></li><li class="next next_page"><a rel="next">Next</a></li></ul>
+		</div>`} {
+		want, err := parseSearchPage("", strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []*commit{}
+		summary, err := streamCommitRows(strings.NewReader(page), func(c *commit) error {
+			got = append(got, c)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.ResultCount != want.ResultCount || summary.TotalPages != want.TotalPages || summary.Commits != len(got) {
+			t.Errorf("summary %+v, want %q results, %q pages", summary, want.ResultCount, want.TotalPages)
+		}
+		if len(got) != len(want.Commits) {
+			t.Fatalf("streamed %d commits, parsed %d", len(got), len(want.Commits))
+		}
+		for i := range got {
+			if *got[i] != *want.Commits[i] {
+				t.Errorf("commit %d = %+v, want %+v", i, *got[i], *want.Commits[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+)
+
+type suggestion struct {
+	*commit
+	Score int
+}
+
+var suggestCommand = cli.Command{
+	Name:  "suggest",
+	Usage: "suggest commit messages for the staged changes",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "keywords, k",
+			Value: 3,
+			Usage: "number of keywords derived from the diff to search for",
+		},
+		cli.IntFlag{
+			Name:  "limit, n",
+			Value: 10,
+			Usage: "number of suggestions to print",
+		},
+	},
+	Action: func(c *cli.Context) {
+		words, err := stagedKeywords()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "not a git repository or git failed:", err)
+			os.Exit(1)
+		}
+		if len(words) == 0 {
+			fmt.Fprintln(os.Stderr, "nothing staged")
+			os.Exit(1)
+		}
+		if len(words) > c.Int("keywords") {
+			words = words[:c.Int("keywords")]
+		}
+
+		fmt.Printf("keywords: %s\n\n", strings.Join(words, ", "))
+		suggestions := suggestMessages(words, c.Int("limit"))
+		if len(suggestions) == 0 {
+			fmt.Println("No Results Found.")
+			return
+		}
+		for i, s := range suggestions {
+			fmt.Fprintf(color.Output, "%3d. %s  %s\n",
+				i+1,
+				highlightWords(s.Message, strings.Join(words, " ")),
+				theme.repo("(%s)", s.Repo),
+			)
+		}
+	},
+}
+
+// suggestFromStaged searches commit-m for the top keywords of the staged
+// changes and returns up to limit ranked commits.
+func suggestFromStaged(keywords, limit int) []*suggestion {
+	words, err := stagedKeywords()
+	if err != nil {
+		return nil
+	}
+	if len(words) > keywords {
+		words = words[:keywords]
+	}
+	return suggestMessages(words, limit)
+}
+
+// suggestMessages searches each keyword and ranks the distinct messages by
+// how many of the keywords they mention, earlier keywords counting more.
+func suggestMessages(words []string, limit int) []*suggestion {
+	byMessage := map[string]*suggestion{}
+	for _, word := range words {
+		result, err := cachedCrawl(word, 1, 0)
+		if err != nil {
+			continue
+		}
+		for _, c := range result.Commits {
+			if _, ok := byMessage[c.Message]; !ok {
+				byMessage[c.Message] = &suggestion{commit: c}
+			}
+		}
+	}
+
+	suggestions := []*suggestion{}
+	for _, s := range byMessage {
+		message := strings.ToLower(s.Message)
+		for i, word := range words {
+			if strings.Contains(message, word) {
+				s.Score += len(words) - i
+			}
+		}
+		suggestions = append(suggestions, s)
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return len(suggestions[i].Message) < len(suggestions[j].Message)
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var syncCommand = cli.Command{
+	Name:      "sync",
+	Usage:     "crawl and store every page for a set of keywords",
+	ArgsUsage: "[keyword...]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "keywords-file",
+			Usage: "file with one keyword per line (# starts a comment)",
+		},
+		cli.StringFlag{
+			Name:  "pages",
+			Value: "all",
+			Usage: "pages to crawl per keyword: all, N or FIRST-LAST",
+		},
+		cli.DurationFlag{
+			Name:  "delay",
+			Value: 2 * time.Second,
+			Usage: "politeness delay between requests",
+		},
+		cli.DurationFlag{
+			Name:  "interval",
+			Usage: "repeat the sync with this interval instead of running once",
+		},
+	},
+	Action: func(c *cli.Context) {
+		keywords := []string(c.Args())
+		if path := c.String("keywords-file"); path != "" {
+			fromFile, err := readKeywordsFile(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			keywords = append(keywords, fromFile...)
+		}
+		if len(keywords) == 0 {
+			cli.ShowCommandHelp(c, "sync")
+			os.Exit(1)
+		}
+		pages, err := parsePageRange(c.String("pages"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		for {
+			syncKeywords(keywords, pages, c.Duration("delay"))
+			if c.Duration("interval") <= 0 || !pause(c.Duration("interval")) {
+				return
+			}
+		}
+	},
+}
+
+func syncKeywords(keywords []string, pages pageRange, delay time.Duration) {
+	breaker := &circuitBreaker{}
+	for i, keyword := range keywords {
+		if i > 0 && !pause(delay) {
+			return
+		}
+		commits, crawled := 0, 0
+		err := crawlPages(keyword, pages, delay, func(page int, result QueryResult) bool {
+			crawled++
+			commits += len(result.Commits)
+			return true
+		})
+		fmt.Printf("%s  %-30s %d pages, %d commits\n", time.Now().Format("2006-01-02 15:04:05"), keyword, crawled, commits)
+		if err != nil {
+			logger.Warn("sync failed", "keyword", keyword, "error", err)
+		}
+		if interrupted() {
+			return
+		}
+		if breaker.record(err) {
+			logger.Error("sync stopped", "error", breaker.err(i+1, len(keywords)))
+			return
+		}
+	}
+}
+
+func readKeywordsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readKeywords(f)
+}
+
+// readKeywords reads one keyword per line, skipping blank lines and
+// comments starting with #.
+func readKeywords(r io.Reader) ([]string, error) {
+	keywords := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			keywords = append(keywords, line)
+		}
+	}
+	return keywords, scanner.Err()
+}
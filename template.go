@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+)
+
+// writeCommitTemplate writes the first limit messages as commented examples
+// into a file usable as git's commit.template.
+func writeCommitTemplate(path, keyword string, commits []*commit, limit int) error {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "# Examples from commit-m for %q:\n", keyword)
+	for i, c := range commits {
+		if i >= limit {
+			break
+		}
+		fmt.Fprintf(&buf, "#   %s\n", c.Message)
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
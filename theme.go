@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+)
+
+type colorFunc func(format string, a ...interface{}) string
+
+// theme holds the colors used for the parts of the output.
+var theme = struct {
+	repo      colorFunc
+	sha1      colorFunc
+	highlight colorFunc
+}{
+	repo:      color.BlueString,
+	sha1:      color.CyanString,
+	highlight: color.YellowString,
+}
+
+var colorsByName = map[string]colorFunc{
+	"black":   color.New(color.FgBlack).SprintfFunc(),
+	"red":     color.RedString,
+	"green":   color.GreenString,
+	"yellow":  color.YellowString,
+	"blue":    color.BlueString,
+	"magenta": color.MagentaString,
+	"cyan":    color.CyanString,
+	"white":   color.WhiteString,
+	"bold":    color.New(color.Bold).SprintfFunc(),
+	"none":    fmt.Sprintf,
+}
+
+func colorByName(name string) (colorFunc, error) {
+	f, ok := colorsByName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown color: %s", name)
+	}
+	return f, nil
+}
+
+// setTheme replaces the colors named in the config.
+func setTheme(colors colorsConfig) error {
+	for _, part := range []struct {
+		name   string
+		target *colorFunc
+	}{
+		{colors.Repo, &theme.repo},
+		{colors.Sha1, &theme.sha1},
+		{colors.Highlight, &theme.highlight},
+	} {
+		if part.name == "" {
+			continue
+		}
+		f, err := colorByName(part.name)
+		if err != nil {
+			return err
+		}
+		*part.target = f
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/codegangsta/cli"
+)
+
+// caCertPath and insecureTLS are --ca-cert and --insecure-skip-verify,
+// kept for curlCommand.
+var (
+	caCertPath  string
+	insecureTLS bool
+)
+
+// setTLS trusts the certificates of --ca-cert in addition to the system
+// ones, or disables verification with --insecure-skip-verify, for every
+// request made through the default transport.
+func setTLS(c *cli.Context) error {
+	return configureTLS(c.String("ca-cert"), c.Bool("insecure-skip-verify"))
+}
+
+func configureTLS(caCert string, insecure bool) error {
+	caCertPath, insecureTLS = caCert, insecure
+	if caCertPath == "" && !insecureTLS {
+		return nil
+	}
+	t := baseTransport
+	config := &tls.Config{}
+	if t.TLSClientConfig != nil {
+		config = t.TLSClientConfig.Clone()
+	}
+	if caCertPath != "" {
+		pem, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s: no PEM certificates found", caCertPath)
+		}
+		config.RootCAs = pool
+	}
+	if insecureTLS {
+		fmt.Fprintln(os.Stderr, tr("warning: TLS certificates are not verified"))
+		config.InsecureSkipVerify = true
+	}
+	t.TLSClientConfig = config
+	return nil
+}
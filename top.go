@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+)
+
+type duplicateMessage struct {
+	Message      string `json:"message"`
+	Commits      int    `json:"commits"`
+	Repositories int    `json:"repositories"`
+}
+
+var topCommand = cli.Command{
+	Name:      "top",
+	Usage:     "report the messages matching a keyword most often used verbatim",
+	ArgsUsage: "keyword",
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "top, n",
+			Value: 20,
+			Usage: "number of messages reported",
+		},
+	}, analysisFlags...),
+	Action: func(c *cli.Context) {
+		keyword := analysisKeyword(c, "top")
+		commits, err := analysedCommits(c, keyword)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			if len(commits) == 0 {
+				os.Exit(failureExitCode(err))
+			}
+		}
+
+		duplicates := duplicateMessages(commits)
+		if n := c.Int("top"); n > 0 && len(duplicates) > n {
+			duplicates = duplicates[:n]
+		}
+		if c.Bool("json") {
+			printJSON(map[string]interface{}{"keyword": keyword, "commits": len(commits), "messages": duplicates})
+			return
+		}
+		if len(duplicates) == 0 {
+			fmt.Println(tr("No Results Found."))
+			return
+		}
+		for i, d := range duplicates {
+			fmt.Fprintf(color.Output, "%3d. %5d repos %5d commits  %s\n", i+1, d.Repositories, d.Commits, highlightWords(d.Message, keyword))
+		}
+	},
+}
+
+// duplicateMessages counts the commits and repositories of each message
+// used more than once, the messages used by the most repositories first.
+func duplicateMessages(commits []*commit) []duplicateMessage {
+	counts := map[string]int{}
+	repos := map[string]map[string]bool{}
+	for _, c := range commits {
+		message := strings.TrimSpace(c.Message)
+		counts[message]++
+		if repos[message] == nil {
+			repos[message] = map[string]bool{}
+		}
+		repos[message][c.Repo] = true
+	}
+	duplicates := []duplicateMessage{}
+	for message, n := range counts {
+		if n > 1 {
+			duplicates = append(duplicates, duplicateMessage{Message: message, Commits: n, Repositories: len(repos[message])})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		a, b := duplicates[i], duplicates[j]
+		if a.Repositories != b.Repositories {
+			return a.Repositories > b.Repositories
+		}
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Message < b.Message
+	})
+	return duplicates
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"time"
+)
+
+// traceEnabled is set by --trace.
+var traceEnabled bool
+
+func tracef(format string, args ...interface{}) {
+	if traceEnabled {
+		fmt.Fprintf(os.Stderr, "trace: "+format+"\n", args...)
+	}
+}
+
+// traceTransport reports the DNS, connect, TLS, time to first byte and
+// total time of each request. The total includes reading the body, so it is
+// reported when the body is closed.
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+type requestTiming struct {
+	start                            time.Time
+	dnsStart, connectStart, tlsStart time.Time
+	dns, connect, tls, ttfb          time.Duration
+	reused                           bool
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timing := &requestTiming{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { timing.dnsStart = time.Now() },
+		DNSDone:  func(httptrace.DNSDoneInfo) { timing.dns = time.Since(timing.dnsStart) },
+		ConnectStart: func(string, string) {
+			timing.connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			timing.connect = time.Since(timing.connectStart)
+		},
+		TLSHandshakeStart: func() { timing.tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			timing.tls = time.Since(timing.tlsStart)
+		},
+		GotConn:              func(info httptrace.GotConnInfo) { timing.reused = info.Reused },
+		GotFirstResponseByte: func() { timing.ttfb = time.Since(timing.start) },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		tracef("%s %s: %s after %s", req.Method, req.URL, err, ms(time.Since(timing.start)))
+		return nil, err
+	}
+	conn := "new connection"
+	if timing.reused {
+		conn = "reused connection"
+	}
+	res.Body = &tracedBody{ReadCloser: res.Body, done: func() {
+		tracef("%s %s: %s %s, dns %s, connect %s, tls %s, ttfb %s, total %s",
+			req.Method, req.URL, res.Proto, conn, ms(timing.dns), ms(timing.connect), ms(timing.tls),
+			ms(timing.ttfb), ms(time.Since(timing.start)))
+	}}
+	return res, nil
+}
+
+type tracedBody struct {
+	io.ReadCloser
+	done   func()
+	closed bool
+}
+
+func (b *tracedBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.done()
+	}
+	return b.ReadCloser.Close()
+}
+
+func ms(d time.Duration) string {
+	return d.Round(time.Millisecond / 10).String()
+}
+
+func enableTrace() {
+	traceEnabled = true
+	http.DefaultTransport = &traceTransport{base: http.DefaultTransport}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"strings"
+)
+
+// romaji is the Hepburn spelling of the hiragana. Katakana is converted to
+// hiragana before the lookup. Kanji have no spelling here and are kept as
+// they are.
+var romaji = map[string]string{
+	"あ": "a", "い": "i", "う": "u", "え": "e", "お": "o",
+	"か": "ka", "き": "ki", "く": "ku", "け": "ke", "こ": "ko",
+	"さ": "sa", "し": "shi", "す": "su", "せ": "se", "そ": "so",
+	"た": "ta", "ち": "chi", "つ": "tsu", "て": "te", "と": "to",
+	"な": "na", "に": "ni", "ぬ": "nu", "ね": "ne", "の": "no",
+	"は": "ha", "ひ": "hi", "ふ": "fu", "へ": "he", "ほ": "ho",
+	"ま": "ma", "み": "mi", "む": "mu", "め": "me", "も": "mo",
+	"や": "ya", "ゆ": "yu", "よ": "yo",
+	"ら": "ra", "り": "ri", "る": "ru", "れ": "re", "ろ": "ro",
+	"わ": "wa", "を": "wo", "ん": "n",
+	"が": "ga", "ぎ": "gi", "ぐ": "gu", "げ": "ge", "ご": "go",
+	"ざ": "za", "じ": "ji", "ず": "zu", "ぜ": "ze", "ぞ": "zo",
+	"だ": "da", "ぢ": "ji", "づ": "zu", "で": "de", "ど": "do",
+	"ば": "ba", "び": "bi", "ぶ": "bu", "べ": "be", "ぼ": "bo",
+	"ぱ": "pa", "ぴ": "pi", "ぷ": "pu", "ぺ": "pe", "ぽ": "po",
+	"ゔ":  "vu",
+	"きゃ": "kya", "きゅ": "kyu", "きょ": "kyo",
+	"しゃ": "sha", "しゅ": "shu", "しょ": "sho", "しぇ": "she",
+	"ちゃ": "cha", "ちゅ": "chu", "ちょ": "cho", "ちぇ": "che",
+	"にゃ": "nya", "にゅ": "nyu", "にょ": "nyo",
+	"ひゃ": "hya", "ひゅ": "hyu", "ひょ": "hyo",
+	"みゃ": "mya", "みゅ": "myu", "みょ": "myo",
+	"りゃ": "rya", "りゅ": "ryu", "りょ": "ryo",
+	"ぎゃ": "gya", "ぎゅ": "gyu", "ぎょ": "gyo",
+	"じゃ": "ja", "じゅ": "ju", "じょ": "jo", "じぇ": "je",
+	"びゃ": "bya", "びゅ": "byu", "びょ": "byo",
+	"ぴゃ": "pya", "ぴゅ": "pyu", "ぴょ": "pyo",
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo",
+	"てぃ": "ti", "でぃ": "di", "うぃ": "wi", "うぇ": "we",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+}
+
+// kana is the reverse of romaji, with common alternative spellings.
+var kana = func() map[string]string {
+	m := map[string]string{"si": "し", "ti": "ち", "tu": "つ", "hu": "ふ", "zi": "じ"}
+	for k, r := range romaji {
+		if _, ok := m[r]; !ok && k != "ぢ" && k != "づ" {
+			m[r] = k
+		}
+	}
+	return m
+}()
+
+func katakanaToHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - 0x60
+	}
+	return r
+}
+
+func hiraganaToKatakana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ぁ' && r <= 'ゖ' {
+			return r + 0x60
+		}
+		return r
+	}, s)
+}
+
+// toRomaji spells the kana in s in romaji. ok is false when s has no kana.
+func toRomaji(s string) (string, bool) {
+	runes := []rune(s)
+	var b strings.Builder
+	converted := false
+	double := false
+	// vowel is the vowel the last kana ended with, repeated by ー, and
+	// kana whether the last rune was one
+	var vowel byte
+	kana := false
+	for i := 0; i < len(runes); i++ {
+		r := katakanaToHiragana(runes[i])
+		if r == 'っ' {
+			double = true
+			converted = true
+			continue
+		}
+		if r == 'ー' {
+			if vowel != 0 {
+				b.WriteByte(vowel)
+			} else if !kana {
+				b.WriteRune(runes[i])
+			}
+			continue
+		}
+		spelling := ""
+		if i+1 < len(runes) {
+			spelling = romaji[string([]rune{r, katakanaToHiragana(runes[i+1])})]
+			if spelling != "" {
+				i++
+			}
+		}
+		if spelling == "" {
+			spelling = romaji[string(r)]
+		}
+		if spelling == "" {
+			b.WriteRune(runes[i])
+			double = false
+			vowel, kana = 0, false
+			continue
+		}
+		converted = true
+		if double {
+			if strings.HasPrefix(spelling, "ch") {
+				b.WriteByte('t')
+			} else {
+				b.WriteByte(spelling[0])
+			}
+			double = false
+		}
+		b.WriteString(spelling)
+		vowel, kana = 0, true
+		if last := spelling[len(spelling)-1]; strings.IndexByte("aiueo", last) >= 0 {
+			vowel = last
+		}
+	}
+	return b.String(), converted
+}
+
+// toHiragana spells the romaji s in hiragana. ok is false unless all of s
+// reads as romaji, so English words are left alone.
+func toHiragana(s string) (string, bool) {
+	s = strings.ToLower(s)
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == ' ' {
+			b.WriteByte(' ')
+			i++
+			continue
+		}
+		if s[i] < 'a' || s[i] > 'z' {
+			return "", false
+		}
+		// doubled consonant: kitte -> きって, matcha -> まっちゃ
+		if i+1 < len(s) && (s[i] == s[i+1] && !strings.ContainsRune("aiueon", rune(s[i])) || strings.HasPrefix(s[i:], "tch")) {
+			b.WriteString("っ")
+			i++
+			continue
+		}
+		matched := false
+		for n := 3; n >= 1; n-- {
+			if i+n <= len(s) {
+				if k, ok := kana[s[i:i+n]]; ok {
+					b.WriteString(k)
+					i += n
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// keywordVariants returns the other spellings of keyword: romaji for
+// keywords with kana, and hiragana and katakana for romaji keywords.
+func keywordVariants(keyword string) []string {
+	if r, ok := toRomaji(keyword); ok {
+		return []string{r}
+	}
+	if h, ok := toHiragana(keyword); ok {
+		return []string{h, hiraganaToKatakana(h)}
+	}
+	return nil
+}
+
+// mergeVariants adds the commits found for the other spellings of keyword
+// to result. The counts of the original search are kept.
+func mergeVariants(result QueryResult, keyword string, fetch func(string) (QueryResult, error)) QueryResult {
+	seen := map[string]bool{}
+	for _, c := range result.Commits {
+		seen[commitKey(c)] = true
+	}
+	for _, variant := range keywordVariants(keyword) {
+		debugf("transliterated %q to %q", keyword, variant)
+		other, err := fetch(variant)
+		if err != nil {
+			logger.Warn("search of transliteration failed", "keyword", variant, "error", err)
+			continue
+		}
+		for _, c := range other.Commits {
+			if !seen[commitKey(c)] {
+				seen[commitKey(c)] = true
+				result.Commits = append(result.Commits, c)
+			}
+		}
+	}
+	return result
+}
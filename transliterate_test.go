@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToRomaji(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+		ok       bool
+	}{
+		{"ねこ", "neko", true},
+		{"キャッシュ", "kyasshu", true},
+		{"まっちゃ", "matcha", true},
+		{"サーバー", "saabaa", true},
+		// ー only lengthens a vowel
+		{"ラーメンー", "raamen", true},
+		{"修正ー", "修正ー", false},
+		{"修正しました", "修正shimashita", true},
+		{"バグ修正", "bagu修正", true},
+		// no kana: kept as it is
+		{"修正", "修正", false},
+		{"typo", "typo", false},
+		{"café", "café", false},
+	} {
+		got, ok := toRomaji(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("toRomaji(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestToHiragana(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+		ok       bool
+	}{
+		{"neko", "ねこ", true},
+		{"Sushi", "すし", true},
+		{"kitte", "きって", true},
+		{"matcha", "まっちゃ", true},
+		{"tsu", "つ", true},
+		{"tu", "つ", true},
+		{"kyasshu", "きゃっしゅ", true},
+		{"bagu shuusei", "ばぐ しゅうせい", true},
+		// not romaji
+		{"typo", "", false},
+		{"café", "", false},
+		{"fix-123", "", false},
+	} {
+		got, ok := toHiragana(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("toHiragana(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKeywordVariants(t *testing.T) {
+	for _, tt := range []struct {
+		keyword string
+		want    []string
+	}{
+		{"ねこ", []string{"neko"}},
+		{"ネコ", []string{"neko"}},
+		{"neko", []string{"ねこ", "ネコ"}},
+		{"修正", nil},
+		{"refactor", nil},
+		{"naïve", nil},
+	} {
+		if got := keywordVariants(tt.keyword); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("keywordVariants(%q) = %q, want %q", tt.keyword, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+)
+
+type typoCount struct {
+	Misspelling string `json:"misspelling"`
+	Correction  string `json:"correction"`
+	Results     int    `json:"results"`
+}
+
+var typosCommand = cli.Command{
+	Name:  "typos",
+	Usage: "search the common misspellings and rank them by the number of commits making them",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "top, n",
+			Value: 0,
+			Usage: "number of misspellings reported, 0 for all",
+		},
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: lintCacheTTL,
+			Usage: "reuse cached pages fetched within this duration",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the report as json",
+		},
+	},
+	Action: func(c *cli.Context) {
+		typos, err := sweepTypos(c.Duration("cache-ttl"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			if len(typos) == 0 {
+				os.Exit(failureExitCode(err))
+			}
+		}
+		if n := c.Int("top"); n > 0 && len(typos) > n {
+			typos = typos[:n]
+		}
+		if c.Bool("json") {
+			printJSON(typos)
+			return
+		}
+
+		width, total := 0, 0
+		for _, t := range typos {
+			if w := runewidth.StringWidth(t.Misspelling + " → " + t.Correction); w > width {
+				width = w
+			}
+			total += t.Results
+		}
+		for i, t := range typos {
+			pad := strings.Repeat(" ", width-runewidth.StringWidth(t.Misspelling+" → "+t.Correction))
+			fmt.Fprintf(color.Output, "%3d. %s → %s%s %8d  %s\n",
+				i+1, theme.highlight("%s", t.Misspelling), t.Correction, pad, t.Results, bar(t.Results, typos[0].Results, 30))
+		}
+		fmt.Printf("\n%d commits with one of %d misspellings\n", total, len(typos))
+	},
+}
+
+// sweepTypos looks up every common misspelling, most results first. The
+// misspellings that could not be searched are reported in the error.
+func sweepTypos(ttl time.Duration) ([]typoCount, error) {
+	words := make([]string, 0, len(commonMisspellings))
+	for word := range commonMisspellings {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	typos := []typoCount{}
+	failed := 0
+	var lastErr error
+	breaker := &circuitBreaker{}
+	for i, word := range words {
+		fmt.Fprintf(os.Stderr, "\r%d/%d %-20s", i+1, len(words), word)
+		if _, cached := cachedResult(buildUrl(word, 1), ttl); !cached && i > 0 && !pause(allPagesDelay) {
+			break
+		}
+		result, err := cachedCrawl(word, 1, ttl)
+		if breaker.record(err) {
+			fmt.Fprintln(os.Stderr)
+			sortTypos(typos)
+			return typos, breaker.err(i+1, len(words))
+		}
+		if err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		n := parseResultCount(result.ResultCount)
+		if n == 0 {
+			n = len(result.Commits)
+		}
+		typos = append(typos, typoCount{Misspelling: word, Correction: commonMisspellings[word], Results: n})
+	}
+	fmt.Fprintln(os.Stderr)
+
+	sortTypos(typos)
+	if failed > 0 {
+		return typos, fmt.Errorf("%d misspellings could not be searched: %s", failed, lastErr)
+	}
+	return typos, nil
+}
+
+func sortTypos(typos []typoCount) {
+	sort.SliceStable(typos, func(i, j int) bool {
+		return typos[i].Results > typos[j].Results
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/codegangsta/cli"
+)
+
+// Build metadata, set with
+//
+//	go build -ldflags "-X main.version=1.2.3 -X main.buildCommit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// When they are not set, the VCS information recorded by the Go toolchain is
+// used instead.
+var (
+	version     = "0.0.0"
+	buildCommit = ""
+	buildDate   = ""
+)
+
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+func currentBuild() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		Commit:    buildCommit,
+		Date:      buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	return info
+}
+
+var versionCommand = cli.Command{
+	Name:  "version",
+	Usage: "print the version and build information",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "output as json",
+		},
+	},
+	Action: func(c *cli.Context) {
+		info := currentBuild()
+		if c.Bool("json") {
+			if err := json.NewEncoder(os.Stdout).Encode(info); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Printf("gommit-m %s\n", info.Version)
+		if info.Commit != "" {
+			fmt.Printf("commit:   %s\n", info.Commit)
+		}
+		if info.Date != "" {
+			fmt.Printf("built:    %s\n", info.Date)
+		}
+		fmt.Printf("go:       %s %s\n", info.GoVersion, info.Platform)
+	},
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/fatih/color"
+	"github.com/robfig/cron/v3"
+)
+
+// watchNotifyFlags choose what is done with new commits, and are passed
+// on by watch install.
+var watchNotifyFlags = append([]cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "exclude, x",
+		Value: &cli.StringSlice{},
+		Usage: "exclude commits whose message contains the word",
+	},
+	cli.BoolFlag{
+		Name:  "desktop-notify",
+		Usage: "show a desktop notification when new commits are found",
+	},
+	cli.StringSliceFlag{
+		Name:  "email-to",
+		Value: &cli.StringSlice{},
+		Usage: "mail a digest of new commits to the address, using [smtp] in the config (repeatable)",
+	},
+}, append(webhookFlags, chatFlags...)...)
+
+var watchCommand = cli.Command{
+	Name:      "watch",
+	Usage:     "re-run a search periodically and print only new commits, or without a keyword every [[watch]] of the config",
+	ArgsUsage: "keyword [page]",
+	Flags: append([]cli.Flag{
+		cli.DurationFlag{
+			Name:  "interval",
+			Value: time.Hour,
+			Usage: "time between searches",
+		},
+		cli.StringFlag{
+			Name:  "cron",
+			Usage: "search on a cron schedule instead, e.g. \"0 9 * * MON\" or @daily",
+		},
+		cli.BoolFlag{
+			Name:  "once",
+			Usage: "search once, for new commits since the last --once run, as run by watch install",
+		},
+	}, watchNotifyFlags...),
+	Subcommands: []cli.Command{
+		watchInstallCommand,
+		watchUninstallCommand,
+	},
+	Action: func(c *cli.Context) {
+		keyword := c.Args().First()
+		if keyword == "" && len(watchSettings) > 0 && !c.Bool("once") {
+			runConfigWatches()
+			return
+		}
+		if keyword == "" {
+			cli.ShowCommandHelp(c, "watch")
+			os.Exit(1)
+		}
+		page := parsePage(c.Args().Get(1))
+
+		if c.IsSet("interval") && c.IsSet("cron") {
+			fmt.Fprintln(os.Stderr, "--interval and --cron cannot be used together")
+			os.Exit(1)
+		}
+		schedule, err := watchSchedule(c.Duration("interval"), c.String("cron"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		w := &watcher{
+			keyword: keyword,
+			page:    page,
+			exclude: c.StringSlice("exclude"),
+			seen:    map[string]bool{},
+			notify: &watchNotifier{
+				desktop:       c.Bool("desktop-notify"),
+				emailTo:       c.StringSlice("email-to"),
+				chats:         c.StringSlice("notify"),
+				chatCount:     c.Int("notify-count"),
+				webhook:       c.String("post-webhook"),
+				webhookSecret: c.String("webhook-secret"),
+			},
+		}
+		if c.Bool("once") {
+			fresh, first, err := w.pollSinceLast()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if !first {
+				w.notify.send(keyword, page, fresh)
+			}
+			return
+		}
+		if expr := c.String("cron"); expr != "" {
+			fmt.Printf("watching %q on %q\n", keyword, expr)
+		} else {
+			fmt.Printf("watching %q every %s\n", keyword, c.Duration("interval"))
+		}
+		w.run(schedule)
+	},
+}
+
+// watchConfig is a [[watch]] of the config, run with the others by watch
+// without a keyword.
+type watchConfig struct {
+	Keyword       string   `toml:"keyword"`
+	Page          int      `toml:"page"`
+	Interval      duration `toml:"interval"`
+	Cron          string   `toml:"cron"`
+	Exclude       []string `toml:"exclude"`
+	DesktopNotify bool     `toml:"desktop_notify"`
+	EmailTo       []string `toml:"email_to"`
+	Notify        []string `toml:"notify"`
+	NotifyCount   int      `toml:"notify_count"`
+	PostWebhook   string   `toml:"post_webhook"`
+	WebhookSecret string   `toml:"webhook_secret"`
+}
+
+var watchSettings []watchConfig
+
+// watchSchedule is the schedule of --interval, or of the cron expression
+// ("0 9 * * MON", "@daily") when given.
+func watchSchedule(interval time.Duration, expr string) (cron.Schedule, error) {
+	if expr != "" {
+		schedule, err := cron.ParseStandard(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", expr, err)
+		}
+		return schedule, nil
+	}
+	if interval < time.Second {
+		return nil, fmt.Errorf("interval must be at least a second")
+	}
+	return cron.Every(interval), nil
+}
+
+// runConfigWatches runs every [[watch]] of the config, each on its own
+// schedule, until interrupted.
+func runConfigWatches() {
+	watchers := make([]*watcher, len(watchSettings))
+	schedules := make([]cron.Schedule, len(watchSettings))
+	for i, wc := range watchSettings {
+		if wc.Keyword == "" {
+			fmt.Fprintf(os.Stderr, "[[watch]] %d of %s has no keyword\n", i+1, configFile)
+			os.Exit(1)
+		}
+		if wc.Interval.Duration != 0 && wc.Cron != "" {
+			fmt.Fprintf(os.Stderr, "[[watch]] %q of %s has both an interval and a cron\n", wc.Keyword, configFile)
+			os.Exit(1)
+		}
+		interval := wc.Interval.Duration
+		if interval == 0 {
+			interval = time.Hour
+		}
+		var err error
+		if schedules[i], err = watchSchedule(interval, wc.Cron); err != nil {
+			fmt.Fprintf(os.Stderr, "[[watch]] %q of %s: %v\n", wc.Keyword, configFile, err)
+			os.Exit(1)
+		}
+		page := wc.Page
+		if page < 1 {
+			page = 1
+		}
+		chatCount := wc.NotifyCount
+		if chatCount == 0 {
+			chatCount = 5
+		}
+		watchers[i] = &watcher{
+			keyword: wc.Keyword,
+			page:    page,
+			exclude: wc.Exclude,
+			seen:    map[string]bool{},
+			notify: &watchNotifier{
+				desktop:       wc.DesktopNotify,
+				emailTo:       wc.EmailTo,
+				chats:         wc.Notify,
+				chatCount:     chatCount,
+				webhook:       wc.PostWebhook,
+				webhookSecret: firstNonEmpty(wc.WebhookSecret, os.Getenv("GOMMITM_WEBHOOK_SECRET")),
+			},
+		}
+	}
+	fmt.Printf("watching %d searches of %s\n", len(watchers), configFile)
+	var wg sync.WaitGroup
+	for i, w := range watchers {
+		wg.Add(1)
+		go func(w *watcher, schedule cron.Schedule) {
+			defer wg.Done()
+			w.run(schedule)
+		}(w, schedules[i])
+	}
+	wg.Wait()
+}
+
+// watchNotifier is what is done with the new commits found by a watch,
+// set by watchNotifyFlags or a [[watch]] of the config.
+type watchNotifier struct {
+	desktop       bool
+	emailTo       []string
+	chats         []string
+	chatCount     int
+	webhook       string
+	webhookSecret string
+}
+
+func (n *watchNotifier) send(keyword string, page int, fresh []*commit) {
+	if n.desktop {
+		if err := notifyNewCommits(keyword, fresh); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	for _, target := range n.chats {
+		if err := notifyChat(target, keyword, fresh, n.chatCount); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	if len(n.emailTo) > 0 {
+		if err := sendDigest(n.emailTo, keyword, fresh); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	if n.webhook != "" {
+		if err := postWebhook(n.webhook, n.webhookSecret, keyword, page, fresh, nil); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+type watcher struct {
+	keyword string
+	page    int
+	exclude []string
+	seen    map[string]bool
+	notify  *watchNotifier
+}
+
+// run polls on the schedule until interrupted. The first poll only
+// establishes what has been seen already.
+func (w *watcher) run(schedule cron.Schedule) {
+	for first := true; ; first = false {
+		fresh := w.poll()
+		if !first {
+			w.notify.send(w.keyword, w.page, fresh)
+		}
+		select {
+		case <-time.After(time.Until(schedule.Next(time.Now()))):
+		case <-runContext.Done():
+			return
+		}
+	}
+}
+
+func commitKey(c *commit) string {
+	if c.Sha1 == "" {
+		return c.CommitURL
+	}
+	return c.Repo + "@" + c.Sha1
+}
+
+// poll runs the search once and prints commits not seen by earlier polls.
+func (w *watcher) poll() []*commit {
+	result, err := cachedCrawl(w.keyword, w.page, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil
+	}
+
+	fresh := []*commit{}
+	for _, c := range excludeCommits(result.Commits, w.exclude) {
+		if key := commitKey(c); !w.seen[key] {
+			w.seen[key] = true
+			fresh = append(fresh, c)
+		}
+	}
+	w.print(fresh)
+	return fresh
+}
+
+// pollSinceLast runs the search once and prints the commits not found by
+// the previous pollSinceLast of the search, kept on disk. first tells that
+// there was no previous one.
+func (w *watcher) pollSinceLast() (fresh []*commit, first bool, err error) {
+	result, err := cachedCrawl(w.keyword, w.page, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	query := fmt.Sprintf("watch\x00%s\x00%d", w.keyword, w.page)
+	if _, serr := os.Stat(lastSeenFile(query)); os.IsNotExist(serr) {
+		first = true
+	}
+	fresh, err = newSinceLast(query, excludeCommits(result.Commits, w.exclude))
+	if err != nil {
+		return nil, false, err
+	}
+	w.print(fresh)
+	return fresh, first, nil
+}
+
+func (w *watcher) print(fresh []*commit) {
+	for _, c := range fresh {
+		fmt.Fprintf(color.Output, "%s %s %s %s\n    %s\n",
+			time.Now().Format("2006-01-02 15:04:05"),
+			theme.repo(c.Repo),
+			theme.sha1(c.Sha1),
+			highlightWords(c.Message, w.keyword),
+			c.CommitURL,
+		)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+const launchdLabelPrefix = "com.github.yuroyoro.gommit-m.watch."
+
+var unitNameUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+var watchInstallCommand = cli.Command{
+	Name:      "install",
+	Usage:     "run the watch from a systemd user timer, or a launchd agent on macOS, that survives reboots",
+	ArgsUsage: "keyword [page]",
+	Flags: append([]cli.Flag{
+		cli.DurationFlag{
+			Name:  "interval",
+			Value: time.Hour,
+			Usage: "time between searches",
+		},
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "name of the watch (defaults to the keyword), for uninstall",
+		},
+		cli.BoolFlag{
+			Name:  "print",
+			Usage: "print the unit files instead of installing them",
+		},
+	}, watchNotifyFlags...),
+	Action: func(c *cli.Context) {
+		keyword := c.Args().First()
+		if keyword == "" {
+			cli.ShowCommandHelp(c, "install")
+			os.Exit(1)
+		}
+		interval := c.Duration("interval")
+		if interval < time.Minute {
+			fmt.Fprintln(os.Stderr, "interval must be at least a minute")
+			os.Exit(1)
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		args := append([]string{exe, "watch", "--once"}, flagArgs(c, watchNotifyFlags)...)
+		// flagArgs leaves out secrets, which the units, readable only by
+		// the user, need
+		for _, target := range c.StringSlice("notify") {
+			args = append(args, "--notify="+target)
+		}
+		if secret := c.String("webhook-secret"); secret != "" {
+			args = append(args, "--webhook-secret="+secret)
+		}
+		args = append(args, "--", keyword)
+		if page := c.Args().Get(1); page != "" {
+			args = append(args, page)
+		}
+		units := watchUnits(watchName(c.String("name"), keyword), args, interval)
+		if units == nil {
+			fmt.Fprintf(os.Stderr, "watch install is not supported on %s\n", runtime.GOOS)
+			os.Exit(1)
+		}
+		if c.Bool("print") {
+			for _, u := range units {
+				fmt.Printf("# %s\n%s\n", u.path, u.content)
+			}
+			return
+		}
+		if err := installUnits(units); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, u := range units {
+			fmt.Println("installed", u.path)
+		}
+	},
+}
+
+var watchUninstallCommand = cli.Command{
+	Name:      "uninstall",
+	Usage:     "stop and remove a watch installed by watch install",
+	ArgsUsage: "name",
+	Action: func(c *cli.Context) {
+		name := c.Args().First()
+		if name == "" {
+			cli.ShowCommandHelp(c, "uninstall")
+			os.Exit(1)
+		}
+		units := watchUnits(watchName(name, ""), nil, 0)
+		if units == nil {
+			fmt.Fprintf(os.Stderr, "watch install is not supported on %s\n", runtime.GOOS)
+			os.Exit(1)
+		}
+		if err := uninstallUnits(units); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, u := range units {
+			fmt.Println("removed", u.path)
+		}
+	},
+}
+
+// watchName is the name the units of a watch are installed under.
+func watchName(name, keyword string) string {
+	if name == "" {
+		name = keyword
+	}
+	return strings.Trim(unitNameUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+type unitFile struct {
+	path    string
+	content string
+}
+
+// watchUnits are the files running args every interval: a systemd service
+// and its timer, or a launchd agent. It returns nil on other systems.
+func watchUnits(name string, args []string, interval time.Duration) []unitFile {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			dir = filepath.Join(homeDir(), ".config")
+		}
+		unit := filepath.Join(dir, "systemd", "user", "gommit-m-watch-"+name)
+		return []unitFile{
+			{unit + ".service", systemdService(name, args)},
+			{unit + ".timer", systemdTimer(name, interval)},
+		}
+	case "darwin":
+		label := launchdLabelPrefix + name
+		return []unitFile{
+			{filepath.Join(homeDir(), "Library", "LaunchAgents", label+".plist"), launchdPlist(label, args, interval)},
+		}
+	}
+	return nil
+}
+
+func systemdService(name string, args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = systemdQuote(arg)
+	}
+	return fmt.Sprintf(`[Unit]
+Description=gommit-m watch %s
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, name, strings.Join(quoted, " "))
+}
+
+func systemdTimer(name string, interval time.Duration) string {
+	return fmt.Sprintf(`[Unit]
+Description=gommit-m watch %s every %s
+
+[Timer]
+OnBootSec=1min
+OnUnitActiveSec=%ds
+
+[Install]
+WantedBy=timers.target
+`, name, interval, int(interval.Seconds()))
+}
+
+// systemdQuote quotes the argument for ExecStart, where % and $ are
+// expanded and whitespace splits arguments.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+func launchdPlist(label string, args []string, interval time.Duration) string {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>`)
+	xml.EscapeText(&b, []byte(label))
+	b.WriteString("</string>\n\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range args {
+		b.WriteString("\t\t<string>")
+		xml.EscapeText(&b, []byte(arg))
+		b.WriteString("</string>\n")
+	}
+	fmt.Fprintf(&b, `	</array>
+	<key>StartInterval</key>
+	<integer>%d</integer>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`, int(interval.Seconds()))
+	return b.String()
+}
+
+// installUnits writes the units, readable only by the user as they hold
+// the notifier flags, and enables them.
+func installUnits(units []unitFile) error {
+	for _, u := range units {
+		if err := os.MkdirAll(filepath.Dir(u.path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(u.path, []byte(u.content), 0600); err != nil {
+			return err
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		// reloading replaces an agent installed before
+		exec.Command("launchctl", "unload", units[0].path).Run()
+		return runUnitCommand("launchctl", "load", "-w", units[0].path)
+	}
+	if err := runUnitCommand("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return runUnitCommand("systemctl", "--user", "enable", "--now", filepath.Base(units[1].path))
+}
+
+func uninstallUnits(units []unitFile) error {
+	if _, err := os.Stat(units[0].path); err != nil {
+		return err
+	}
+	if runtime.GOOS == "darwin" {
+		if err := runUnitCommand("launchctl", "unload", "-w", units[0].path); err != nil {
+			return err
+		}
+		return os.Remove(units[0].path)
+	}
+	if err := runUnitCommand("systemctl", "--user", "disable", "--now", filepath.Base(units[1].path)); err != nil {
+		return err
+	}
+	for _, u := range units {
+		if err := os.Remove(u.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return runUnitCommand("systemctl", "--user", "daemon-reload")
+}
+
+func runUnitCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %v", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
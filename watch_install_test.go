@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSystemdQuote(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"--once", "--once"},
+		{"fix typo", `"fix typo"`},
+		{"100%", "100%%"},
+		{"$HOME", "$$HOME"},
+		{`say "hi"`, `"say \"hi\""`},
+		{"", `""`},
+	} {
+		if got := systemdQuote(tt.in); got != tt.want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWatchName(t *testing.T) {
+	if got := watchName("", "Fix typo: README!"); got != "fix-typo-readme" {
+		t.Errorf("watchName = %q", got)
+	}
+	if got := watchName("daily", "fix typo"); got != "daily" {
+		t.Errorf("watchName with a name = %q", got)
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	plist := launchdPlist(launchdLabelPrefix+"typo", []string{"/usr/local/bin/gommit-m", "watch", "--once", "--", "a & b"}, time.Hour)
+	for _, want := range []string{
+		"<string>com.github.yuroyoro.gommit-m.watch.typo</string>",
+		"<string>a &amp; b</string>",
+		"<integer>3600</integer>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist has no %s:\n%s", want, plist)
+		}
+	}
+}
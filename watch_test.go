@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchSchedule(t *testing.T) {
+	now := time.Date(2026, 10, 14, 18, 0, 0, 0, time.Local) // a Wednesday
+	for _, tt := range []struct {
+		interval time.Duration
+		cron     string
+		want     time.Time
+	}{
+		{time.Hour, "", now.Add(time.Hour)},
+		{time.Hour, "0 9 * * MON", time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)},
+		{0, "@daily", time.Date(2026, 10, 15, 0, 0, 0, 0, time.Local)},
+	} {
+		schedule, err := watchSchedule(tt.interval, tt.cron)
+		if err != nil {
+			t.Errorf("watchSchedule(%s, %q): %v", tt.interval, tt.cron, err)
+			continue
+		}
+		if got := schedule.Next(now); !got.Equal(tt.want) {
+			t.Errorf("watchSchedule(%s, %q).Next = %s, want %s", tt.interval, tt.cron, got, tt.want)
+		}
+	}
+	for _, expr := range []string{"0 9 * *", "0 9 * * XYZ"} {
+		if _, err := watchSchedule(time.Hour, expr); err == nil {
+			t.Errorf("watchSchedule(%q) succeeded", expr)
+		}
+	}
+	if _, err := watchSchedule(0, ""); err == nil {
+		t.Error("watchSchedule with no interval succeeded")
+	}
+}
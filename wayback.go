@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+const waybackAvailabilityAPI = "https://archive.org/wayback/available"
+
+type waybackAvailability struct {
+	ArchivedSnapshots struct {
+		Closest *struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Status    string `json:"status"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// waybackSnapshot returns the url of the closest Internet Archive snapshot
+// of the page, or "" when it was never archived.
+func waybackSnapshot(client *http.Client, pageURL string) (string, error) {
+	res, err := client.Get(waybackAvailabilityAPI + "?url=" + url.QueryEscape(pageURL))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	availability := waybackAvailability{}
+	if err := json.NewDecoder(res.Body).Decode(&availability); err != nil {
+		return "", err
+	}
+	closest := availability.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available {
+		return "", nil
+	}
+	return closest.URL, nil
+}
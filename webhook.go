@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+var webhookFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "post-webhook",
+		Usage: "POST the results as JSON to the url",
+	},
+	cli.StringFlag{
+		Name:   "webhook-secret",
+		EnvVar: "GOMMITM_WEBHOOK_SECRET",
+		Usage:  "sign webhook payloads with HMAC-SHA256 in the X-Gommitm-Signature header",
+	},
+}
+
+type webhookPayload struct {
+	Keyword string    `json:"keyword"`
+	Page    int       `json:"page"`
+	Sent    time.Time `json:"sent"`
+	Commits []*commit `json:"commits"`
+	Error   string    `json:"error"`
+}
+
+// postWebhook sends the commits found for keyword. With a secret, the
+// body is signed as "sha256=<hex hmac>" like GitHub webhooks.
+func postWebhook(url, secret, keyword string, page int, commits []*commit, searchErr error) error {
+	payload := &webhookPayload{Keyword: keyword, Page: page, Sent: time.Now(), Commits: commits}
+	if searchErr != nil {
+		payload.Error = searchErr.Error()
+	}
+	if payload.Commits == nil {
+		payload.Commits = []*commit{}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Gommitm-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook: POST %s: %s", url, res.Status)
+	}
+	return nil
+}
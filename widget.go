@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// zshWidget is the zle widget printed by widget --init zsh. It escapes the
+// message for the double quotes of `git commit -m "`.
+const zshWidget = `# gommit-m widget: %[1]s inserts a commit message found by gommit-m
+gommit-m-widget() {
+  local msg
+  zle -I
+  msg="$(gommit-m widget </dev/tty)"
+  if [[ -n $msg ]]; then
+    msg=${msg//\\/\\\\}
+    msg=${msg//\"/\\\"}
+    msg=${msg//\$/\\\$}
+    msg=${msg//\` + "`" + `/\\\` + "`" + `}
+    LBUFFER+=$msg
+  fi
+  zle reset-prompt
+}
+zle -N gommit-m-widget
+bindkey '%[1]s' gommit-m-widget
+`
+
+// tmuxPopup is the binding printed by widget --init tmux. It types the
+// message into the pane the popup was opened from.
+const tmuxPopup = `# gommit-m widget: prefix %[1]s types a commit message found by gommit-m
+bind-key %[1]s display-popup -E -w 80%% -h 60%% 'msg="$(gommit-m widget)" && tmux send-keys -t "#{pane_id}" -l -- "$msg"'
+`
+
+var widgetCommand = cli.Command{
+	Name:      "widget",
+	Usage:     "pick a result and print only its message, for shell and tmux key bindings (see --init)",
+	ArgsUsage: "[keyword]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "init",
+			Usage: "print the zle widget (zsh) or popup binding (tmux) running widget, for .zshrc or tmux.conf",
+		},
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "key bound by --init (default ^Xm for zsh, m for tmux)",
+		},
+		cli.DurationFlag{
+			Name:  "cache-ttl",
+			Value: time.Hour,
+			Usage: "reuse cached pages fetched within this duration",
+		},
+	},
+	Action: func(c *cli.Context) {
+		switch shell := c.String("init"); shell {
+		case "":
+		case "zsh":
+			fmt.Printf(zshWidget, firstNonEmpty(c.String("key"), "^Xm"))
+			return
+		case "tmux":
+			fmt.Printf(tmuxPopup, firstNonEmpty(c.String("key"), "m"))
+			return
+		default:
+			fmt.Fprintf(os.Stderr, "unknown --init %q, zsh or tmux\n", shell)
+			os.Exit(exitUsage)
+		}
+
+		// stdout is read by the binding, so the picker runs on the terminal
+		var in io.Reader = os.Stdin
+		var out io.Writer = os.Stderr
+		width := 80
+		if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+			defer tty.Close()
+			in, out = tty, tty
+			if w, _, err := term.GetSize(int(tty.Fd())); err == nil {
+				width = w
+			}
+		}
+
+		keyword := strings.Join(c.Args(), " ")
+		if keyword == "" {
+			fmt.Fprint(out, "keyword: ")
+			line, _ := readLine(in)
+			if keyword = strings.TrimSpace(line); keyword == "" {
+				os.Exit(1)
+			}
+		}
+		result, err := cachedCrawl(keyword, 1, c.Duration("cache-ttl"))
+		if err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(failureExitCode(err))
+		}
+		if len(result.Commits) == 0 {
+			fmt.Fprintln(out, tr("No Results Found."))
+			os.Exit(1)
+		}
+		numWidth := len(fmt.Sprint(len(result.Commits)))
+		for i, commit := range result.Commits {
+			line := fmt.Sprintf("%*d  %s", numWidth, i+1, firstLine(commit.Message))
+			fmt.Fprintln(out, runewidth.Truncate(line, width-1, "…"))
+		}
+		selected := pickCommitFrom(in, out, result.Commits)
+		if selected == nil {
+			os.Exit(1)
+		}
+		fmt.Println(selected.Message)
+	},
+}
+
+// readLine reads up to a newline one byte at a time, leaving the rest of
+// the input for the picker.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
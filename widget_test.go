@@ -0,0 +1,22 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestPickCommitFrom(t *testing.T) {
+	commits := []*commit{{Message: "fix typo"}, {Message: "fix typo in docs"}}
+	in := strings.NewReader("typo\n3\n2\n")
+	keyword, err := readLine(in)
+	if err != nil || keyword != "typo" {
+		t.Fatalf("readLine = %q, %v", keyword, err)
+	}
+	if got := pickCommitFrom(in, ioutil.Discard, commits); got != commits[1] {
+		t.Errorf("picked %+v, want the second commit", got)
+	}
+	if got := pickCommitFrom(strings.NewReader("q\n"), ioutil.Discard, commits); got != nil {
+		t.Errorf("picked %+v after quitting", got)
+	}
+}